@@ -0,0 +1,626 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: ogree.proto
+
+package api
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// OgreeClient is the client API for Ogree service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type OgreeClient interface {
+	// Objects of the hierarchy and stray objects
+	CreateObject(ctx context.Context, in *CreateObjectRequest, opts ...grpc.CallOption) (*Object, error)
+	GetObject(ctx context.Context, in *GetObjectRequest, opts ...grpc.CallOption) (*Object, error)
+	UpdateObject(ctx context.Context, in *UpdateObjectRequest, opts ...grpc.CallOption) (*Object, error)
+	DeleteObject(ctx context.Context, in *GetObjectRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListObjects(ctx context.Context, in *ListObjectsRequest, opts ...grpc.CallOption) (Ogree_ListObjectsClient, error)
+	// Sends an event each time an object is created, updated or deleted
+	WatchObjects(ctx context.Context, in *WatchObjectsRequest, opts ...grpc.CallOption) (Ogree_WatchObjectsClient, error)
+	// Creates the objects in the order they are sent
+	BulkCreateObjects(ctx context.Context, opts ...grpc.CallOption) (Ogree_BulkCreateObjectsClient, error)
+	// Templates
+	CreateTemplate(ctx context.Context, in *Template, opts ...grpc.CallOption) (*Template, error)
+	GetTemplate(ctx context.Context, in *GetTemplateRequest, opts ...grpc.CallOption) (*Template, error)
+	UpdateTemplate(ctx context.Context, in *UpdateTemplateRequest, opts ...grpc.CallOption) (*Template, error)
+	DeleteTemplate(ctx context.Context, in *GetTemplateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListTemplates(ctx context.Context, in *ListTemplatesRequest, opts ...grpc.CallOption) (Ogree_ListTemplatesClient, error)
+}
+
+type ogreeClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOgreeClient(cc grpc.ClientConnInterface) OgreeClient {
+	return &ogreeClient{cc}
+}
+
+func (c *ogreeClient) CreateObject(ctx context.Context, in *CreateObjectRequest, opts ...grpc.CallOption) (*Object, error) {
+	out := new(Object)
+	err := c.cc.Invoke(ctx, "/ogree.v1.Ogree/CreateObject", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ogreeClient) GetObject(ctx context.Context, in *GetObjectRequest, opts ...grpc.CallOption) (*Object, error) {
+	out := new(Object)
+	err := c.cc.Invoke(ctx, "/ogree.v1.Ogree/GetObject", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ogreeClient) UpdateObject(ctx context.Context, in *UpdateObjectRequest, opts ...grpc.CallOption) (*Object, error) {
+	out := new(Object)
+	err := c.cc.Invoke(ctx, "/ogree.v1.Ogree/UpdateObject", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ogreeClient) DeleteObject(ctx context.Context, in *GetObjectRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/ogree.v1.Ogree/DeleteObject", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ogreeClient) ListObjects(ctx context.Context, in *ListObjectsRequest, opts ...grpc.CallOption) (Ogree_ListObjectsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Ogree_ServiceDesc.Streams[0], "/ogree.v1.Ogree/ListObjects", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ogreeListObjectsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Ogree_ListObjectsClient interface {
+	Recv() (*Object, error)
+	grpc.ClientStream
+}
+
+type ogreeListObjectsClient struct {
+	grpc.ClientStream
+}
+
+func (x *ogreeListObjectsClient) Recv() (*Object, error) {
+	m := new(Object)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *ogreeClient) WatchObjects(ctx context.Context, in *WatchObjectsRequest, opts ...grpc.CallOption) (Ogree_WatchObjectsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Ogree_ServiceDesc.Streams[1], "/ogree.v1.Ogree/WatchObjects", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ogreeWatchObjectsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Ogree_WatchObjectsClient interface {
+	Recv() (*ObjectEvent, error)
+	grpc.ClientStream
+}
+
+type ogreeWatchObjectsClient struct {
+	grpc.ClientStream
+}
+
+func (x *ogreeWatchObjectsClient) Recv() (*ObjectEvent, error) {
+	m := new(ObjectEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *ogreeClient) BulkCreateObjects(ctx context.Context, opts ...grpc.CallOption) (Ogree_BulkCreateObjectsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Ogree_ServiceDesc.Streams[2], "/ogree.v1.Ogree/BulkCreateObjects", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ogreeBulkCreateObjectsClient{stream}
+	return x, nil
+}
+
+type Ogree_BulkCreateObjectsClient interface {
+	Send(*CreateObjectRequest) error
+	CloseAndRecv() (*BulkCreateObjectsResponse, error)
+	grpc.ClientStream
+}
+
+type ogreeBulkCreateObjectsClient struct {
+	grpc.ClientStream
+}
+
+func (x *ogreeBulkCreateObjectsClient) Send(m *CreateObjectRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *ogreeBulkCreateObjectsClient) CloseAndRecv() (*BulkCreateObjectsResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(BulkCreateObjectsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *ogreeClient) CreateTemplate(ctx context.Context, in *Template, opts ...grpc.CallOption) (*Template, error) {
+	out := new(Template)
+	err := c.cc.Invoke(ctx, "/ogree.v1.Ogree/CreateTemplate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ogreeClient) GetTemplate(ctx context.Context, in *GetTemplateRequest, opts ...grpc.CallOption) (*Template, error) {
+	out := new(Template)
+	err := c.cc.Invoke(ctx, "/ogree.v1.Ogree/GetTemplate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ogreeClient) UpdateTemplate(ctx context.Context, in *UpdateTemplateRequest, opts ...grpc.CallOption) (*Template, error) {
+	out := new(Template)
+	err := c.cc.Invoke(ctx, "/ogree.v1.Ogree/UpdateTemplate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ogreeClient) DeleteTemplate(ctx context.Context, in *GetTemplateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/ogree.v1.Ogree/DeleteTemplate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ogreeClient) ListTemplates(ctx context.Context, in *ListTemplatesRequest, opts ...grpc.CallOption) (Ogree_ListTemplatesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Ogree_ServiceDesc.Streams[3], "/ogree.v1.Ogree/ListTemplates", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ogreeListTemplatesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Ogree_ListTemplatesClient interface {
+	Recv() (*Template, error)
+	grpc.ClientStream
+}
+
+type ogreeListTemplatesClient struct {
+	grpc.ClientStream
+}
+
+func (x *ogreeListTemplatesClient) Recv() (*Template, error) {
+	m := new(Template)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// OgreeServer is the server API for Ogree service.
+// All implementations must embed UnimplementedOgreeServer
+// for forward compatibility
+type OgreeServer interface {
+	// Objects of the hierarchy and stray objects
+	CreateObject(context.Context, *CreateObjectRequest) (*Object, error)
+	GetObject(context.Context, *GetObjectRequest) (*Object, error)
+	UpdateObject(context.Context, *UpdateObjectRequest) (*Object, error)
+	DeleteObject(context.Context, *GetObjectRequest) (*emptypb.Empty, error)
+	ListObjects(*ListObjectsRequest, Ogree_ListObjectsServer) error
+	// Sends an event each time an object is created, updated or deleted
+	WatchObjects(*WatchObjectsRequest, Ogree_WatchObjectsServer) error
+	// Creates the objects in the order they are sent
+	BulkCreateObjects(Ogree_BulkCreateObjectsServer) error
+	// Templates
+	CreateTemplate(context.Context, *Template) (*Template, error)
+	GetTemplate(context.Context, *GetTemplateRequest) (*Template, error)
+	UpdateTemplate(context.Context, *UpdateTemplateRequest) (*Template, error)
+	DeleteTemplate(context.Context, *GetTemplateRequest) (*emptypb.Empty, error)
+	ListTemplates(*ListTemplatesRequest, Ogree_ListTemplatesServer) error
+	mustEmbedUnimplementedOgreeServer()
+}
+
+// UnimplementedOgreeServer must be embedded to have forward compatible implementations.
+type UnimplementedOgreeServer struct {
+}
+
+func (UnimplementedOgreeServer) CreateObject(context.Context, *CreateObjectRequest) (*Object, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateObject not implemented")
+}
+func (UnimplementedOgreeServer) GetObject(context.Context, *GetObjectRequest) (*Object, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetObject not implemented")
+}
+func (UnimplementedOgreeServer) UpdateObject(context.Context, *UpdateObjectRequest) (*Object, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateObject not implemented")
+}
+func (UnimplementedOgreeServer) DeleteObject(context.Context, *GetObjectRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteObject not implemented")
+}
+func (UnimplementedOgreeServer) ListObjects(*ListObjectsRequest, Ogree_ListObjectsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListObjects not implemented")
+}
+func (UnimplementedOgreeServer) WatchObjects(*WatchObjectsRequest, Ogree_WatchObjectsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchObjects not implemented")
+}
+func (UnimplementedOgreeServer) BulkCreateObjects(Ogree_BulkCreateObjectsServer) error {
+	return status.Errorf(codes.Unimplemented, "method BulkCreateObjects not implemented")
+}
+func (UnimplementedOgreeServer) CreateTemplate(context.Context, *Template) (*Template, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateTemplate not implemented")
+}
+func (UnimplementedOgreeServer) GetTemplate(context.Context, *GetTemplateRequest) (*Template, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTemplate not implemented")
+}
+func (UnimplementedOgreeServer) UpdateTemplate(context.Context, *UpdateTemplateRequest) (*Template, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateTemplate not implemented")
+}
+func (UnimplementedOgreeServer) DeleteTemplate(context.Context, *GetTemplateRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteTemplate not implemented")
+}
+func (UnimplementedOgreeServer) ListTemplates(*ListTemplatesRequest, Ogree_ListTemplatesServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListTemplates not implemented")
+}
+func (UnimplementedOgreeServer) mustEmbedUnimplementedOgreeServer() {}
+
+// UnsafeOgreeServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OgreeServer will
+// result in compilation errors.
+type UnsafeOgreeServer interface {
+	mustEmbedUnimplementedOgreeServer()
+}
+
+func RegisterOgreeServer(s grpc.ServiceRegistrar, srv OgreeServer) {
+	s.RegisterService(&Ogree_ServiceDesc, srv)
+}
+
+func _Ogree_CreateObject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateObjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OgreeServer).CreateObject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ogree.v1.Ogree/CreateObject",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OgreeServer).CreateObject(ctx, req.(*CreateObjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ogree_GetObject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetObjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OgreeServer).GetObject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ogree.v1.Ogree/GetObject",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OgreeServer).GetObject(ctx, req.(*GetObjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ogree_UpdateObject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateObjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OgreeServer).UpdateObject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ogree.v1.Ogree/UpdateObject",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OgreeServer).UpdateObject(ctx, req.(*UpdateObjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ogree_DeleteObject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetObjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OgreeServer).DeleteObject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ogree.v1.Ogree/DeleteObject",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OgreeServer).DeleteObject(ctx, req.(*GetObjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ogree_ListObjects_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListObjectsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OgreeServer).ListObjects(m, &ogreeListObjectsServer{stream})
+}
+
+type Ogree_ListObjectsServer interface {
+	Send(*Object) error
+	grpc.ServerStream
+}
+
+type ogreeListObjectsServer struct {
+	grpc.ServerStream
+}
+
+func (x *ogreeListObjectsServer) Send(m *Object) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Ogree_WatchObjects_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchObjectsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OgreeServer).WatchObjects(m, &ogreeWatchObjectsServer{stream})
+}
+
+type Ogree_WatchObjectsServer interface {
+	Send(*ObjectEvent) error
+	grpc.ServerStream
+}
+
+type ogreeWatchObjectsServer struct {
+	grpc.ServerStream
+}
+
+func (x *ogreeWatchObjectsServer) Send(m *ObjectEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Ogree_BulkCreateObjects_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(OgreeServer).BulkCreateObjects(&ogreeBulkCreateObjectsServer{stream})
+}
+
+type Ogree_BulkCreateObjectsServer interface {
+	SendAndClose(*BulkCreateObjectsResponse) error
+	Recv() (*CreateObjectRequest, error)
+	grpc.ServerStream
+}
+
+type ogreeBulkCreateObjectsServer struct {
+	grpc.ServerStream
+}
+
+func (x *ogreeBulkCreateObjectsServer) SendAndClose(m *BulkCreateObjectsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *ogreeBulkCreateObjectsServer) Recv() (*CreateObjectRequest, error) {
+	m := new(CreateObjectRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Ogree_CreateTemplate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Template)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OgreeServer).CreateTemplate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ogree.v1.Ogree/CreateTemplate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OgreeServer).CreateTemplate(ctx, req.(*Template))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ogree_GetTemplate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTemplateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OgreeServer).GetTemplate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ogree.v1.Ogree/GetTemplate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OgreeServer).GetTemplate(ctx, req.(*GetTemplateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ogree_UpdateTemplate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateTemplateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OgreeServer).UpdateTemplate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ogree.v1.Ogree/UpdateTemplate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OgreeServer).UpdateTemplate(ctx, req.(*UpdateTemplateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ogree_DeleteTemplate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTemplateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OgreeServer).DeleteTemplate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ogree.v1.Ogree/DeleteTemplate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OgreeServer).DeleteTemplate(ctx, req.(*GetTemplateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ogree_ListTemplates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListTemplatesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OgreeServer).ListTemplates(m, &ogreeListTemplatesServer{stream})
+}
+
+type Ogree_ListTemplatesServer interface {
+	Send(*Template) error
+	grpc.ServerStream
+}
+
+type ogreeListTemplatesServer struct {
+	grpc.ServerStream
+}
+
+func (x *ogreeListTemplatesServer) Send(m *Template) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Ogree_ServiceDesc is the grpc.ServiceDesc for Ogree service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Ogree_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ogree.v1.Ogree",
+	HandlerType: (*OgreeServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateObject",
+			Handler:    _Ogree_CreateObject_Handler,
+		},
+		{
+			MethodName: "GetObject",
+			Handler:    _Ogree_GetObject_Handler,
+		},
+		{
+			MethodName: "UpdateObject",
+			Handler:    _Ogree_UpdateObject_Handler,
+		},
+		{
+			MethodName: "DeleteObject",
+			Handler:    _Ogree_DeleteObject_Handler,
+		},
+		{
+			MethodName: "CreateTemplate",
+			Handler:    _Ogree_CreateTemplate_Handler,
+		},
+		{
+			MethodName: "GetTemplate",
+			Handler:    _Ogree_GetTemplate_Handler,
+		},
+		{
+			MethodName: "UpdateTemplate",
+			Handler:    _Ogree_UpdateTemplate_Handler,
+		},
+		{
+			MethodName: "DeleteTemplate",
+			Handler:    _Ogree_DeleteTemplate_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListObjects",
+			Handler:       _Ogree_ListObjects_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchObjects",
+			Handler:       _Ogree_WatchObjects_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "BulkCreateObjects",
+			Handler:       _Ogree_BulkCreateObjects_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ListTemplates",
+			Handler:       _Ogree_ListTemplates_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "ogree.proto",
+}
@@ -0,0 +1,645 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"p3/app"
+	"p3/models"
+	u "p3/utils"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server: implementation of the Ogree service on top of models,
+// as the HTTP controllers are
+type Server struct {
+	UnimplementedOgreeServer
+}
+
+// Serve: serves the gRPC API on port until it fails
+func Serve(port string) error {
+	listener, e := net.Listen("tcp", ":"+port)
+	if e != nil {
+		return e
+	}
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(authUnaryInterceptor),
+		grpc.StreamInterceptor(authStreamInterceptor))
+	RegisterOgreeServer(server, &Server{})
+	return server.Serve(listener)
+}
+
+// authenticate: same JWT as the HTTP API, given in the
+// authorization metadata (Bearer {token-body})
+func authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	tokenHeader := ""
+	if values := md.Get("authorization"); len(values) > 0 {
+		tokenHeader = values[0]
+	}
+	email, msg := app.ParseToken(tokenHeader)
+	if msg != "" {
+		return nil, status.Error(codes.Unauthenticated, msg)
+	}
+	return context.WithValue(ctx, "user", email), nil
+}
+
+func authUnaryInterceptor(ctx context.Context, req interface{},
+	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, e := authenticate(ctx)
+	if e != nil {
+		return nil, e
+	}
+	return handler(ctx, req)
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+func authStreamInterceptor(srv interface{}, stream grpc.ServerStream,
+	info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, e := authenticate(stream.Context())
+	if e != nil {
+		return e
+	}
+	return handler(srv, &authenticatedStream{stream, ctx})
+}
+
+// toStatus: gRPC error from an error code of models,
+// with the same meaning as the HTTP status codes
+func toStatus(resp map[string]interface{}, e string) error {
+	msg, _ := resp["message"].(string)
+	if errs, ok := resp["errors"].([]string); ok && len(errs) > 0 {
+		msg += ": " + strings.Join(errs, ", ")
+	}
+	switch e {
+	case "validate", "invalid", "Invalid ParentID", "Need ParentID":
+		return status.Error(codes.InvalidArgument, msg)
+	case "duplicate":
+		return status.Error(codes.AlreadyExists, msg)
+	case "mongo: no documents in result", "not found", "parent not found":
+		return status.Error(codes.NotFound, msg)
+	case "precondition failed":
+		return status.Error(codes.FailedPrecondition, msg)
+	case "conflict":
+		return status.Error(codes.Aborted, msg)
+	case "locked":
+		return status.Error(codes.Unavailable, msg)
+	default:
+		if strings.Contains(e, "duplicate") {
+			return status.Error(codes.AlreadyExists, msg)
+		}
+		return status.Error(codes.Internal, msg+" "+e)
+	}
+}
+
+// getEntity: entity of an object (isTemplate false) or a template
+func getEntity(entity string, isTemplate bool) (int, string, error) {
+	entStr := strings.Replace(entity, "-", "_", 1)
+	entInt := u.EntityStrToInt(entStr)
+	templates := entInt == u.ROOMTMPL || entInt == u.OBJTMPL || entInt == u.BLDGTMPL
+	if entInt < 0 || templates != isTemplate {
+		return -1, "", status.Error(codes.InvalidArgument, "Invalid entity: '"+entity+"'")
+	}
+	return entInt, entStr, nil
+}
+
+// getObjectReq: request for an object given by id or by name
+func getObjectReq(entInt int, id, hierarchyName string) (bson.M, error) {
+	switch {
+	case id != "":
+		objID, e := primitive.ObjectIDFromHex(id)
+		if e != nil {
+			return nil, status.Error(codes.InvalidArgument, "Error while converting ID to ObjectID")
+		}
+		return bson.M{"_id": objID}, nil
+	case hierarchyName == "":
+		return nil, status.Error(codes.InvalidArgument, "id or hierarchy_name is required")
+	case entInt == u.TENANT || entInt == u.STRAYDEV || entInt == u.STRAYSENSOR:
+		return bson.M{"name": hierarchyName}, nil
+	default:
+		return bson.M{"hierarchyName": hierarchyName}, nil
+	}
+}
+
+// toPlain: mongo values (ObjectID, DateTime, primitive.A...)
+// to the JSON values protobuf structs accept
+func toPlain(data map[string]interface{}) map[string]interface{} {
+	plain := map[string]interface{}{}
+	bytes, _ := json.Marshal(data)
+	json.Unmarshal(bytes, &plain)
+	return plain
+}
+
+func toMap(data interface{}) map[string]interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		return v
+	case primitive.M:
+		return v
+	}
+	return map[string]interface{}{}
+}
+
+func getTimestamp(data map[string]interface{}, field string) *timestamppb.Timestamp {
+	if d, ok := data[field].(primitive.DateTime); ok {
+		return timestamppb.New(d.Time())
+	}
+	return nil
+}
+
+func getID(data map[string]interface{}) string {
+	id := data["id"]
+	if id == nil {
+		id = data["_id"]
+	}
+	if objID, ok := id.(primitive.ObjectID); ok {
+		return objID.Hex()
+	}
+	s, _ := id.(string)
+	return s
+}
+
+// attributesOneof: the typed attributes of an object,
+// the field of each entity family is named after it
+func attributesOneof() protoreflect.OneofDescriptor {
+	return (&Object{}).ProtoReflect().Descriptor().Oneofs().ByName("attributes")
+}
+
+// attributesField: field of the typed attributes of
+// entity, nil for the entities without typed attributes
+func attributesField(entity string) protoreflect.FieldDescriptor {
+	return attributesOneof().Fields().ByName(protoreflect.Name(entity))
+}
+
+// toObject: message of an object of models
+func toObject(entity string, data map[string]interface{}) *Object {
+	plain := toPlain(data)
+	obj := &Object{
+		Id:          getID(data),
+		Entity:      entity,
+		CreatedDate: getTimestamp(data, "createdDate"),
+		LastUpdated: getTimestamp(data, "lastUpdated"),
+		Revision:    models.GetRevision(data),
+	}
+	obj.Name, _ = plain["name"].(string)
+	obj.Category, _ = plain["category"].(string)
+	obj.Domain, _ = plain["domain"].(string)
+	obj.ParentId, _ = plain["parentId"].(string)
+	obj.HierarchyName, _ = plain["hierarchyName"].(string)
+	if description, ok := plain["description"].([]interface{}); ok {
+		for _, line := range description {
+			if s, ok := line.(string); ok {
+				obj.Description = append(obj.Description, s)
+			}
+		}
+	}
+	if attributes, ok := plain["attributes"].(map[string]interface{}); ok {
+		if field := attributesField(entity); field != nil {
+			typed := obj.ProtoReflect().Mutable(field).Message()
+			fields := typed.Descriptor().Fields()
+			for i := 0; i < fields.Len(); i++ {
+				name := fields.Get(i).JSONName()
+				if value, ok := attributes[name].(string); ok {
+					if value != "" {
+						typed.Set(fields.Get(i), protoreflect.ValueOfString(value))
+					}
+					delete(attributes, name)
+				}
+			}
+		}
+		obj.CustomAttributes, _ = structpb.NewStruct(attributes)
+	}
+	if tags, ok := plain["tags"].(map[string]interface{}); ok {
+		obj.Tags = map[string]string{}
+		for k, v := range tags {
+			obj.Tags[k], _ = v.(string)
+		}
+	}
+	return obj
+}
+
+// fromObject: data for models of a message of the given entity.
+// If all is false, only the fields set are given (for a merge patch)
+func fromObject(obj *Object, entity string, all bool) (map[string]interface{}, error) {
+	data := map[string]interface{}{}
+	set := func(field string, value string) {
+		if all || value != "" {
+			data[field] = value
+		}
+	}
+	set("name", obj.Name)
+	set("category", obj.Category)
+	set("domain", obj.Domain)
+	set("parentId", obj.ParentId)
+	if all || len(obj.Description) > 0 {
+		description := []interface{}{}
+		for _, line := range obj.Description {
+			description = append(description, line)
+		}
+		data["description"] = description
+	}
+	attributes := map[string]interface{}{}
+	if obj.CustomAttributes != nil {
+		attributes = obj.CustomAttributes.AsMap()
+	}
+	typed := obj.ProtoReflect().WhichOneof(attributesOneof())
+	if typed != nil && typed != attributesField(entity) {
+		return nil, status.Error(codes.InvalidArgument,
+			"The attributes of a "+string(typed.Name())+" are given for a "+entity)
+	} else if typed != nil {
+		obj.ProtoReflect().Get(typed).Message().Range(
+			func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+				attributes[field.JSONName()] = value.String()
+				return true
+			})
+	}
+	if all || typed != nil || obj.CustomAttributes != nil {
+		data["attributes"] = attributes
+	}
+	if len(obj.Tags) > 0 {
+		tags := map[string]interface{}{}
+		for k, v := range obj.Tags {
+			tags[k] = v
+		}
+		data["tags"] = tags
+	}
+	return data, nil
+}
+
+func (s *Server) CreateObject(ctx context.Context, req *CreateObjectRequest) (*Object, error) {
+	if req.Object == nil {
+		return nil, status.Error(codes.InvalidArgument, "object is required")
+	}
+	entInt, entStr, err := getEntity(req.Object.Entity, false)
+	if err != nil {
+		return nil, err
+	}
+	if req.Object.Category == "" && entInt < u.ROOMTMPL {
+		req.Object.Category = entStr
+	}
+	if entInt < u.ROOMTMPL && req.Object.Category != entStr {
+		return nil, status.Error(codes.InvalidArgument,
+			"Category does not correspond with the entity of the object")
+	}
+	data, err := fromObject(req.Object, entStr, true)
+	if err != nil {
+		return nil, err
+	}
+	if entInt == u.TENANT || req.Object.ParentId == "" {
+		delete(data, "parentId")
+	}
+
+	resp, e := models.CreateEntity(entInt, data)
+	if e != "" {
+		return nil, toStatus(resp, e)
+	}
+	return toObject(entStr, toMap(resp["data"])), nil
+}
+
+func (s *Server) GetObject(ctx context.Context, req *GetObjectRequest) (*Object, error) {
+	entInt, entStr, err := getEntity(req.Entity, false)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := getObjectReq(entInt, req.GetId(), req.GetHierarchyName())
+	if err != nil {
+		return nil, err
+	}
+	data, e := models.GetEntity(filter, entStr, u.RequestFilters{})
+	if e != "" {
+		return nil, toStatus(u.Message(false, "Error while getting "+entStr+": "+e), e)
+	}
+	return toObject(entStr, data), nil
+}
+
+func (s *Server) UpdateObject(ctx context.Context, req *UpdateObjectRequest) (*Object, error) {
+	if req.Object == nil {
+		return nil, status.Error(codes.InvalidArgument, "object is required")
+	}
+	entInt, entStr, err := getEntity(req.Object.Entity, false)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := getObjectReq(entInt, req.Object.Id, req.Object.HierarchyName)
+	if err != nil {
+		return nil, err
+	}
+	var revisions []int64
+	if req.ExpectedRevision != 0 {
+		revisions = []int64{req.ExpectedRevision}
+	}
+
+	var resp map[string]interface{}
+	var e string
+	data, err := fromObject(req.Object, entStr, req.Replace)
+	if err != nil {
+		return nil, err
+	}
+	if req.Replace {
+		if entInt == u.TENANT {
+			delete(data, "parentId")
+		}
+		resp, e = models.UpdateEntity(entStr, filter, &data, false, revisions)
+	} else {
+		resp, e = models.PatchEntity(entStr, filter, data, u.MergePatchType, revisions)
+	}
+	if e != "" {
+		return nil, toStatus(resp, e)
+	}
+	return toObject(entStr, toMap(resp["data"])), nil
+}
+
+func (s *Server) DeleteObject(ctx context.Context, req *GetObjectRequest) (*emptypb.Empty, error) {
+	entInt, entStr, err := getEntity(req.Entity, false)
+	if err != nil {
+		return nil, err
+	}
+	// The object is found as GetObject finds it, then deleted by its id
+	filter, err := getObjectReq(entInt, req.GetId(), req.GetHierarchyName())
+	if err != nil {
+		return nil, err
+	}
+	data, e := models.GetEntity(filter, entStr, u.RequestFilters{FieldsToShow: []string{"_id"}})
+	if e != "" {
+		return nil, toStatus(u.Message(false, "Error while getting "+entStr+": "+e), e)
+	}
+	if resp, e := models.DeleteEntity(entStr, data["id"].(primitive.ObjectID), nil); e != "" {
+		return nil, toStatus(resp, e)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// hierarchyEntities: collections listed and watched by default
+func hierarchyEntities() []string {
+	entities := []string{}
+	for i := u.TENANT; i <= u.GROUP; i++ {
+		entities = append(entities, u.EntityToString(i))
+	}
+	return entities
+}
+
+func (s *Server) ListObjects(req *ListObjectsRequest, stream Ogree_ListObjectsServer) error {
+	entities := hierarchyEntities()
+	if req.Entity != "" {
+		_, entStr, err := getEntity(req.Entity, false)
+		if err != nil {
+			return err
+		}
+		entities = []string{entStr}
+	}
+
+	filter := bson.M{}
+	if req.Parent != "" {
+		filter["hierarchyName"] = primitive.Regex{
+			Pattern: "^" + regexp.QuoteMeta(req.Parent) + "\\.", Options: ""}
+	}
+	for k, v := range req.Attributes {
+		filter["attributes."+k] = v
+	}
+
+	// Objects are sent as they are read from the database
+	sent := int32(0)
+	var err error
+	for _, entStr := range entities {
+		if req.Limit > 0 && sent >= req.Limit {
+			return nil
+		}
+		e := models.StreamEntities(stream.Context(), entStr, filter, int64(req.Limit-sent),
+			func(obj map[string]interface{}) bool {
+				if err = stream.Send(toObject(entStr, obj)); err != nil {
+					return false
+				}
+				sent++
+				return true
+			})
+		if err != nil {
+			return err
+		}
+		if e != "" {
+			return toStatus(u.Message(false, "Error while getting "+entStr+": "+e), e)
+		}
+	}
+	return nil
+}
+
+// eventName: name (hierarchyName, or name) of the object of an
+// event. Events may only give the id of the object: it is then
+// found again, unless it was deleted
+func eventName(event models.ObjectEvent) string {
+	if name, ok := event.Data["hierarchyName"].(string); ok {
+		return name
+	}
+	if name, ok := event.Data["name"].(string); ok {
+		return name
+	}
+	if event.Type == "deleted" {
+		return ""
+	}
+	entInt, _, _ := getEntity(event.Entity, false)
+	filter, err := getObjectReq(entInt, getID(event.Data), "")
+	if err != nil {
+		return ""
+	}
+	data, _ := models.GetEntity(filter, event.Entity,
+		u.RequestFilters{FieldsToShow: []string{"name", "hierarchyName"}})
+	if name, ok := data["hierarchyName"].(string); ok {
+		return name
+	}
+	name, _ := data["name"].(string)
+	return name
+}
+
+func (s *Server) WatchObjects(req *WatchObjectsRequest, stream Ogree_WatchObjectsServer) error {
+	entities := map[string]bool{}
+	for _, entity := range req.Entities {
+		_, entStr, err := getEntity(entity, false)
+		if err != nil {
+			return err
+		}
+		entities[entStr] = true
+	}
+
+	events, unsubscribe := models.SubscribeEvents()
+	defer unsubscribe()
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if _, _, err := getEntity(event.Entity, false); err != nil {
+				continue
+			}
+			if len(entities) > 0 && !entities[event.Entity] {
+				continue
+			}
+			// Events of objects whose name is unknown are sent
+			if req.Parent != "" {
+				name := eventName(event)
+				if name != "" && name != req.Parent && !strings.HasPrefix(name, req.Parent+".") {
+					continue
+				}
+			}
+			e := stream.Send(&ObjectEvent{Type: event.Type, Object: toObject(event.Entity, event.Data)})
+			if e != nil {
+				return e
+			}
+		}
+	}
+}
+
+func (s *Server) BulkCreateObjects(stream Ogree_BulkCreateObjectsServer) error {
+	resp := &BulkCreateObjectsResponse{}
+	for index := int32(0); ; index++ {
+		req, e := stream.Recv()
+		if e == io.EOF {
+			return stream.SendAndClose(resp)
+		}
+		if e != nil {
+			return e
+		}
+
+		result := &BulkCreateObjectsResponse_Result{Index: index}
+		obj, err := s.CreateObject(stream.Context(), req)
+		if err != nil {
+			result.Error = status.Convert(err).Message()
+		} else {
+			result.Id = obj.Id
+			resp.Created++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+}
+
+// toTemplate: message of a template of models
+func toTemplate(entity string, data map[string]interface{}) *Template {
+	plain := toPlain(data)
+	for _, field := range []string{"id", "_id", "createdDate", "lastUpdated", "revision"} {
+		delete(plain, field)
+	}
+	tmpl := &Template{
+		Id:          getID(data),
+		Entity:      entity,
+		CreatedDate: getTimestamp(data, "createdDate"),
+		LastUpdated: getTimestamp(data, "lastUpdated"),
+		Revision:    models.GetRevision(data),
+	}
+	tmpl.Slug, _ = plain["slug"].(string)
+	tmpl.Data, _ = structpb.NewStruct(plain)
+	return tmpl
+}
+
+func fromTemplate(tmpl *Template) map[string]interface{} {
+	data := map[string]interface{}{}
+	if tmpl.Data != nil {
+		data = tmpl.Data.AsMap()
+	}
+	if tmpl.Slug != "" {
+		data["slug"] = tmpl.Slug
+	}
+	return data
+}
+
+func (s *Server) CreateTemplate(ctx context.Context, tmpl *Template) (*Template, error) {
+	entInt, entStr, err := getEntity(tmpl.Entity, true)
+	if err != nil {
+		return nil, err
+	}
+	resp, e := models.CreateEntity(entInt, fromTemplate(tmpl))
+	if e != "" {
+		return nil, toStatus(resp, e)
+	}
+	return toTemplate(entStr, toMap(resp["data"])), nil
+}
+
+func (s *Server) GetTemplate(ctx context.Context, req *GetTemplateRequest) (*Template, error) {
+	_, entStr, err := getEntity(req.Entity, true)
+	if err != nil {
+		return nil, err
+	}
+	data, e := models.GetEntity(bson.M{"slug": req.Slug}, entStr, u.RequestFilters{})
+	if e != "" {
+		return nil, toStatus(u.Message(false, "Error while getting "+entStr+": "+e), e)
+	}
+	return toTemplate(entStr, data), nil
+}
+
+func (s *Server) UpdateTemplate(ctx context.Context, req *UpdateTemplateRequest) (*Template, error) {
+	if req.Template == nil || req.Template.Slug == "" {
+		return nil, status.Error(codes.InvalidArgument, "template with a slug is required")
+	}
+	_, entStr, err := getEntity(req.Template.Entity, true)
+	if err != nil {
+		return nil, err
+	}
+	var revisions []int64
+	if req.ExpectedRevision != 0 {
+		revisions = []int64{req.ExpectedRevision}
+	}
+
+	data := fromTemplate(req.Template)
+	var resp map[string]interface{}
+	var e string
+	if req.Replace {
+		resp, e = models.UpdateEntity(entStr, bson.M{"slug": req.Template.Slug}, &data, false, revisions)
+	} else {
+		resp, e = models.PatchEntity(entStr, bson.M{"slug": req.Template.Slug}, data,
+			u.MergePatchType, revisions)
+	}
+	if e != "" {
+		return nil, toStatus(resp, e)
+	}
+	return toTemplate(entStr, toMap(resp["data"])), nil
+}
+
+func (s *Server) DeleteTemplate(ctx context.Context, req *GetTemplateRequest) (*emptypb.Empty, error) {
+	entInt, _, err := getEntity(req.Entity, true)
+	if err != nil {
+		return nil, err
+	}
+	if resp, e := models.DeleteTemplate(entInt, bson.M{"slug": req.Slug}, false, nil); e != "" {
+		return nil, toStatus(resp, e)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) ListTemplates(req *ListTemplatesRequest, stream Ogree_ListTemplatesServer) error {
+	_, entStr, err := getEntity(req.Entity, true)
+	if err != nil {
+		return err
+	}
+	e := models.StreamEntities(stream.Context(), entStr, bson.M{}, 0,
+		func(tmpl map[string]interface{}) bool {
+			err = stream.Send(toTemplate(entStr, tmpl))
+			return err == nil
+		})
+	if err != nil {
+		return err
+	}
+	if e != "" {
+		return toStatus(u.Message(false, "Error while getting "+entStr+": "+e), e)
+	}
+	return nil
+}
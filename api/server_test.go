@@ -0,0 +1,49 @@
+package api
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestObjectAttributes(t *testing.T) {
+	data := map[string]interface{}{"name": "A01", "category": "rack",
+		"attributes": map[string]interface{}{"posXYZ": `{"x":1,"y":2,"z":0}`,
+			"posXYUnit": "m", "height": "47", "vendor": "acme", "weight": 120.0}}
+	obj := toObject("rack", data)
+	rack := obj.GetRack()
+	if rack == nil || rack.PosXyz != `{"x":1,"y":2,"z":0}` || rack.PosXyUnit != "m" || rack.Height != "47" {
+		t.Fatalf("got %v, expected the typed attributes of a rack", obj.Attributes)
+	}
+	custom := obj.CustomAttributes.AsMap()
+	if len(custom) != 2 || custom["vendor"] != "acme" || custom["weight"] != 120.0 {
+		t.Errorf("got %v, expected vendor and weight only", custom)
+	}
+
+	back, err := fromObject(obj, "rack", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	attrs := back["attributes"].(map[string]interface{})
+	for key, value := range data["attributes"].(map[string]interface{}) {
+		if attrs[key] != value {
+			t.Errorf("%s: got %v, expected %v", key, attrs[key], value)
+		}
+	}
+
+	// Entities without typed attributes only have custom attributes
+	obj = toObject("ac", map[string]interface{}{"attributes": map[string]interface{}{"height": "2"}})
+	if obj.Attributes != nil || obj.CustomAttributes.AsMap()["height"] != "2" {
+		t.Errorf("got %v %v, expected custom attributes only", obj.Attributes, obj.CustomAttributes)
+	}
+
+	// The typed attributes should match the entity
+	if _, err := fromObject(&Object{Attributes: &Object_Device{&DeviceAttributes{PosU: "1"}}}, "rack", true); err == nil {
+		t.Errorf("expected an error for device attributes given for a rack")
+	}
+	patch, _ := fromObject(&Object{Attributes: &Object_Device{&DeviceAttributes{PosU: "3"}},
+		CustomAttributes: &structpb.Struct{}}, "device", false)
+	if attrs := patch["attributes"].(map[string]interface{}); len(attrs) != 1 || attrs["posU"] != "3" {
+		t.Errorf("got %v, expected only posU in the patch", patch)
+	}
+}
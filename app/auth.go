@@ -40,58 +40,52 @@ var JwtAuthentication = func(next http.Handler) http.Handler {
 		}
 
 		//Grab the token from the header
-		response := make(map[string]interface{})
-		tokenHeader := r.Header.Get("Authorization")
-
-		//Token is missing return 403
-		if tokenHeader == "" {
-			response = u.Message(false, "Missing auth token")
-			w.WriteHeader(http.StatusForbidden)
-			w.Header().Add("Content-Type", "application/json")
-			u.Respond(w, response)
-			return
-		}
-
-		//Token format `Bearer {token-body}`
-		splitted := strings.Split(tokenHeader, " ")
-		if len(splitted) != 2 {
-			response = u.Message(false, "Invalid/Malformed auth token")
+		email, msg := ParseToken(r.Header.Get("Authorization"))
+		if msg != "" {
 			w.WriteHeader(http.StatusForbidden)
 			w.Header().Add("Content-Type", "application/json")
-			u.Respond(w, response)
-			return
-		}
-
-		//Grab the token body
-		tokenPart := splitted[1]
-		tk := &models.Token{}
-
-		token, err := jwt.ParseWithClaims(tokenPart, tk, func(token *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("token_password")), nil
-		})
-
-		//Malformed token
-		if err != nil {
-			response = u.Message(false, "Malformed authentication token")
-			w.WriteHeader(http.StatusForbidden)
-			w.Header().Add("Content-Type", "application/json")
-			u.Respond(w, response)
-			return
-		}
-
-		//Token is invalid
-		if !token.Valid {
-			response = u.Message(false, "Token is not valid.")
-			w.WriteHeader(http.StatusForbidden)
-			w.Header().Add("Content-Type", "application/json")
-			u.Respond(w, response)
+			u.Respond(w, u.Message(false, msg))
 			return
 		}
 
 		//Success
 		//set the caller to the user retrieved from the parsed token
-		ctx := context.WithValue(r.Context(), "user", tk.Email)
+		ctx := context.WithValue(r.Context(), "user", email)
 		r = r.WithContext(ctx)
 		next.ServeHTTP(w, r) //proceed in the middleware chain!
 	})
 }
+
+// ParseToken: checks an Authorization header value (Bearer {token-body})
+// and returns the email of the user, or an error message
+func ParseToken(tokenHeader string) (string, string) {
+	//Token is missing
+	if tokenHeader == "" {
+		return "", "Missing auth token"
+	}
+
+	//Token format `Bearer {token-body}`
+	splitted := strings.Split(tokenHeader, " ")
+	if len(splitted) != 2 {
+		return "", "Invalid/Malformed auth token"
+	}
+
+	//Grab the token body
+	tokenPart := splitted[1]
+	tk := &models.Token{}
+
+	token, err := jwt.ParseWithClaims(tokenPart, tk, func(token *jwt.Token) (interface{}, error) {
+		return []byte(os.Getenv("token_password")), nil
+	})
+
+	//Malformed token
+	if err != nil {
+		return "", "Malformed authentication token"
+	}
+
+	//Token is invalid
+	if !token.Valid {
+		return "", "Token is not valid."
+	}
+	return tk.Email, ""
+}
@@ -0,0 +1,344 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"p3/models"
+	u "p3/utils"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// swagger:operation POST /api/assets assets CreateAsset
+// Uploads an asset (FBX model, photo...).
+// The content is stored once: uploading a file whose content
+// already exists returns the existing asset. Templates and objects
+// reference an asset with "asset:<id>" in their fbxModel or photo
+// field (attribute for objects). The maximum size is set with
+// assets_max_size in the .env file.
+// ---
+// consumes:
+// - application/octet-stream
+// - multipart/form-data
+// produces:
+// - application/json
+// parameters:
+//   - name: file
+//     in: body
+//     description: 'The file, or its "file" field if multipart'
+//     required: true
+//     type: file
+//   - name: filename
+//     in: query
+//     description: 'Name of the file if it is not sent as multipart'
+//     required: false
+//     type: string
+//
+// responses:
+//
+//	'200':
+//	    description: 'The content already exists, the existing asset
+//	    is returned.'
+//	'201':
+//	    description: 'Created. The asset is returned with its id and
+//	    the reference to use in templates and objects.'
+//	'400':
+//	    description: Bad request. An error message will be returned.
+//	'413':
+//	    description: 'Too large. The asset is bigger than the
+//	    maximum size.'
+var CreateAsset = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 CreateAsset ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Content-Type", "application/json")
+		w.Header().Add("Allow", "GET, POST, OPTIONS, HEAD")
+		return
+	}
+
+	data, filename, contentType, ok := readUpload(w, r, "CREATE ASSET")
+	if !ok {
+		return
+	}
+
+	resp, e := models.CreateAsset(data, filename, contentType)
+	switch e {
+	case "":
+		if asset, _ := resp["data"].(map[string]interface{}); asset["deduplicated"] == true {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusCreated)
+		}
+	case "too large":
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		u.ErrLog("Error while creating the asset", "CREATE ASSET", e, r)
+	case "invalid":
+		w.WriteHeader(http.StatusBadRequest)
+		u.ErrLog("Error while creating the asset", "CREATE ASSET", e, r)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		u.ErrLog("Error while creating the asset", "CREATE ASSET", e, r)
+	}
+	u.Respond(w, resp)
+}
+
+// readUpload: a file sent as the "file" field of a multipart form
+// or as the body, its name (the filename query parameter if not
+// multipart) and its type. An error response is written on failure
+func readUpload(w http.ResponseWriter, r *http.Request, funcName string) ([]byte, string, string, bool) {
+	var data []byte
+	var err error
+	filename := r.URL.Query().Get("filename")
+	contentType := r.Header.Get("Content-Type")
+	// Some room is left for the multipart headers
+	r.Body = http.MaxBytesReader(w, r.Body, models.GetAssetMaxSize()+1<<20)
+	if strings.HasPrefix(contentType, "multipart/form-data") {
+		file, header, e := r.FormFile("file")
+		if err = e; err == nil {
+			data, err = io.ReadAll(file)
+			file.Close()
+			filename = header.Filename
+			contentType = header.Header.Get("Content-Type")
+		}
+	} else {
+		data, err = io.ReadAll(r.Body)
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), "too large") {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		u.Respond(w, u.Message(false, "Error while reading the file: "+err.Error()))
+		u.ErrLog("Error while reading the file", funcName, "", r)
+		return nil, "", "", false
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return data, filename, contentType, true
+}
+
+// swagger:operation GET /api/assets assets GetAssets
+// Gets the metadata of the assets.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: orphaned
+//     in: query
+//     description: 'If true, only the assets that were referenced
+//     by deleted or updated objects and are not referenced anymore'
+//     required: false
+//     type: boolean
+//
+// responses:
+//
+//	'200':
+//	    description: 'Found. A response body will be returned with
+//	    the id, hash, size, filename and contentType of the assets.'
+var GetAssets = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetAssets ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	resp, e := models.GetAssets(r.URL.Query().Get("orphaned") == "true")
+	if e != "" {
+		w.WriteHeader(http.StatusInternalServerError)
+		u.ErrLog("Error while getting the assets", "GET ASSETS", e, r)
+	}
+	u.Respond(w, resp)
+}
+
+// getAssetId: the id of the asset in the URL, an
+// error response is written if it is not valid
+func getAssetId(w http.ResponseWriter, r *http.Request, funcName string) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error while converting ID to ObjectID"))
+		u.ErrLog("Error while converting ID to ObjectID", funcName, "", r)
+		return id, false
+	}
+	return id, true
+}
+
+// swagger:operation GET /api/assets/{id} assets GetAsset
+// Downloads an asset.
+// Range requests are supported, so are If-None-Match and
+// If-Range with the ETag of the asset (the hash of its content).
+// ---
+// produces:
+// - application/octet-stream
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID of the asset'
+//     required: true
+//     type: string
+//
+// responses:
+//
+//	'200':
+//	    description: 'The content of the asset.'
+//	'206':
+//	    description: 'The requested range of the content.'
+//	'404':
+//	    description: Not Found. An error message will be returned.
+//	'416':
+//	    description: 'The requested range is not satisfiable.'
+var GetAsset = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetAsset ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Allow", "GET, DELETE, OPTIONS, HEAD")
+		return
+	}
+
+	id, ok := getAssetId(w, r, "GET ASSET")
+	if !ok {
+		return
+	}
+	asset, content, e := models.OpenAsset(id)
+	switch e {
+	case "":
+	case "mongo: no documents in result":
+		w.WriteHeader(http.StatusNotFound)
+		u.Respond(w, asset)
+		u.ErrLog("Error while getting the asset", "GET ASSET", e, r)
+		return
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		u.Respond(w, asset)
+		u.ErrLog("Error while getting the asset", "GET ASSET", e, r)
+		return
+	}
+	defer content.Close()
+	serveAsset(w, r, asset, content)
+}
+
+// serveAsset: writes the content of an asset,
+// honouring range and conditional requests
+func serveAsset(w http.ResponseWriter, r *http.Request, asset map[string]interface{}, content io.ReadSeeker) {
+	// The content of an asset never changes
+	w.Header().Set("Content-Type", asset["contentType"].(string))
+	w.Header().Set("ETag", `"`+asset["hash"].(string)+`"`)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	if filename, _ := asset["filename"].(string); filename != "" {
+		w.Header().Set("Content-Disposition",
+			mime.FormatMediaType("inline", map[string]string{"filename": filename}))
+	}
+	created, _ := asset["createdDate"].(primitive.DateTime)
+	http.ServeContent(w, r, "", created.Time(), content)
+}
+
+// swagger:operation GET /api/assets/{id}/usages assets GetAssetUsages
+// Gets the templates and objects referencing an asset.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID of the asset'
+//     required: true
+//     type: string
+//
+// responses:
+//
+//	'200':
+//	    description: 'Found. A response body will be returned with
+//	    the templates (slug) and objects (hierarchyName) using
+//	    the asset.'
+//	'404':
+//	    description: Not Found. An error message will be returned.
+var GetAssetUsages = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetAssetUsages ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Content-Type", "application/json")
+		w.Header().Add("Allow", "GET, OPTIONS, HEAD")
+		return
+	}
+
+	id, ok := getAssetId(w, r, "GET ASSET USAGES")
+	if !ok {
+		return
+	}
+	resp, e := models.GetAssetUsages(id)
+	switch e {
+	case "":
+	case "mongo: no documents in result":
+		w.WriteHeader(http.StatusNotFound)
+		u.ErrLog("Error while getting asset usages", "GET ASSET USAGES", e, r)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		u.ErrLog("Error while getting asset usages", "GET ASSET USAGES", e, r)
+	}
+	u.Respond(w, resp)
+}
+
+// swagger:operation DELETE /api/assets/{id} assets DeleteAsset
+// Deletes an asset.
+// Assets still referenced by a template (or one of its versions)
+// or an object are not deleted.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID of the asset'
+//     required: true
+//     type: string
+//
+// responses:
+//
+//	'204':
+//	    description: 'Successfully deleted the asset.
+//	    No response body will be returned'
+//	'404':
+//	    description: Not Found. An error message will be returned.
+//	'409':
+//	    description: 'Conflict. The asset is still used by the
+//	    templates and objects returned in the response body.'
+var DeleteAsset = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 DeleteAsset ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	id, ok := getAssetId(w, r, "DELETE ASSET")
+	if !ok {
+		return
+	}
+	resp, e := models.DeleteAsset(id)
+	switch e {
+	case "":
+		w.WriteHeader(http.StatusNoContent)
+	case "mongo: no documents in result", "not found":
+		w.WriteHeader(http.StatusNotFound)
+		u.ErrLog("Error while deleting the asset", "DELETE ASSET", e, r)
+	case "in use":
+		w.WriteHeader(http.StatusConflict)
+		u.ErrLog("Error while deleting the asset", "DELETE ASSET", e, r)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		u.ErrLog("Error while deleting the asset", "DELETE ASSET", e, r)
+	}
+	u.Respond(w, resp)
+}
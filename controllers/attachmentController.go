@@ -0,0 +1,385 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"p3/models"
+	u "p3/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// requestUser: the user sending the request, from its token
+func requestUser(r *http.Request) string {
+	user, _ := r.Context().Value("user").(string)
+	return user
+}
+
+// respondObjectItem: writes the status of an
+// attachment or note request and its response
+func respondObjectItem(w http.ResponseWriter, r *http.Request, resp map[string]interface{}, e, funcName string, successCode int) {
+	switch e {
+	case "":
+		w.WriteHeader(successCode)
+	case "not found", "mongo: no documents in result":
+		w.WriteHeader(http.StatusNotFound)
+		u.ErrLog("Error in "+funcName, funcName, e, r)
+	case "invalid":
+		w.WriteHeader(http.StatusBadRequest)
+		u.ErrLog("Error in "+funcName, funcName, e, r)
+	case "too large":
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		u.ErrLog("Error in "+funcName, funcName, e, r)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		u.ErrLog("Error in "+funcName, funcName, e, r)
+	}
+	u.Respond(w, resp)
+}
+
+// swagger:operation GET /api/objects/{name}/attachments objects GetObjectAttachments
+// Gets the files attached to an object, the newest first.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: name
+//     in: path
+//     description: 'hierarchyName of the object'
+//     required: true
+//     type: string
+//
+// responses:
+//
+//	'200':
+//	    description: 'Found. A response body will be returned with
+//	    the filename, description, author and dates of each file.'
+//	'404':
+//	    description: Not Found. An error message will be returned.
+var GetObjectAttachments = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetObjectAttachments ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Content-Type", "application/json")
+		w.Header().Add("Allow", "GET, POST, OPTIONS, HEAD")
+		return
+	}
+
+	resp, e := models.GetObjectItems("attachment", mux.Vars(r)["name"])
+	respondObjectItem(w, r, resp, e, "GET OBJECT ATTACHMENTS", http.StatusOK)
+}
+
+// swagger:operation POST /api/objects/{name}/attachments objects CreateAttachment
+// Attaches a file (photo, PDF...) to an object.
+// The file is stored as an asset (see POST /api/assets), the
+// user sending the request is recorded as its author.
+// ---
+// consumes:
+// - application/octet-stream
+// - multipart/form-data
+// produces:
+// - application/json
+// parameters:
+//   - name: name
+//     in: path
+//     description: 'hierarchyName of the object'
+//     required: true
+//     type: string
+//   - name: file
+//     in: body
+//     description: 'The file, or its "file" field if multipart'
+//     required: true
+//     type: file
+//   - name: filename
+//     in: query
+//     description: 'Name of the file if it is not sent as multipart'
+//     required: false
+//     type: string
+//   - name: description
+//     in: query
+//     description: 'Description of the file, it can also be
+//     a field of the multipart form'
+//     required: false
+//     type: string
+//
+// responses:
+//
+//	'201':
+//	    description: 'Created. The attachment is returned.'
+//	'404':
+//	    description: Not Found. An error message will be returned.
+//	'413':
+//	    description: 'Too large. The file is bigger than the
+//	    maximum size of an asset.'
+var CreateAttachment = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 CreateAttachment ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	data, filename, contentType, ok := readUpload(w, r, "CREATE ATTACHMENT")
+	if !ok {
+		return
+	}
+	resp, e := models.CreateAttachment(mux.Vars(r)["name"], data, filename,
+		contentType, r.FormValue("description"), requestUser(r))
+	respondObjectItem(w, r, resp, e, "CREATE ATTACHMENT", http.StatusCreated)
+}
+
+// swagger:operation GET /api/objects/{name}/attachments/{id} objects GetAttachment
+// Downloads a file attached to an object.
+// Range requests are supported.
+// ---
+// produces:
+// - application/octet-stream
+// parameters:
+//   - name: name
+//     in: path
+//     description: 'hierarchyName of the object'
+//     required: true
+//     type: string
+//   - name: id
+//     in: path
+//     description: 'ID of the attachment'
+//     required: true
+//     type: string
+//
+// responses:
+//
+//	'200':
+//	    description: 'The content of the file.'
+//	'206':
+//	    description: 'The requested range of the content.'
+//	'404':
+//	    description: Not Found. An error message will be returned.
+var GetAttachment = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetAttachment ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Allow", "GET, DELETE, OPTIONS, HEAD")
+		return
+	}
+
+	asset, content, e := models.OpenAttachment(mux.Vars(r)["name"], mux.Vars(r)["id"])
+	if e != "" {
+		respondObjectItem(w, r, asset, e, "GET ATTACHMENT", http.StatusOK)
+		return
+	}
+	defer content.Close()
+	serveAsset(w, r, asset, content)
+}
+
+// swagger:operation DELETE /api/objects/{name}/attachments/{id} objects DeleteAttachment
+// Removes a file attached to an object.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: name
+//     in: path
+//     description: 'hierarchyName of the object'
+//     required: true
+//     type: string
+//   - name: id
+//     in: path
+//     description: 'ID of the attachment'
+//     required: true
+//     type: string
+//
+// responses:
+//
+//	'204':
+//	    description: 'Successfully deleted the attachment.
+//	    No response body will be returned'
+//	'404':
+//	    description: Not Found. An error message will be returned.
+var DeleteAttachment = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 DeleteAttachment ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	resp, e := models.DeleteObjectItem("attachment", mux.Vars(r)["name"], mux.Vars(r)["id"])
+	respondObjectItem(w, r, resp, e, "DELETE ATTACHMENT", http.StatusNoContent)
+}
+
+// swagger:operation GET /api/objects/{name}/notes objects GetObjectNotes
+// Gets the notes of an object, the newest first.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: name
+//     in: path
+//     description: 'hierarchyName of the object'
+//     required: true
+//     type: string
+//
+// responses:
+//
+//	'200':
+//	    description: 'Found. A response body will be returned with
+//	    the text, author and dates of each note.'
+//	'404':
+//	    description: Not Found. An error message will be returned.
+var GetObjectNotes = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetObjectNotes ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Content-Type", "application/json")
+		w.Header().Add("Allow", "GET, POST, OPTIONS, HEAD")
+		return
+	}
+
+	resp, e := models.GetObjectItems("note", mux.Vars(r)["name"])
+	respondObjectItem(w, r, resp, e, "GET OBJECT NOTES", http.StatusOK)
+}
+
+// decodeNote: the text of a note sent as {"text": "..."}
+func decodeNote(w http.ResponseWriter, r *http.Request, funcName string) (string, bool) {
+	body := struct {
+		Text string `json:"text"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error while decoding request body: "+err.Error()))
+		u.ErrLog("Error while decoding request body", funcName, "", r)
+		return "", false
+	}
+	return body.Text, true
+}
+
+// swagger:operation POST /api/objects/{name}/notes objects CreateNote
+// Adds a note to an object.
+// The user sending the request is recorded as its author.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: name
+//     in: path
+//     description: 'hierarchyName of the object'
+//     required: true
+//     type: string
+//   - name: body
+//     in: body
+//     description: 'The text of the note: {"text": "..."}'
+//     required: true
+//     type: string
+//
+// responses:
+//
+//	'201':
+//	    description: 'Created. The note is returned.'
+//	'400':
+//	    description: Bad request. An error message will be returned.
+//	'404':
+//	    description: Not Found. An error message will be returned.
+var CreateNote = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 CreateNote ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	text, ok := decodeNote(w, r, "CREATE NOTE")
+	if !ok {
+		return
+	}
+	resp, e := models.CreateNote(mux.Vars(r)["name"], text, requestUser(r))
+	respondObjectItem(w, r, resp, e, "CREATE NOTE", http.StatusCreated)
+}
+
+// swagger:operation PUT /api/objects/{name}/notes/{id} objects UpdateNote
+// Changes the text of a note of an object.
+// The user sending the request is recorded in updatedBy.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: name
+//     in: path
+//     description: 'hierarchyName of the object'
+//     required: true
+//     type: string
+//   - name: id
+//     in: path
+//     description: 'ID of the note'
+//     required: true
+//     type: string
+//   - name: body
+//     in: body
+//     description: 'The new text of the note: {"text": "..."}'
+//     required: true
+//     type: string
+//
+// responses:
+//
+//	'200':
+//	    description: 'Updated. The note is returned.'
+//	'400':
+//	    description: Bad request. An error message will be returned.
+//	'404':
+//	    description: Not Found. An error message will be returned.
+var UpdateNote = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 UpdateNote ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Content-Type", "application/json")
+		w.Header().Add("Allow", "PUT, DELETE, OPTIONS")
+		return
+	}
+
+	text, ok := decodeNote(w, r, "UPDATE NOTE")
+	if !ok {
+		return
+	}
+	resp, e := models.UpdateNote(mux.Vars(r)["name"], mux.Vars(r)["id"], text, requestUser(r))
+	respondObjectItem(w, r, resp, e, "UPDATE NOTE", http.StatusOK)
+}
+
+// swagger:operation DELETE /api/objects/{name}/notes/{id} objects DeleteNote
+// Removes a note of an object.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: name
+//     in: path
+//     description: 'hierarchyName of the object'
+//     required: true
+//     type: string
+//   - name: id
+//     in: path
+//     description: 'ID of the note'
+//     required: true
+//     type: string
+//
+// responses:
+//
+//	'204':
+//	    description: 'Successfully deleted the note.
+//	    No response body will be returned'
+//	'404':
+//	    description: Not Found. An error message will be returned.
+var DeleteNote = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 DeleteNote ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	resp, e := models.DeleteObjectItem("note", mux.Vars(r)["name"], mux.Vars(r)["id"])
+	respondObjectItem(w, r, resp, e, "DELETE NOTE", http.StatusNoContent)
+}
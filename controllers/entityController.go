@@ -1,9 +1,12 @@
 package controllers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
+	"mime"
 	"net/http"
 	"os"
 	"p3/models"
@@ -64,6 +67,24 @@ func Flatten(prefix string, src map[string]interface{}, dest map[string]interfac
 	}
 }
 
+// writeETag: adds the ETag header of the object and
+// returns true if it matches the If-None-Match header,
+// in which case the response is a 304 without body
+func writeETag(w http.ResponseWriter, r *http.Request, data map[string]interface{}) bool {
+	if _, ok := data["revision"]; !ok {
+		return false
+	}
+	revision := models.GetRevision(data)
+	w.Header().Set("ETag", u.FormatETag(revision))
+
+	ifNoneMatch := r.Header.Get("If-None-Match")
+	if ifNoneMatch != "" && u.ETagMatches(u.ParseETags(ifNoneMatch), revision) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
 func DispRequestMetaData(r *http.Request) {
 	fmt.Println("URL:", r.URL.String())
 	fmt.Println("IP-ADDR: ", r.RemoteAddr)
@@ -78,6 +99,27 @@ func getFiltersFromQueryParams(r *http.Request) u.RequestFilters {
 	return filters
 }
 
+// setLocked: the status of a request that failed because an object
+// it changes is locked by another request, it can be retried
+func setLocked(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", "1")
+	w.WriteHeader(http.StatusServiceUnavailable)
+}
+
+// validTagSelector: responds with an error if the tags
+// query parameter of filters can't be parsed
+func validTagSelector(w http.ResponseWriter, r *http.Request, filters u.RequestFilters, funcName string) bool {
+	if len(filters.Tags) > 0 {
+		if _, e := u.TagSelectorToFilter(filters.Tags); e != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			u.Respond(w, u.Message(false, "Error: "+e.Error()))
+			u.ErrLog("Error while parsing tags", funcName, e.Error(), r)
+			return false
+		}
+	}
+	return true
+}
+
 // swagger:operation POST /api/{obj} objects CreateObject
 // Creates an object in the system.
 // ---
@@ -90,7 +132,7 @@ func getFiltersFromQueryParams(r *http.Request) u.RequestFilters {
 //   "buildings", "rooms", "racks", "devices", "acs", "panels",
 //   "cabinets", "groups", "corridors",
 //   "room-templates", "obj-templates", "bldg-templates","sensors", "stray-devices",
-//   "stray-sensors" are acceptable'
+//   "stray-sensors", "domains" are acceptable'
 //   required: true
 //   type: string
 //   default: "sites"
@@ -126,9 +168,30 @@ func getFiltersFromQueryParams(r *http.Request) u.RequestFilters {
 // - name: Attributes
 //   in: query
 //   description: 'Any other object attributes can be added.
-//   They are required depending on the obj type.'
+//   They are required depending on the obj type. A device in a
+//   rack can be given posU "auto" (or "auto:top", "auto:bottom",
+//   "auto:<from>-<to>" for a preferred range) to be placed at
+//   the first free position that fits it.'
 //   required: true
 //   type: json
+// - name: instantiate
+//   in: query
+//   description: 'Rooms, racks and devices only. If true, the size,
+//   height and colors of the object are copied from its template,
+//   unless given in its attributes. The components and slots of an
+//   obj_template are created as child devices, the reservedArea,
+//   technicalArea, separators and tiles of a room_template are copied
+//   to the room. Nothing is created if any of them is not valid.'
+//   required: false
+//   type: boolean
+//   default: false
+// - name: Idempotency-Key
+//   in: header
+//   description: 'Unique key of the request. Retrying with the same key
+//   and body returns the first response instead of creating the
+//   object again. Keys are kept for idempotency_ttl (default 24h).'
+//   required: false
+//   type: string
 // responses:
 //     '201':
 //         description: 'Created. A response body will be returned with
@@ -136,6 +199,16 @@ func getFiltersFromQueryParams(r *http.Request) u.RequestFilters {
 //     '400':
 //         description: 'Bad request. A response body with an error
 //         message will be returned.'
+//     '409':
+//         description: 'Conflict. A request with the same Idempotency-Key
+//         is still being processed. If it stopped without a response,
+//         the key can be used again 30 seconds later.'
+//     '422':
+//         description: 'Unprocessable. The Idempotency-Key was already
+//         used with a different body.'
+//     '503':
+//         description: 'Unavailable. The rack of the device is locked by
+//         another request, the request can be retried (Retry-After).'
 
 var CreateEntity = func(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("******************************************************")
@@ -192,25 +265,79 @@ var CreateEntity = func(w http.ResponseWriter, r *http.Request) {
 	//Clean the data of 'id' attribute if present
 	delete(entity, "id")
 
-	resp, e = models.CreateEntity(i, entity)
+	//A retried request with the same Idempotency-Key gets
+	//the first response instead of creating the object again
+	idempotencyKey := r.Header.Get("Idempotency-Key")
+	if idempotencyKey != "" {
+		//Keys are scoped by user and entity
+		idempotencyKey = fmt.Sprint(r.Context().Value("user")) + "/" + entStr + "/" + idempotencyKey
+		body, _ := json.Marshal(entity)
+		hash := sha256.Sum256(body)
+		stored, status, e4 := models.ReserveIdempotencyKey(idempotencyKey, hex.EncodeToString(hash[:]))
+		switch e4 {
+		case "":
+		case "replay":
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(status)
+			u.Respond(w, stored)
+			return
+		case "mismatch":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			u.Respond(w, stored)
+			u.ErrLog("Idempotency-Key reused with another body", "CREATE "+entUpper, e4, r)
+			return
+		case "in progress":
+			w.WriteHeader(http.StatusConflict)
+			u.Respond(w, stored)
+			return
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			u.Respond(w, stored)
+			u.ErrLog("Error while checking Idempotency-Key", "CREATE "+entUpper, e4, r)
+			return
+		}
+	}
+
+	if r.URL.Query().Get("instantiate") == "true" {
+		resp, e = models.InstantiateEntity(i, entity)
+	} else {
+		resp, e = models.CreateEntity(i, entity)
+	}
 
+	status := http.StatusCreated
 	switch e {
 	case "validate", "duplicate":
-		w.WriteHeader(http.StatusBadRequest)
+		status = http.StatusBadRequest
+		u.ErrLog("Error while creating "+entStr, "CREATE "+entUpper, e, r)
+	case "locked":
+		status = http.StatusServiceUnavailable
+		w.Header().Set("Retry-After", "1")
+		u.ErrLog("Error while creating "+entStr, "CREATE "+entUpper, e, r)
+	case "internal":
+		status = http.StatusInternalServerError
 		u.ErrLog("Error while creating "+entStr, "CREATE "+entUpper, e, r)
 	case "":
-		w.WriteHeader(http.StatusCreated)
 	default:
 		if strings.Split(e, " ")[1] == "duplicate" {
-			w.WriteHeader(http.StatusBadRequest)
+			status = http.StatusBadRequest
 			u.ErrLog("Error: Duplicate "+entStr+" is forbidden",
 				"CREATE "+entUpper, e, r)
 		} else {
-			w.WriteHeader(http.StatusInternalServerError)
+			status = http.StatusInternalServerError
 			u.ErrLog("Error while creating "+entStr, "CREATE "+entUpper, e, r)
 		}
 	}
 
+	if idempotencyKey != "" {
+		if status == http.StatusInternalServerError || status == http.StatusServiceUnavailable {
+			//Let the client retry
+			models.ReleaseIdempotencyKey(idempotencyKey)
+		} else {
+			models.SaveIdempotentResponse(idempotencyKey, status, resp)
+		}
+	}
+
+	w.WriteHeader(status)
 	u.Respond(w, resp)
 }
 
@@ -264,6 +391,9 @@ var GetGenericObject = func(w http.ResponseWriter, r *http.Request) {
 
 	name, e := mux.Vars(r)["name"]
 	filters := getFiltersFromQueryParams(r)
+	if !validTagSelector(w, r, filters, "GET GENERIC") {
+		return
+	}
 	if e {
 		data, e1 = models.GetObjectByName(name, filters)
 	} else {
@@ -277,12 +407,16 @@ var GetGenericObject = func(w http.ResponseWriter, r *http.Request) {
 		u.ErrLog("Error while getting "+name, "GET GENERIC", "", r)
 		w.WriteHeader(http.StatusNotFound)
 	} else {
+		if writeETag(w, r, data) {
+			return
+		}
 		resp = u.Message(true, "successfully got object")
 	}
 
 	if r.Method == "OPTIONS" && data != nil {
 		w.Header().Add("Content-Type", "application/json")
 		w.Header().Add("Allow", "GET, DELETE, OPTIONS, PATCH, PUT")
+		w.Header().Add("Accept-Patch", u.JSONPatchType+", "+u.MergePatchType)
 	} else {
 		resp["data"] = data
 		u.Respond(w, resp)
@@ -303,21 +437,30 @@ var GetGenericObject = func(w http.ResponseWriter, r *http.Request) {
 //   description: 'Indicates the location. Only values of "tenants", "sites",
 //   "buildings", "rooms", "racks", "devices", "room-templates",
 //   "obj-templates", "bldg-templates","acs", "panels","cabinets", "groups",
-//   "corridors","sensors","stray-devices", "stray-sensors" are acceptable'
+//   "corridors","sensors","stray-devices", "stray-sensors", "domains" are acceptable'
 //   required: true
 //   type: string
 //   default: "sites"
 // - name: ID
 //   in: path
 //   description: 'ID of desired object or Name of Tenant.
-//   For templates the slug is the ID. For stray-devices the name is the ID'
+//   For templates the slug is the ID, "slug@version" gets a previous
+//   version of the template. For stray-devices the name is the ID'
 //   required: true
 //   type: int
 //   default: 999
+// - name: If-None-Match
+//   in: header
+//   description: 'ETag of a previously retrieved version of the object'
+//   required: false
+//   type: string
 // responses:
 //     '200':
 //         description: 'Found. A response body will be returned with
-//         a meaningful message.'
+//         a meaningful message. The ETag header holds the object revision.'
+//     '304':
+//         description: 'Not Modified. The If-None-Match header matches
+//         the current revision, no response body will be returned.'
 //     '400':
 //         description: Bad request. An error message will be returned.
 //     '404':
@@ -364,6 +507,9 @@ var GetEntity = func(w http.ResponseWriter, r *http.Request) {
 
 	entityStr := mux.Vars(r)["entity"]
 	filters := getFiltersFromQueryParams(r)
+	if !validTagSelector(w, r, filters, "GET ENTITY") {
+		return
+	}
 
 	//If templates, format them
 	entityStr = strings.Replace(entityStr, "-", "_", 1)
@@ -390,6 +536,8 @@ var GetEntity = func(w http.ResponseWriter, r *http.Request) {
 	} else if id, e = mux.Vars(r)["name"]; e { //GET By String
 		if entityStr == "tenant" {
 			data, e1 = models.GetEntity(bson.M{"name": id}, entityStr, filters) //GET By Name
+		} else if strings.Contains(entityStr, "template") && strings.Contains(id, "@") {
+			data, e1 = models.GetTemplateVersion(entityStr, id) //GET By slug@version (template)
 		} else if strings.Contains(entityStr, "template") {
 			data, e1 = models.GetEntity(bson.M{"slug": id}, entityStr, filters) //GET By Slug (template)
 		} else {
@@ -423,6 +571,9 @@ var GetEntity = func(w http.ResponseWriter, r *http.Request) {
 		}
 
 	} else {
+		if writeETag(w, r, data) {
+			return
+		}
 
 		message := ""
 		switch u.EntityStrToInt(entityStr) {
@@ -441,6 +592,7 @@ var GetEntity = func(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "OPTIONS" && data != nil {
 		w.Header().Add("Content-Type", "application/json")
 		w.Header().Add("Allow", "GET, DELETE, OPTIONS, PATCH, PUT")
+		w.Header().Add("Accept-Patch", u.JSONPatchType+", "+u.MergePatchType)
 	} else {
 		resp["data"] = data
 		u.Respond(w, resp)
@@ -460,7 +612,7 @@ var GetEntity = func(w http.ResponseWriter, r *http.Request) {
 //     description: 'Indicates the location. Only values of "tenants", "sites",
 //     "buildings", "rooms", "racks", "devices", "room-templates",
 //     "obj-templates","acs", "panels", "cabinets", "groups",
-//     "corridors", "sensors", "stray-devices", "stray-sensors" are acceptable'
+//     "corridors", "sensors", "stray-devices", "stray-sensors", "domains" are acceptable'
 //     required: true
 //     type: string
 //     default: "sites"
@@ -540,7 +692,7 @@ var GetAllEntities = func(w http.ResponseWriter, r *http.Request) {
 //     "buildings", "rooms", "racks", "devices", "room-templates",
 //     "obj-templates","acs", "panels",
 //     "cabinets", "groups", "corridors","sensors", "stray-devices"
-//     "stray-sensors" are acceptable'
+//     "stray-sensors", "domains" are acceptable'
 //     required: true
 //     type: string
 //     default: "sites"
@@ -551,14 +703,43 @@ var GetAllEntities = func(w http.ResponseWriter, r *http.Request) {
 //     required: true
 //     type: int
 //     default: 999
+//   - name: If-Match
+//     in: header
+//     description: 'Only delete if the object revision matches this ETag'
+//     required: false
+//     type: string
+//   - name: async
+//     in: query
+//     description: 'If true, the object (given by name) is deleted by
+//     a background job. See GET /api/jobs/{id}'
+//     required: false
+//     type: boolean
+//   - name: force
+//     in: query
+//     description: 'Templates only. If true, a template still used
+//     is deleted and the objects using it are detached from it
+//     (their template attribute is emptied).'
+//     required: false
+//     type: boolean
 //
 // responses:
 //
+//	'202':
+//	   description: 'Accepted. A job was submitted (async=true),
+//	   a response body with its id will be returned'
 //	'204':
 //	   description: 'Successfully deleted object.
 //	   No response body will be returned'
 //	'404':
 //	   description: Not found. An error message will be returned
+//	'409':
+//	   description: 'Conflict. The domain has subdomains or objects
+//	   still belong to it, or the template is still used by the
+//	   objects returned in the response body. Or an object detached
+//	   from the template was modified meanwhile, nothing was changed.'
+//	'412':
+//	   description: 'Precondition failed. The object revision does
+//	   not match the If-Match header.'
 var DeleteEntity = func(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("******************************************************")
 	fmt.Println("FUNCTION CALL: 	 DeleteEntity ")
@@ -582,14 +763,47 @@ var DeleteEntity = func(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	//If-Match is checked by the delete itself so that the
+	//object can't change between the check and the delete
+	revisions := u.ParseETags(r.Header.Get("If-Match"))
+
+	//Big deletions can be run in the background
+	if r.URL.Query().Get("async") == "true" && e2 && !e && entity != "domain" {
+		//Fail early, the job checks it again when it runs
+		req := bson.M{"hierarchyName": name}
+		if strings.Contains(entity, "template") {
+			req = bson.M{"slug": name}
+		} else if entity == "tenant" {
+			req = bson.M{"name": name}
+		}
+		if resp, e3 := models.CheckRevision(entity, req, revisions); e3 != "" {
+			if e3 == "precondition failed" {
+				w.WriteHeader(http.StatusPreconditionFailed)
+			} else {
+				w.WriteHeader(http.StatusNotFound)
+			}
+			u.ErrLog("Error while deleting entity", "DELETE ENTITY", e3, r)
+			u.Respond(w, resp)
+			return
+		}
+		respondJobSubmission(w, r, "delete",
+			map[string]interface{}{"entity": entity, "name": name,
+				"force":   r.URL.Query().Get("force") == "true",
+				"ifMatch": r.Header.Get("If-Match")})
+		return
+	}
+
+	var e3 string
 	switch {
 	case e2 && !e: // DELETE by name
-		if strings.Contains(entity, "template") {
-			v, _ = models.DeleteEntityManual(entity, bson.M{"slug": name})
+		if entity == "domain" {
+			v, e3 = models.DeleteDomain(bson.M{"hierarchyName": name}, revisions)
+		} else if strings.Contains(entity, "template") {
+			v, e3 = models.DeleteTemplate(u.EntityStrToInt(entity), bson.M{"slug": name},
+				r.URL.Query().Get("force") == "true", revisions)
 		} else {
 			//use hierarchyName
-			v = models.DeleteEntityByName(entity, name)
-
+			v, e3 = models.DeleteEntityByName(entity, name, revisions)
 		}
 
 	case e && !e2: // DELETE by id
@@ -600,10 +814,11 @@ var DeleteEntity = func(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if entity == "device" {
-			v, _ = models.DeleteDeviceF(objID)
+		if strings.Contains(entity, "template") {
+			v, e3 = models.DeleteTemplate(u.EntityStrToInt(entity), bson.M{"_id": objID},
+				r.URL.Query().Get("force") == "true", revisions)
 		} else {
-			v, _ = models.DeleteEntity(entity, objID)
+			v, e3 = models.DeleteEntity(entity, objID, revisions)
 		}
 
 	default:
@@ -612,7 +827,14 @@ var DeleteEntity = func(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if v["status"] == false {
+	if e3 == "in use" || e3 == "conflict" {
+		//Domains and templates still used are kept
+		w.WriteHeader(http.StatusConflict)
+		u.ErrLog("Error while deleting entity", "DELETE ENTITY", e3, r)
+	} else if e3 == "precondition failed" {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		u.ErrLog("Error while deleting entity", "DELETE ENTITY", e3, r)
+	} else if v["status"] == false {
 		w.WriteHeader(http.StatusNotFound)
 		v["message"] = "No Records Found!"
 		u.ErrLog("Error while deleting entity", "DELETE ENTITY", "Not Found", r)
@@ -630,7 +852,14 @@ var DeleteEntity = func(w http.ResponseWriter, r *http.Request) {
 // If the operation succeeds, the data result will be returned.
 // If no new or any information is provided
 // an OK will still be returned
+// With a Content-Type of application/json-patch+json (RFC 6902)
+// or application/merge-patch+json (RFC 7396) the patch is applied
+// to the stored object, which is then validated as a whole.
 // ---
+// consumes:
+// - application/json
+// - application/json-patch+json
+// - application/merge-patch+json
 // produces:
 // - application/json
 // parameters:
@@ -639,7 +868,7 @@ var DeleteEntity = func(w http.ResponseWriter, r *http.Request) {
 //   description: 'Indicates the location. Only values of "tenants", "sites",
 //   "buildings", "rooms", "racks", "devices", "room-templates",
 //   "obj-templates", "bldg-templates","rooms", "acs", "panels", "cabinets", "groups",
-//   "corridors", "sensors", "stray-devices", "stray-sensors" are acceptable'
+//   "corridors", "sensors", "stray-devices", "stray-sensors", "domains" are acceptable'
 //   required: true
 //   type: string
 //   default: "sites"
@@ -678,14 +907,25 @@ var DeleteEntity = func(w http.ResponseWriter, r *http.Request) {
 //   description: Any other object attributes can be updated
 //   required: false
 //   type: json
+// - name: If-Match
+//   in: header
+//   description: 'Only update if the object revision matches this ETag'
+//   required: false
+//   type: string
 // responses:
 //     '200':
 //         description: 'Updated. A response body will be returned with
-//         a meaningful message.'
+//         a meaningful message. The ETag header holds the new revision.'
 //     '400':
 //         description: Bad request. An error message will be returned.
 //     '404':
 //         description: Not Found. An error message will be returned.
+//     '409':
+//         description: 'Conflict. The object was modified by another
+//         request while being updated, the update can be retried.'
+//     '412':
+//         description: 'Precondition failed. The object revision does
+//         not match the If-Match header.'
 
 // swagger:operation PUT /api/{objs}/{id} objects UpdateObject
 // Changes Object data in the system.
@@ -704,7 +944,7 @@ var DeleteEntity = func(w http.ResponseWriter, r *http.Request) {
 //   description: 'Indicates the location. Only values of "tenants", "sites",
 //   "buildings", "rooms", "racks", "devices", "room-templates",
 //   "obj-templates", "bldg-templates","rooms","acs", "panels", "cabinets", "groups",
-//   "corridors","sensors", "stray-devices", "stray-sensors" are acceptable'
+//   "corridors","sensors", "stray-devices", "stray-sensors", "domains" are acceptable'
 //   required: true
 //   type: string
 //   default: "sites"
@@ -743,14 +983,28 @@ var DeleteEntity = func(w http.ResponseWriter, r *http.Request) {
 //   description: Any other object attributes can be updated
 //   required: false
 //   type: json
+// - name: If-Match
+//   in: header
+//   description: 'Only update if the object revision matches this ETag'
+//   required: false
+//   type: string
 // responses:
 //     '200':
 //         description: 'Updated. A response body will be returned with
-//         a meaningful message.'
+//         a meaningful message. The ETag header holds the new revision.'
 //     '400':
 //         description: Bad request. An error message will be returned.
 //     '404':
 //         description: Not Found. An error message will be returned.
+//     '409':
+//         description: 'Conflict. The object was modified by another
+//         request while being updated, the update can be retried.'
+//     '412':
+//         description: 'Precondition failed. The object revision does
+//         not match the If-Match header.'
+//     '503':
+//         description: 'Unavailable. The rack of the device is locked by
+//         another request, the request can be retried (Retry-After).'
 
 var UpdateEntity = func(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("******************************************************")
@@ -770,7 +1024,24 @@ var UpdateEntity = func(w http.ResponseWriter, r *http.Request) {
 	}
 	println(r.Method)
 
-	err := json.NewDecoder(r.Body).Decode(&updateData)
+	//Standard patch formats are chosen by Content-Type,
+	//anything else is the legacy flattened $set PATCH
+	var patch interface{}
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	isStdPatch := isPatch &&
+		(contentType == u.JSONPatchType || contentType == u.MergePatchType)
+	//Domains have no flattened PATCH: a rename has to compute
+	//their hierarchyName again, as for a merge patch
+	if isPatch && !isStdPatch && mux.Vars(r)["entity"] == "domains" {
+		isStdPatch, contentType = true, u.MergePatchType
+	}
+
+	var err error
+	if isStdPatch {
+		err = json.NewDecoder(r.Body).Decode(&patch)
+	} else {
+		err = json.NewDecoder(r.Body).Decode(&updateData)
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		u.Respond(w, u.Message(false, "Error while decoding request body"))
@@ -792,6 +1063,9 @@ var UpdateEntity = func(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	//If-Match gives the revisions the client expects
+	revisions := u.ParseETags(r.Header.Get("If-Match"))
+
 	//Flatten updateData if we have
 	//a PATCH request
 	if isPatch {
@@ -811,7 +1085,11 @@ var UpdateEntity = func(w http.ResponseWriter, r *http.Request) {
 			req = bson.M{"hierarchyName": name}
 		}
 
-		v, e3 = models.UpdateEntity(entity, req, &updateData, isPatch)
+		if isStdPatch {
+			v, e3 = models.PatchEntity(entity, req, patch, contentType, revisions)
+		} else {
+			v, e3 = models.UpdateEntity(entity, req, &updateData, isPatch, revisions)
+		}
 
 	case e: // Update with id
 		objID, err := primitive.ObjectIDFromHex(id)
@@ -825,7 +1103,11 @@ var UpdateEntity = func(w http.ResponseWriter, r *http.Request) {
 		println("OBJID:", objID.Hex())
 		println("Entity;", entity)
 
-		v, e3 = models.UpdateEntity(entity, bson.M{"_id": objID}, &updateData, isPatch)
+		if isStdPatch {
+			v, e3 = models.PatchEntity(entity, bson.M{"_id": objID}, patch, contentType, revisions)
+		} else {
+			v, e3 = models.UpdateEntity(entity, bson.M{"_id": objID}, &updateData, isPatch, revisions)
+		}
 
 	default:
 		w.WriteHeader(http.StatusBadRequest)
@@ -844,12 +1126,140 @@ var UpdateEntity = func(w http.ResponseWriter, r *http.Request) {
 	case "mongo: no documents in result", "parent not found":
 		w.WriteHeader(http.StatusNotFound)
 		u.ErrLog("Error while updating "+entity, "UPDATE "+strings.ToUpper(entity), e3, r)
+	case "precondition failed":
+		w.WriteHeader(http.StatusPreconditionFailed)
+		u.ErrLog("Error while updating "+entity, "UPDATE "+strings.ToUpper(entity), e3, r)
+	case "conflict":
+		w.WriteHeader(http.StatusConflict)
+		u.ErrLog("Error while updating "+entity, "UPDATE "+strings.ToUpper(entity), e3, r)
+	case "locked":
+		setLocked(w)
+		u.ErrLog("Error while updating "+entity, "UPDATE "+strings.ToUpper(entity), e3, r)
+	case "":
+		if data, ok := v["data"].(primitive.M); ok {
+			w.Header().Set("ETag", u.FormatETag(models.GetRevision(data)))
+		}
 	default:
 	}
 
 	u.Respond(w, v)
 }
 
+// swagger:operation PATCH /api/{objs}/{id}/tags objects UpdateTags
+// Updates the tags of an object or a template.
+// The body is merged into the tags as a JSON merge patch (RFC 7396):
+// tags given with a value are set, tags given with null are removed,
+// the others are kept.
+// ---
+// consumes:
+// - application/json
+// - application/merge-patch+json
+// produces:
+// - application/json
+// parameters:
+//   - name: objs
+//     in: query
+//     description: 'Indicates the location. Only values of "tenants", "sites",
+//     "buildings", "rooms", "racks", "devices", "room-templates",
+//     "obj-templates", "bldg-templates","rooms", "acs", "panels", "cabinets", "groups",
+//     "corridors", "sensors", "stray-devices", "stray-sensors" are acceptable'
+//     required: true
+//     type: string
+//     default: "racks"
+//   - name: ID
+//     in: path
+//     description: 'ID or hierarchyName of the object. For templates the
+//     slug is the ID. For tenants and stray-devices the name is the ID'
+//     required: true
+//     type: string
+//   - name: tags
+//     in: body
+//     description: 'Tags to set (string values) or remove (null values)'
+//     required: true
+//     type: json
+//     default: {"env": "prod", "owner": null}
+//
+// responses:
+//
+//	'200':
+//	    description: 'Updated. A response body will be returned with
+//	    the updated object.'
+//	'400':
+//	    description: Bad request. The tags are not valid.
+//	'404':
+//	    description: Not Found. An error message will be returned.
+//	'412':
+//	    description: 'Precondition Failed. The object was modified
+//	    since the revision given with If-Match.'
+var UpdateTags = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 UpdateTags ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	tags := map[string]interface{}{}
+	if err := json.NewDecoder(r.Body).Decode(&tags); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error while decoding request body: tags should be an object"))
+		u.ErrLog("Error while decoding request body", "UPDATE TAGS", "", r)
+		return
+	}
+
+	entity := strings.Replace(mux.Vars(r)["entity"], "-", "_", 1)
+	if u.EntityStrToInt(entity) < 0 {
+		w.WriteHeader(http.StatusNotFound)
+		u.Respond(w, u.Message(false, "Invalid object in URL: '"+mux.Vars(r)["entity"]+"' Please provide a valid object"))
+		u.ErrLog("Cannot update invalid object", "UPDATE TAGS "+mux.Vars(r)["entity"], "", r)
+		return
+	}
+
+	var req bson.M
+	if id, ok := mux.Vars(r)["id"]; ok {
+		objID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			u.Respond(w, u.Message(false, "Error while converting ID to ObjectID"))
+			u.ErrLog("Error while converting ID to ObjectID", "UPDATE TAGS", "", r)
+			return
+		}
+		req = bson.M{"_id": objID}
+	} else {
+		name := mux.Vars(r)["name"]
+		if strings.Contains(entity, "template") {
+			req = bson.M{"slug": name}
+		} else if entity == "tenant" || entity == "stray_device" || entity == "stray_sensor" {
+			req = bson.M{"name": name}
+		} else {
+			req = bson.M{"hierarchyName": name}
+		}
+	}
+
+	v, e := models.PatchEntity(entity, req, map[string]interface{}{"tags": tags},
+		u.MergePatchType, u.ParseETags(r.Header.Get("If-Match")))
+	switch e {
+	case "":
+		if data, ok := v["data"].(primitive.M); ok {
+			w.Header().Set("ETag", u.FormatETag(models.GetRevision(data)))
+		}
+	case "validate", "invalid":
+		w.WriteHeader(http.StatusBadRequest)
+		u.ErrLog("Error while updating tags of "+entity, "UPDATE TAGS", e, r)
+	case "mongo: no documents in result":
+		w.WriteHeader(http.StatusNotFound)
+		u.ErrLog("Error while updating tags of "+entity, "UPDATE TAGS", e, r)
+	case "precondition failed":
+		w.WriteHeader(http.StatusPreconditionFailed)
+		u.ErrLog("Error while updating tags of "+entity, "UPDATE TAGS", e, r)
+	case "conflict":
+		w.WriteHeader(http.StatusConflict)
+		u.ErrLog("Error while updating tags of "+entity, "UPDATE TAGS", e, r)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		u.ErrLog("Error while updating tags of "+entity, "UPDATE TAGS", e, r)
+	}
+	u.Respond(w, v)
+}
+
 // swagger:operation GET /api/{objs}? objects GetObject
 // Gets an Object using any attribute (with the exception of description)
 // via query in the system
@@ -864,7 +1274,7 @@ var UpdateEntity = func(w http.ResponseWriter, r *http.Request) {
 //     description: 'Indicates the object. Only values of "tenants", "sites",
 //     "buildings", "rooms", "racks", "devices", "room-templates",
 //     "obj-templates","acs","panels", "groups", "corridors",
-//     "sensors", "stray-devices" and "stray-sensors" are acceptable'
+//     "sensors", "stray-devices" "stray-sensors" and "domains" are acceptable'
 //     required: true
 //     type: string
 //     default: "sites"
@@ -910,6 +1320,9 @@ var GetEntityByQuery = func(w http.ResponseWriter, r *http.Request) {
 
 	entStr = r.URL.Path[5 : len(r.URL.Path)-1]
 	filters := getFiltersFromQueryParams(r)
+	if !validTagSelector(w, r, filters, "GET ENTITYQUERY") {
+		return
+	}
 
 	//If templates, format them
 	entStr = strings.Replace(entStr, "-", "_", 1)
@@ -1115,8 +1528,13 @@ var GetEntitiesOfAncestor = func(w http.ResponseWriter, r *http.Request) {
 	//Could be: "ac", "panel", "corridor", "cabinet", "sensor"
 	indicator := mux.Vars(r)["sub"]
 
+	filters := getFiltersFromQueryParams(r)
+	if !validTagSelector(w, r, filters, "GET CHILDRENOFPARENT") {
+		return
+	}
+
 	//TODO: hierarchyName
-	data, e1 := models.GetEntitiesOfAncestor(id, enum, entStr, indicator)
+	data, e1 := models.GetEntitiesOfAncestor(id, enum, entStr, indicator, filters)
 	if data == nil {
 		resp = u.Message(false, "Error while getting "+entStr+"s: "+e1)
 		u.ErrLog("Error while getting children of "+entStr,
@@ -1232,6 +1650,9 @@ var GetEntityHierarchy = func(w http.ResponseWriter, r *http.Request) {
 
 	//Check if the request is a ranged hierarchy
 	filters := getFiltersFromQueryParams(r)
+	if !validTagSelector(w, r, filters, "GET ENTITYHIERARCHY") {
+		return
+	}
 	if len(filters.Limit) > 0 { //limit={number} was provided
 		end, _ = strconv.Atoi(filters.Limit)
 		limit = u.EntityStrToInt(entity) + end
@@ -1411,6 +1832,9 @@ var GetHierarchyByName = func(w http.ResponseWriter, r *http.Request) {
 
 	// Check if the request is a ranged hierarchy
 	filters := getFiltersFromQueryParams(r)
+	if !validTagSelector(w, r, filters, "GetHierarchyByName") {
+		return
+	}
 	if len(filters.Limit) > 0 {
 		//limit={number} was provided
 		limit, _ = strconv.Atoi(filters.Limit)
@@ -1603,14 +2027,19 @@ var GetEntitiesUsingNamesOfParents = func(w http.ResponseWriter, r *http.Request
 	oID, _ := getObjID(id)
 
 	if len(arr)%2 != 0 { //This means we are getting entities
+		filters := getFiltersFromQueryParams(r)
+		if !validTagSelector(w, r, filters, "GET ENTITIESUSINGANCESTORNAMES") {
+			return
+		}
+
 		var data []map[string]interface{}
 		var e3 string
 		if e1 {
 			println("we are getting entities here")
-			data, e3 = models.GetEntitiesUsingTenantAsAncestor(entity, tname, ancestry)
+			data, e3 = models.GetEntitiesUsingTenantAsAncestor(entity, tname, ancestry, filters)
 
 		} else {
-			data, e3 = models.GetEntitiesUsingAncestorNames(entity, oID, ancestry)
+			data, e3 = models.GetEntitiesUsingAncestorNames(entity, oID, ancestry, filters)
 		}
 
 		if len(data) == 0 {
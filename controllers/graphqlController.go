@@ -0,0 +1,601 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"p3/models"
+	u "p3/utils"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Entities available in the GraphQL schema
+var graphqlEntities = []int{u.TENANT, u.SITE, u.BLDG, u.ROOM, u.RACK, u.DEVICE,
+	u.AC, u.PWRPNL, u.CABINET, u.CORRIDOR, u.SENSOR, u.GROUP}
+
+// graphqlJSON: free form values such as attributes
+var graphqlJSON = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "JSON",
+	Description: "Any JSON value",
+	Serialize: func(value interface{}) interface{} {
+		// Mongo types to plain JSON values
+		data, e := json.Marshal(value)
+		if e != nil {
+			return nil
+		}
+		var plain interface{}
+		json.Unmarshal(data, &plain)
+		return plain
+	},
+	ParseValue: func(value interface{}) interface{} {
+		return value
+	},
+	ParseLiteral: parseJSONLiteral,
+})
+
+func parseJSONLiteral(valueAST ast.Value) interface{} {
+	switch v := valueAST.(type) {
+	case *ast.ObjectValue:
+		obj := map[string]interface{}{}
+		for _, field := range v.Fields {
+			obj[field.Name.Value] = parseJSONLiteral(field.Value)
+		}
+		return obj
+	case *ast.ListValue:
+		list := []interface{}{}
+		for _, item := range v.Values {
+			list = append(list, parseJSONLiteral(item))
+		}
+		return list
+	case *ast.IntValue:
+		var n float64
+		json.Unmarshal([]byte(v.Value), &n)
+		return n
+	case *ast.FloatValue:
+		var n float64
+		json.Unmarshal([]byte(v.Value), &n)
+		return n
+	case *ast.BooleanValue:
+		return v.Value
+	case *ast.StringValue:
+		return v.Value
+	case *ast.EnumValue:
+		return v.Value
+	}
+	return nil
+}
+
+// graphqlTypeName: GraphQL type of an entity (ex: Building)
+func graphqlTypeName(entity int) string {
+	entStr := u.EntityToString(entity)
+	return strings.ToUpper(entStr[:1]) + entStr[1:]
+}
+
+// graphqlLoader: batches the queries of a request. Resolvers
+// register the keys they need and return a thunk, all the keys
+// registered when the first thunk runs are fetched at once
+type graphqlLoader struct {
+	mutex   sync.Mutex
+	batches map[string]*graphqlBatch
+}
+
+type graphqlBatch struct {
+	collection string
+	field      string
+	filter     bson.M
+	keys       []string
+	loaded     bool
+	results    map[string][]map[string]interface{}
+	err        error
+}
+
+func (loader *graphqlLoader) load(collection, field, key string, filter bson.M) func() ([]map[string]interface{}, error) {
+	filterData, _ := json.Marshal(filter)
+	batchKey := collection + "|" + field + "|" + string(filterData)
+
+	loader.mutex.Lock()
+	batch, ok := loader.batches[batchKey]
+	if !ok || batch.loaded {
+		batch = &graphqlBatch{collection: collection, field: field, filter: filter}
+		loader.batches[batchKey] = batch
+	}
+	batch.keys = append(batch.keys, key)
+	loader.mutex.Unlock()
+
+	return func() ([]map[string]interface{}, error) {
+		loader.mutex.Lock()
+		defer loader.mutex.Unlock()
+		if !batch.loaded {
+			batch.fetch()
+		}
+		return batch.results[key], batch.err
+	}
+}
+
+func (batch *graphqlBatch) fetch() {
+	batch.loaded = true
+	batch.results = map[string][]map[string]interface{}{}
+	keys := bson.A{}
+	for _, key := range batch.keys {
+		if batch.field == "_id" {
+			id, _ := primitive.ObjectIDFromHex(key)
+			keys = append(keys, id)
+		} else {
+			keys = append(keys, key)
+		}
+	}
+
+	req := bson.M{}
+	for k, v := range batch.filter {
+		req[k] = v
+	}
+	req[batch.field] = bson.M{"$in": keys}
+	data, e := models.GetManyEntities(batch.collection, req, u.RequestFilters{})
+	if e != "" {
+		batch.err = errors.New(e)
+		return
+	}
+	for _, obj := range data {
+		var key string
+		if batch.field == "_id" {
+			key = obj["id"].(primitive.ObjectID).Hex()
+		} else {
+			key, _ = obj[batch.field].(string)
+		}
+		batch.results[key] = append(batch.results[key], obj)
+	}
+}
+
+type graphqlLoaderKey struct{}
+
+func getGraphqlLoader(p graphql.ResolveParams) *graphqlLoader {
+	return p.Context.Value(graphqlLoaderKey{}).(*graphqlLoader)
+}
+
+// graphqlFilterArgs: arguments of the fields returning lists
+var graphqlFilterArgs = graphql.FieldConfigArgument{
+	"name":       &graphql.ArgumentConfig{Type: graphql.String},
+	"domain":     &graphql.ArgumentConfig{Type: graphql.String},
+	"attributes": &graphql.ArgumentConfig{Type: graphqlJSON, Description: "Attributes values to match"},
+	"limit":      &graphql.ArgumentConfig{Type: graphql.Int},
+}
+
+func getGraphqlFilter(args map[string]interface{}) bson.M {
+	filter := bson.M{}
+	for _, field := range []string{"name", "domain"} {
+		if v, ok := args[field].(string); ok {
+			filter[field] = v
+		}
+	}
+	if attrs, ok := args["attributes"].(map[string]interface{}); ok {
+		for k, v := range attrs {
+			filter["attributes."+k] = v
+		}
+	}
+	return filter
+}
+
+func limitResults(list []map[string]interface{}, args map[string]interface{}) []map[string]interface{} {
+	if limit, ok := args["limit"].(int); ok && limit >= 0 && limit < len(list) {
+		return list[:limit]
+	}
+	return list
+}
+
+func getGraphqlSource(p graphql.ResolveParams) map[string]interface{} {
+	source, _ := p.Source.(map[string]interface{})
+	if source == nil {
+		if m, ok := p.Source.(primitive.M); ok {
+			source = m
+		}
+	}
+	return source
+}
+
+// graphqlObjectFields: fields common to all entities
+func graphqlObjectFields(objectInterface *graphql.Interface) graphql.Fields {
+	date := func(field string) *graphql.Field {
+		return &graphql.Field{Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				if d, ok := getGraphqlSource(p)[field].(primitive.DateTime); ok {
+					return d.Time().Format(time.RFC3339), nil
+				}
+				return nil, nil
+			}}
+	}
+	return graphql.Fields{
+		"id": &graphql.Field{Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				if id, ok := getGraphqlSource(p)["id"].(primitive.ObjectID); ok {
+					return id.Hex(), nil
+				}
+				return getGraphqlSource(p)["id"], nil
+			}},
+		"name":          &graphql.Field{Type: graphql.String},
+		"category":      &graphql.Field{Type: graphql.String},
+		"domain":        &graphql.Field{Type: graphql.String},
+		"description":   &graphql.Field{Type: graphql.NewList(graphql.String)},
+		"hierarchyName": &graphql.Field{Type: graphql.String},
+		"parentId":      &graphql.Field{Type: graphql.String},
+		"attributes":    &graphql.Field{Type: graphqlJSON},
+		"revision":      &graphql.Field{Type: graphql.Int},
+		"createdDate":   date("createdDate"),
+		"lastUpdated":   date("lastUpdated"),
+		"parent":        &graphql.Field{Type: objectInterface, Resolve: resolveGraphqlParent},
+		"children": &graphql.Field{Type: graphql.NewList(objectInterface), Args: graphqlFilterArgs,
+			Resolve: resolveGraphqlChildren},
+	}
+}
+
+// resolveGraphqlParent: the parent may be in several collections
+func resolveGraphqlParent(p graphql.ResolveParams) (interface{}, error) {
+	source := getGraphqlSource(p)
+	parentId, _ := source["parentId"].(string)
+	entity := u.EntityStrToInt(fmt.Sprint(source["category"]))
+	if parentId == "" || entity < 0 {
+		return nil, nil
+	}
+	loader := getGraphqlLoader(p)
+	thunks := []func() ([]map[string]interface{}, error){}
+	for _, parentEnt := range models.GetParentCollections(entity) {
+		thunks = append(thunks, loader.load(u.EntityToString(parentEnt), "_id", parentId, bson.M{}))
+	}
+	return func() (interface{}, error) {
+		for _, thunk := range thunks {
+			parents, e := thunk()
+			if e != nil {
+				return nil, e
+			}
+			if len(parents) > 0 {
+				return parents[0], nil
+			}
+		}
+		return nil, nil
+	}, nil
+}
+
+// resolveGraphqlChildren: children of all categories
+func resolveGraphqlChildren(p graphql.ResolveParams) (interface{}, error) {
+	source := getGraphqlSource(p)
+	entity := u.EntityStrToInt(fmt.Sprint(source["category"]))
+	id, ok := source["id"].(primitive.ObjectID)
+	if !ok || entity < 0 {
+		return nil, nil
+	}
+	loader := getGraphqlLoader(p)
+	filter := getGraphqlFilter(p.Args)
+	thunks := []func() ([]map[string]interface{}, error){}
+	for _, childEnt := range models.GetChildCollections(entity) {
+		thunks = append(thunks, loader.load(u.EntityToString(childEnt), "parentId", id.Hex(), filter))
+	}
+	return func() (interface{}, error) {
+		children := []map[string]interface{}{}
+		for _, thunk := range thunks {
+			list, e := thunk()
+			if e != nil {
+				return nil, e
+			}
+			children = append(children, list...)
+		}
+		return limitResults(children, p.Args), nil
+	}, nil
+}
+
+// resolveGraphqlChildrenOf: children of the category childEnt
+func resolveGraphqlChildrenOf(childEnt int) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		id, ok := getGraphqlSource(p)["id"].(primitive.ObjectID)
+		if !ok {
+			return nil, nil
+		}
+		thunk := getGraphqlLoader(p).load(u.EntityToString(childEnt), "parentId",
+			id.Hex(), getGraphqlFilter(p.Args))
+		return func() (interface{}, error) {
+			list, e := thunk()
+			return limitResults(list, p.Args), e
+		}, nil
+	}
+}
+
+// graphqlResponseError: error from a response of models
+func graphqlResponseError(resp map[string]interface{}) error {
+	msg := fmt.Sprint(resp["message"])
+	if errs, ok := resp["errors"].([]string); ok && len(errs) > 0 {
+		msg += ": " + strings.Join(errs, ", ")
+	}
+	return errors.New(msg)
+}
+
+func getGraphqlObjectReq(entity int, hierarchyName string) bson.M {
+	if entity == u.TENANT {
+		return bson.M{"name": hierarchyName}
+	}
+	return bson.M{"hierarchyName": hierarchyName}
+}
+
+// buildGraphqlSchema: the schema is generated from the entities,
+// each one has its children and parent, and root fields to
+// query, create, update and delete it
+func buildGraphqlSchema() (graphql.Schema, error) {
+	types := map[int]*graphql.Object{}
+	var objectInterface *graphql.Interface
+	objectInterface = graphql.NewInterface(graphql.InterfaceConfig{
+		Name:        "Object",
+		Description: "Any object of the hierarchy",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphqlObjectFields(objectInterface)
+		}),
+		ResolveType: func(p graphql.ResolveTypeParams) *graphql.Object {
+			source, _ := p.Value.(map[string]interface{})
+			if m, ok := p.Value.(primitive.M); ok {
+				source = m
+			}
+			return types[u.EntityStrToInt(fmt.Sprint(source["category"]))]
+		},
+	})
+
+	for _, entity := range graphqlEntities {
+		entity := entity
+		types[entity] = graphql.NewObject(graphql.ObjectConfig{
+			Name:       graphqlTypeName(entity),
+			Interfaces: []*graphql.Interface{objectInterface},
+			Fields: graphql.FieldsThunk(func() graphql.Fields {
+				fields := graphqlObjectFields(objectInterface)
+				for _, childEnt := range models.GetChildCollections(entity) {
+					fields[u.EntityToString(childEnt)+"s"] = &graphql.Field{
+						Type:    graphql.NewList(types[childEnt]),
+						Args:    graphqlFilterArgs,
+						Resolve: resolveGraphqlChildrenOf(childEnt),
+					}
+				}
+				return fields
+			}),
+		})
+	}
+
+	query := graphql.Fields{
+		"object": &graphql.Field{
+			Type: objectInterface,
+			Args: graphql.FieldConfigArgument{
+				"hierarchyName": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				data, _ := models.GetObjectByName(p.Args["hierarchyName"].(string), u.RequestFilters{})
+				return data, nil
+			},
+		},
+	}
+	mutation := graphql.Fields{}
+	for _, entity := range graphqlEntities {
+		entity := entity
+		entStr := u.EntityToString(entity)
+		typeName := graphqlTypeName(entity)
+
+		query[entStr+"s"] = &graphql.Field{
+			Type: graphql.NewList(types[entity]),
+			Args: graphqlFilterArgs,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				data, e := models.GetManyEntities(entStr, getGraphqlFilter(p.Args), u.RequestFilters{})
+				if e != "" {
+					return nil, errors.New(e)
+				}
+				return limitResults(data, p.Args), nil
+			},
+		}
+		query[entStr] = &graphql.Field{
+			Type: types[entity],
+			Args: graphql.FieldConfigArgument{
+				"id":            &graphql.ArgumentConfig{Type: graphql.ID},
+				"hierarchyName": &graphql.ArgumentConfig{Type: graphql.String}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				var req bson.M
+				if id, ok := p.Args["id"].(string); ok {
+					objID, err := primitive.ObjectIDFromHex(id)
+					if err != nil {
+						return nil, err
+					}
+					req = bson.M{"_id": objID}
+				} else if name, ok := p.Args["hierarchyName"].(string); ok {
+					req = getGraphqlObjectReq(entity, name)
+				} else {
+					return nil, errors.New("id or hierarchyName is required")
+				}
+				data, _ := models.GetEntity(req, entStr, u.RequestFilters{})
+				return data, nil
+			},
+		}
+
+		mutation["create"+typeName] = &graphql.Field{
+			Type: types[entity],
+			Args: graphql.FieldConfigArgument{
+				"data": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphqlJSON)}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				data, ok := p.Args["data"].(map[string]interface{})
+				if !ok {
+					return nil, errors.New("data should be a JSON object")
+				}
+				if data["category"] != entStr {
+					return nil, errors.New("Category in data does not correspond with " + entStr)
+				}
+				delete(data, "id")
+				resp, e := models.CreateEntity(entity, data)
+				if e != "" {
+					return nil, graphqlResponseError(resp)
+				}
+				return resp["data"], nil
+			},
+		}
+		mutation["update"+typeName] = &graphql.Field{
+			Type:        types[entity],
+			Description: "Updates with a JSON merge patch",
+			Args: graphql.FieldConfigArgument{
+				"hierarchyName": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				"data":          &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphqlJSON)}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				req := getGraphqlObjectReq(entity, p.Args["hierarchyName"].(string))
+				resp, e := models.PatchEntity(entStr, req, p.Args["data"], u.MergePatchType, nil)
+				if e != "" {
+					return nil, graphqlResponseError(resp)
+				}
+				if data, ok := resp["data"].(primitive.M); ok {
+					return map[string]interface{}(data), nil
+				}
+				return resp["data"], nil
+			},
+		}
+		mutation["delete"+typeName] = &graphql.Field{
+			Type: graphql.Boolean,
+			Args: graphql.FieldConfigArgument{
+				"hierarchyName": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				resp, e := models.DeleteEntityByName(entStr, p.Args["hierarchyName"].(string), nil)
+				if e != "" {
+					return false, graphqlResponseError(resp)
+				}
+				return true, nil
+			},
+		}
+	}
+
+	objectTypes := []graphql.Type{}
+	for _, entity := range graphqlEntities {
+		objectTypes = append(objectTypes, types[entity])
+	}
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: query}),
+		Mutation: graphql.NewObject(graphql.ObjectConfig{Name: "Mutation", Fields: mutation}),
+		Types:    objectTypes,
+	})
+}
+
+var (
+	graphqlSchema     graphql.Schema
+	graphqlSchemaErr  error
+	graphqlSchemaOnce sync.Once
+)
+
+// isGraphqlMutation: the operation run by a request is a mutation
+// (the named one, or any of them if there is no name). The query is
+// parsed, comments or fragments can come before the operation
+func isGraphqlMutation(query, operationName string) bool {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		// graphql.Do reports the syntax error
+		return false
+	}
+	for _, definition := range doc.Definitions {
+		op, ok := definition.(*ast.OperationDefinition)
+		if !ok || op.Operation != ast.OperationTypeMutation {
+			continue
+		}
+		if operationName == "" || (op.Name != nil && op.Name.Value == operationName) {
+			return true
+		}
+	}
+	return false
+}
+
+// swagger:operation POST /api/graphql objects GraphQL
+// Runs a GraphQL query or mutation.
+// Objects can be navigated from tenants to devices with their
+// children and parent, mutations are validated like the REST API.
+// GET with the query in the URL is also accepted for queries.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: query
+//     in: body
+//     description: 'GraphQL document'
+//     required: true
+//     type: string
+//     default: "{ tenants { name sites { name } } }"
+//   - name: variables
+//     in: body
+//     description: 'Values of the variables of the document'
+//     required: false
+//     type: json
+//   - name: operationName
+//     in: body
+//     description: 'Operation to run if the document has several'
+//     required: false
+//     type: string
+//
+// responses:
+//
+//	'200':
+//	    description: 'Run. The data and errors will be returned.'
+//	'400':
+//	    description: Bad request. An error message will be returned.
+//	'405':
+//	    description: A mutation sent with GET.
+var GraphQL = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GraphQL ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Content-Type", "application/json")
+		w.Header().Add("Allow", "GET, POST, OPTIONS")
+		return
+	}
+
+	body := struct {
+		Query         string                 `json:"query"`
+		Variables     map[string]interface{} `json:"variables"`
+		OperationName string                 `json:"operationName"`
+	}{}
+	if r.Method == "GET" {
+		body.Query = r.URL.Query().Get("query")
+		body.OperationName = r.URL.Query().Get("operationName")
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+			json.Unmarshal([]byte(variables), &body.Variables)
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error while decoding request body"))
+		u.ErrLog("Error while decoding request body", "GRAPHQL", "", r)
+		return
+	}
+
+	graphqlSchemaOnce.Do(func() {
+		graphqlSchema, graphqlSchemaErr = buildGraphqlSchema()
+	})
+	if graphqlSchemaErr != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		u.Respond(w, u.Message(false, "Error while building GraphQL schema: "+graphqlSchemaErr.Error()))
+		u.ErrLog("Error while building GraphQL schema", "GRAPHQL", graphqlSchemaErr.Error(), r)
+		return
+	}
+
+	// Mutations are only allowed with POST
+	if r.Method == "GET" && isGraphqlMutation(body.Query, body.OperationName) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		u.Respond(w, u.Message(false, "Mutations should be sent with POST"))
+		return
+	}
+
+	loader := &graphqlLoader{batches: map[string]*graphqlBatch{}}
+	result := graphql.Do(graphql.Params{
+		Schema:         graphqlSchema,
+		RequestString:  body.Query,
+		VariableValues: body.Variables,
+		OperationName:  body.OperationName,
+		Context:        context.WithValue(r.Context(), graphqlLoaderKey{}, loader),
+	})
+	if len(result.Errors) > 0 {
+		u.ErrLog("Error while running GraphQL query", "GRAPHQL", result.Errors[0].Message, r)
+	}
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
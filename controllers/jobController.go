@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"p3/models"
+	u "p3/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// swagger:operation POST /api/jobs jobs CreateJob
+// Submits a long-running operation as a job.
+// The job is run in the background, its state can be followed
+// with GET /api/jobs/{id}.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: type
+//     in: body
+//     description: 'Type of job. Only values of "delete" (params: entity,
+//     name), "import" (params: objects, a list of {entity, data}),
+//     "hierarchyNames" (params: optional tenant) and "domains" (creates
+//     the missing domains of existing objects, no params) are acceptable'
+//     required: true
+//     type: string
+//     default: "delete"
+//   - name: params
+//     in: body
+//     description: 'Parameters of the job, depending on its type'
+//     required: true
+//     type: json
+//
+// responses:
+//
+//	'202':
+//	    description: 'Accepted. The job was queued, a response body
+//	    with its id will be returned.'
+//	'400':
+//	    description: Bad request. An error message will be returned.
+//	'503':
+//	    description: 'Too many jobs are waiting. Retry later.'
+var CreateJob = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 CreateJob ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+	body := struct {
+		Type   string                 `json:"type"`
+		Params map[string]interface{} `json:"params"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error while decoding request body"))
+		u.ErrLog("Error while decoding request body", "CREATE JOB", "", r)
+		return
+	}
+	if body.Params == nil {
+		body.Params = map[string]interface{}{}
+	}
+
+	respondJobSubmission(w, r, body.Type, body.Params)
+}
+
+// respondJobSubmission: submits a job and answers
+// 202 with its location
+func respondJobSubmission(w http.ResponseWriter, r *http.Request,
+	jobType string, params map[string]interface{}) {
+	resp, e := models.SubmitJob(jobType, params, requestUser(r))
+	switch e {
+	case "":
+		id := resp["data"].(map[string]interface{})["id"].(primitive.ObjectID)
+		w.Header().Set("Location", "/api/jobs/"+id.Hex())
+		w.WriteHeader(http.StatusAccepted)
+	case "invalid":
+		w.WriteHeader(http.StatusBadRequest)
+		u.ErrLog("Error while submitting job", "CREATE JOB", e, r)
+	case "busy":
+		w.WriteHeader(http.StatusServiceUnavailable)
+		u.ErrLog("Error while submitting job", "CREATE JOB", e, r)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		u.ErrLog("Error while submitting job", "CREATE JOB", e, r)
+	}
+	u.Respond(w, resp)
+}
+
+// swagger:operation GET /api/jobs/{id} jobs GetJob
+// Gets the state of a job: status, progress, result and errors.
+// Only the user who submitted the job (or an admin) can get it.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID of the job'
+//     required: true
+//     type: string
+//
+// responses:
+//
+//	'200':
+//	    description: 'Found. A response body will be returned with
+//	    the job.'
+//	'404':
+//	    description: 'Not Found, or submitted by another user. An
+//	    error message will be returned.'
+var GetJob = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetJob ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+	var resp map[string]interface{}
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Content-Type", "application/json")
+		w.Header().Add("Allow", "GET, DELETE, OPTIONS, HEAD")
+		return
+	}
+
+	id, err := getObjID(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error while converting ID to ObjectID"))
+		u.ErrLog("Error while converting ID to ObjectID", "GET JOB", "", r)
+		return
+	}
+
+	data, e := models.GetJob(id, requestUser(r))
+	if data == nil {
+		w.WriteHeader(http.StatusNotFound)
+		resp = u.Message(false, "Error while getting job: "+e)
+		u.ErrLog("Error while getting job", "GET JOB", e, r)
+	} else {
+		resp = u.Message(true, "successfully got job")
+		resp["data"] = data
+	}
+	u.Respond(w, resp)
+}
+
+// swagger:operation DELETE /api/jobs/{id} jobs CancelJob
+// Cancels a pending or running job.
+// What a running job already did is not undone.
+// Only the user who submitted the job (or an admin) can cancel it.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID of the job'
+//     required: true
+//     type: string
+//
+// responses:
+//
+//	'200':
+//	    description: 'Cancelled.'
+//	'404':
+//	    description: 'Not Found, or submitted by another user. An
+//	    error message will be returned.'
+//	'409':
+//	    description: 'Conflict. The job is already finished.'
+var CancelJob = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 CancelJob ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	id, err := getObjID(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error while converting ID to ObjectID"))
+		u.ErrLog("Error while converting ID to ObjectID", "CANCEL JOB", "", r)
+		return
+	}
+
+	resp, e := models.CancelJob(id, requestUser(r))
+	switch e {
+	case "":
+	case "finished":
+		w.WriteHeader(http.StatusConflict)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		u.ErrLog("Error while cancelling job", "CANCEL JOB", e, r)
+	}
+	u.Respond(w, resp)
+}
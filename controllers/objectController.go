@@ -0,0 +1,348 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"p3/models"
+	u "p3/utils"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:operation POST /api/objects/{name}/move objects MoveObject
+// Moves an object and all its children under another parent.
+// The parentId and hierarchyName of every moved object are updated
+// together, the list of moved objects is returned.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: name
+//     in: path
+//     description: 'hierarchyName of the object to move'
+//     required: true
+//     type: string
+//   - name: parent
+//     in: body
+//     description: 'hierarchyName of the new parent'
+//     required: true
+//     type: string
+//     default: "DEMO.BASIC.B1.R2"
+//
+// responses:
+//
+//	'200':
+//	    description: 'Moved. A response body will be returned with
+//	    the old and new hierarchyName of each moved object.'
+//	'400':
+//	    description: 'Bad request. The new parent is not compatible
+//	    with the object or references would be broken.'
+//	'404':
+//	    description: Not Found. An error message will be returned.
+//	'409':
+//	    description: 'Conflict. The new parent already has a child
+//	    with this name, or the object or one of its children was
+//	    modified while being moved: nothing was moved.'
+//	'503':
+//	    description: 'Unavailable. The new parent rack is locked by
+//	    another request, the request can be retried (Retry-After).'
+var MoveObject = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 MoveObject ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+	body := struct {
+		Parent string `json:"parent"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Parent == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error while decoding request body: parent is required"))
+		u.ErrLog("Error while decoding request body", "MOVE OBJECT", "", r)
+		return
+	}
+
+	resp, e := models.MoveObject(mux.Vars(r)["name"], body.Parent)
+	switch e {
+	case "":
+	case "invalid":
+		w.WriteHeader(http.StatusBadRequest)
+		u.ErrLog("Error while moving object", "MOVE OBJECT", e, r)
+	case "not found":
+		w.WriteHeader(http.StatusNotFound)
+		u.ErrLog("Error while moving object", "MOVE OBJECT", e, r)
+	case "clash", "conflict":
+		w.WriteHeader(http.StatusConflict)
+		u.ErrLog("Error while moving object", "MOVE OBJECT", e, r)
+	case "locked":
+		setLocked(w)
+		u.ErrLog("Error while moving object", "MOVE OBJECT", e, r)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		u.ErrLog("Error while moving object", "MOVE OBJECT", e, r)
+	}
+	u.Respond(w, resp)
+}
+
+// swagger:operation POST /api/objects/{name}/clone objects CloneObject
+// Copies an object and all its children under a parent.
+// Copies get new ids, group and corridor contents are updated to
+// name the copies. Nothing is created if any copy can't be.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: name
+//     in: path
+//     description: 'hierarchyName of the object to copy'
+//     required: true
+//     type: string
+//   - name: parent
+//     in: body
+//     description: 'hierarchyName of the parent of the copy'
+//     required: true
+//     type: string
+//     default: "DEMO.BASIC.B1.R2"
+//   - name: name
+//     in: body
+//     description: 'Name of the copy'
+//     required: true
+//     type: string
+//     default: "A02"
+//   - name: attributes
+//     in: body
+//     description: 'Attributes of the copy that should differ from
+//     the original'
+//     required: false
+//     type: json
+//
+// responses:
+//
+//	'201':
+//	    description: 'Created. A response body will be returned with
+//	    the id and hierarchyName of each copy.'
+//	'400':
+//	    description: 'Bad request. The parent is not compatible
+//	    with the object or the copy is not valid.'
+//	'404':
+//	    description: Not Found. An error message will be returned.
+//	'409':
+//	    description: 'Conflict. The parent already has a child
+//	    with this name.'
+//	'503':
+//	    description: 'Unavailable. The parent rack is locked by
+//	    another request, the request can be retried (Retry-After).'
+var CloneObject = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 CloneObject ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+	body := struct {
+		Parent     string                 `json:"parent"`
+		Name       string                 `json:"name"`
+		Attributes map[string]interface{} `json:"attributes"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Parent == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error while decoding request body: parent is required"))
+		u.ErrLog("Error while decoding request body", "CLONE OBJECT", "", r)
+		return
+	}
+
+	resp, e := models.CloneObject(mux.Vars(r)["name"], body.Parent, body.Name, body.Attributes)
+	switch e {
+	case "":
+		w.WriteHeader(http.StatusCreated)
+	case "invalid":
+		w.WriteHeader(http.StatusBadRequest)
+		u.ErrLog("Error while copying object", "CLONE OBJECT", e, r)
+	case "not found":
+		w.WriteHeader(http.StatusNotFound)
+		u.ErrLog("Error while copying object", "CLONE OBJECT", e, r)
+	case "clash":
+		w.WriteHeader(http.StatusConflict)
+		u.ErrLog("Error while copying object", "CLONE OBJECT", e, r)
+	case "locked":
+		setLocked(w)
+		u.ErrLog("Error while copying object", "CLONE OBJECT", e, r)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		u.ErrLog("Error while copying object", "CLONE OBJECT", e, r)
+	}
+	u.Respond(w, resp)
+}
+
+// swagger:operation GET /api/diff objects GetDiff
+// Compares two objects and their children.
+// Children are matched by their name relative to the compared objects,
+// added, removed, moved and changed objects are returned.
+// ---
+// produces:
+// - application/json
+// - text/plain
+// parameters:
+//   - name: left
+//     in: query
+//     description: 'hierarchyName of the first object. It can be
+//     followed by @YYYY-MM-DD (end of that day) or by @ and a RFC 3339
+//     date-time to compare the objects as they were then, from their history'
+//     required: true
+//     type: string
+//     default: "DEMO.BASIC.B1.R1"
+//   - name: right
+//     in: query
+//     description: 'hierarchyName of the second object, with the same syntax'
+//     required: true
+//     type: string
+//     default: "DEMO.BASIC.B1.R2"
+//   - name: format
+//     in: query
+//     description: 'Only values of "json" (default) and "unified"
+//     (human readable text) are acceptable'
+//     required: false
+//     type: string
+//
+// responses:
+//
+//	'200':
+//	    description: 'Compared. The differences will be returned.'
+//	'400':
+//	    description: Bad request. An error message will be returned.
+//	'404':
+//	    description: Not Found. An error message will be returned.
+var GetDiff = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetDiff ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Content-Type", "application/json")
+		w.Header().Add("Allow", "GET, OPTIONS, HEAD")
+		return
+	}
+
+	query := r.URL.Query()
+	left, right, format := query.Get("left"), query.Get("right"), query.Get("format")
+	if left == "" || right == "" || (format != "" && format != "json" && format != "unified") {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error: left and right are required,"+
+			" format should be json or unified"))
+		u.ErrLog("Error while parsing query parameters", "GET DIFF", "", r)
+		return
+	}
+
+	resp, e := models.DiffObjects(left, right)
+	switch e {
+	case "":
+	case "invalid":
+		w.WriteHeader(http.StatusBadRequest)
+		u.ErrLog("Error while comparing objects", "GET DIFF", e, r)
+	case "not found":
+		w.WriteHeader(http.StatusNotFound)
+		u.ErrLog("Error while comparing objects", "GET DIFF", e, r)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		u.ErrLog("Error while comparing objects", "GET DIFF", e, r)
+	}
+
+	if e == "" && format == "unified" {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, models.FormatUnifiedDiff(resp["data"].(map[string]interface{})))
+		return
+	}
+	u.Respond(w, resp)
+}
+
+// swagger:operation GET /api/objects objects GetObjectsByPath
+// Gets the objects whose hierarchyName matches a path pattern.
+// All collections of the hierarchy and stray objects are searched.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: path
+//     in: query
+//     description: 'Path pattern. Every character is literal except
+//     for: * (any characters of a name), ** (any number of names),
+//     ? (one character of a name) and {A,B} (one of the patterns A or B).
+//     At most 256 characters and 10 groups of consecutive wildcards'
+//     required: true
+//     type: string
+//     default: "DEMO.*.B1.**.rack*"
+//   - name: category
+//     in: query
+//     description: 'Only get objects of these categories
+//     (comma separated, ex: rack,device)'
+//     required: false
+//     type: string
+//   - name: tags
+//     in: query
+//     description: 'Only get objects with these tags
+//     (ex: env=prod,owner!=x,decommission,!legacy)'
+//     required: false
+//     type: string
+//
+// responses:
+//
+//	'200':
+//	    description: 'Found. A response body will be returned with
+//	    the matching objects.'
+//	'400':
+//	    description: Bad request. The path pattern is not valid.
+var GetObjectsByPath = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetObjectsByPath ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Content-Type", "application/json")
+		w.Header().Add("Allow", "GET, OPTIONS, HEAD")
+		return
+	}
+
+	query := r.URL.Query()
+	path := query.Get("path")
+	if path == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error: path is required"))
+		u.ErrLog("Error while parsing query parameters", "GET OBJECTS BY PATH", "", r)
+		return
+	}
+	categories := []string{}
+	for _, category := range query["category"] {
+		for _, c := range strings.Split(category, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				categories = append(categories, c)
+			}
+		}
+	}
+
+	if _, e := u.PathPatternToRegex(path); e != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error: invalid path: "+e.Error()))
+		u.ErrLog("Error while parsing path pattern", "GET OBJECTS BY PATH", e.Error(), r)
+		return
+	}
+
+	filters := getFiltersFromQueryParams(r)
+	if !validTagSelector(w, r, filters, "GET OBJECTS BY PATH") {
+		return
+	}
+
+	data, e := models.GetObjectsByPath(path, categories, filters)
+	if e != "" {
+		w.WriteHeader(http.StatusInternalServerError)
+		u.Respond(w, u.Message(false, "Error while getting objects: "+e))
+		u.ErrLog("Error while getting objects", "GET OBJECTS BY PATH", e, r)
+		return
+	}
+
+	resp := u.Message(true, "successfully got objects")
+	resp["data"] = map[string]interface{}{"objects": data}
+	u.Respond(w, resp)
+}
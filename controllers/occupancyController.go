@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"p3/models"
+	u "p3/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// getObjectRequest: request matching the object of the URL,
+// given by its ID or its hierarchyName
+func getObjectRequest(r *http.Request) (bson.M, error) {
+	if id, ok := mux.Vars(r)["id"]; ok {
+		objID, e := getObjID(id)
+		if e != nil {
+			return nil, e
+		}
+		return bson.M{"_id": objID}, nil
+	}
+	return bson.M{"hierarchyName": mux.Vars(r)["name"]}, nil
+}
+
+// swagger:operation GET /api/racks/{id}/occupancy objects GetRackOccupancy
+// Gets the U occupancy of a rack.
+// The devices placed by U (posU) are returned with their range,
+// and the free ranges of the rack, from the bottom (U1) to the top.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID or hierarchyName of the rack'
+//     required: true
+//     type: string
+//     default: "DEMO.ALPHA.B.R1.A01"
+//
+// responses:
+//
+//	'200':
+//	    description: 'Found. A response body will be returned with
+//	    the height of the rack in U, the used and the free ranges
+//	    (posU and sizeU).'
+//	'400':
+//	    description: 'Bad request. The height of the rack is not valid.'
+//	'404':
+//	    description: Not Found. An error message will be returned.
+var GetRackOccupancy = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetRackOccupancy ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Content-Type", "application/json")
+		w.Header().Add("Allow", "GET, OPTIONS, HEAD")
+		return
+	}
+
+	req, err := getObjectRequest(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error while converting ID to ObjectID"))
+		u.ErrLog("Error while converting ID to ObjectID", "GET RACK OCCUPANCY", "", r)
+		return
+	}
+
+	resp, e := models.GetRackOccupancy(req)
+	switch e {
+	case "":
+	case "invalid":
+		w.WriteHeader(http.StatusBadRequest)
+		u.ErrLog("Error while getting rack occupancy", "GET RACK OCCUPANCY", e, r)
+	case "mongo: no documents in result":
+		w.WriteHeader(http.StatusNotFound)
+		u.ErrLog("Error while getting rack occupancy", "GET RACK OCCUPANCY", e, r)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		u.ErrLog("Error while getting rack occupancy", "GET RACK OCCUPANCY", e, r)
+	}
+	u.Respond(w, resp)
+}
+
+// swagger:operation GET /api/devices/{id}/slots objects GetDeviceSlots
+// Gets the slot usage of a device (ex: blade chassis).
+// The slots come from the obj_template of the device, each one
+// is returned with the child device placed in it, if any.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID or hierarchyName of the device'
+//     required: true
+//     type: string
+//     default: "DEMO.ALPHA.B.R1.A01.chassis"
+//
+// responses:
+//
+//	'200':
+//	    description: 'Found. A response body will be returned with
+//	    the slots and the child devices not placed in one of them.'
+//	'404':
+//	    description: Not Found. An error message will be returned.
+var GetDeviceSlots = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetDeviceSlots ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Content-Type", "application/json")
+		w.Header().Add("Allow", "GET, OPTIONS, HEAD")
+		return
+	}
+
+	req, err := getObjectRequest(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error while converting ID to ObjectID"))
+		u.ErrLog("Error while converting ID to ObjectID", "GET DEVICE SLOTS", "", r)
+		return
+	}
+
+	resp, e := models.GetDeviceSlots(req)
+	switch e {
+	case "":
+	case "mongo: no documents in result":
+		w.WriteHeader(http.StatusNotFound)
+		u.ErrLog("Error while getting device slots", "GET DEVICE SLOTS", e, r)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		u.ErrLog("Error while getting device slots", "GET DEVICE SLOTS", e, r)
+	}
+	u.Respond(w, resp)
+}
+
+// swagger:operation GET /api/rooms/{id}/collisions objects GetRoomCollisions
+// Audits the placement of the objects of a room.
+// The footprints of the racks, AC, cabinets and panels are computed
+// from their position (posXYUnit in m, tiles or feet), size and
+// orientation, along the axes of the room (axisOrientation). Objects
+// outside of the room and overlapping objects are returned, so are
+// objects whose footprint can't be computed. Such placements are
+// rejected on writes unless room_collisions is off in the .env file.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID or hierarchyName of the room'
+//     required: true
+//     type: string
+//     default: "DEMO.ALPHA.B.R1"
+//
+// responses:
+//
+//	'200':
+//	    description: 'Found. A response body will be returned with
+//	    the floor of the room, the objects outside of it, the pairs
+//	    of overlapping objects and the invalid ones.'
+//	'400':
+//	    description: 'Bad request. The size of the room is not valid.'
+//	'404':
+//	    description: Not Found. An error message will be returned.
+var GetRoomCollisions = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetRoomCollisions ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Content-Type", "application/json")
+		w.Header().Add("Allow", "GET, OPTIONS, HEAD")
+		return
+	}
+
+	req, err := getObjectRequest(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error while converting ID to ObjectID"))
+		u.ErrLog("Error while converting ID to ObjectID", "GET ROOM COLLISIONS", "", r)
+		return
+	}
+
+	resp, e := models.GetRoomCollisions(req)
+	switch e {
+	case "":
+	case "invalid":
+		w.WriteHeader(http.StatusBadRequest)
+		u.ErrLog("Error while getting room collisions", "GET ROOM COLLISIONS", e, r)
+	case "mongo: no documents in result":
+		w.WriteHeader(http.StatusNotFound)
+		u.ErrLog("Error while getting room collisions", "GET ROOM COLLISIONS", e, r)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		u.ErrLog("Error while getting room collisions", "GET ROOM COLLISIONS", e, r)
+	}
+	u.Respond(w, resp)
+}
@@ -0,0 +1,318 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"p3/models"
+	u "p3/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:operation POST /api/schemas/extensions schemas CreateSchemaExtension
+// Registers a JSON Schema fragment for a category of objects.
+// Objects of this category (and domain, if given) must validate with
+// it in addition to their schema. It applies at once to creations
+// and updates, existing objects are not checked. Only the admins
+// (admins setting of the .env file) can change the extensions.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: category
+//     in: body
+//     description: 'Entity of the objects (ex: device, obj_template).
+//     It is not their category field: devices extensions do not apply
+//     to the obj_templates of category device'
+//     required: true
+//     type: string
+//     default: "device"
+//   - name: domain
+//     in: body
+//     description: 'Domain of the objects, the extension applies to
+//     its subdomains too. All domains if not given'
+//     required: false
+//     type: string
+//     default: "FIN"
+//   - name: schema
+//     in: body
+//     description: 'JSON Schema of the whole object. Embedded definitions
+//     can be referenced (ex: refs/types.json#/definitions/color)'
+//     required: true
+//     type: json
+//     default: {"properties": {"attributes": {"required": ["assetTag"]}}}
+//   - name: description
+//     in: body
+//     required: false
+//     type: string
+//
+// responses:
+//
+//	'201':
+//	    description: 'Created. A response body will be returned with
+//	    the extension.'
+//	'400':
+//	    description: 'Bad request. The schema is not valid.'
+//	'403':
+//	    description: 'Forbidden. The user is not an admin.'
+//	'409':
+//	    description: 'Conflict. An extension already exists for this
+//	    category and domain.'
+var CreateSchemaExtension = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 CreateSchemaExtension ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if !requireAdmin(w, r, "CREATE SCHEMA EXTENSION") {
+		return
+	}
+
+	ext := map[string]interface{}{}
+	if err := json.NewDecoder(r.Body).Decode(&ext); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error while decoding request body"))
+		u.ErrLog("Error while decoding request body", "CREATE SCHEMA EXTENSION", "", r)
+		return
+	}
+
+	resp, e := models.CreateSchemaExtension(ext)
+	respondSchemaExtension(w, r, e, http.StatusCreated, "CREATE SCHEMA EXTENSION")
+	u.Respond(w, resp)
+}
+
+// requireAdmin: only admins can change the schema extensions,
+// they apply to everyone's objects. A 403 response is written
+// for the other users
+func requireAdmin(w http.ResponseWriter, r *http.Request, funcName string) bool {
+	if models.IsAdmin(requestUser(r)) {
+		return true
+	}
+	w.WriteHeader(http.StatusForbidden)
+	u.Respond(w, u.Message(false, "Only admins can change the schema extensions"))
+	u.ErrLog("Schema extensions are changed by admins", funcName, "", r)
+	return false
+}
+
+// respondSchemaExtension: status code for an error code
+// of the schema extensions models
+func respondSchemaExtension(w http.ResponseWriter, r *http.Request, e string, ok int, funcName string) {
+	switch e {
+	case "":
+		w.WriteHeader(ok)
+	case "invalid":
+		w.WriteHeader(http.StatusBadRequest)
+		u.ErrLog("Error with schema extension", funcName, e, r)
+	case "duplicate":
+		w.WriteHeader(http.StatusConflict)
+		u.ErrLog("Error with schema extension", funcName, e, r)
+	case "mongo: no documents in result", "not found":
+		w.WriteHeader(http.StatusNotFound)
+		u.ErrLog("Error with schema extension", funcName, e, r)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		u.ErrLog("Error with schema extension", funcName, e, r)
+	}
+}
+
+// swagger:operation GET /api/schemas/extensions schemas GetSchemaExtensions
+// Gets the schema extensions.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: category
+//     in: query
+//     description: 'Only get the extensions of this category'
+//     required: false
+//     type: string
+//   - name: domain
+//     in: query
+//     description: 'Only get the extensions of this domain'
+//     required: false
+//     type: string
+//
+// responses:
+//
+//	'200':
+//	    description: 'Found. A response body will be returned with
+//	    the extensions.'
+var GetSchemaExtensions = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetSchemaExtensions ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Content-Type", "application/json")
+		w.Header().Add("Allow", "GET, POST, OPTIONS, HEAD")
+		return
+	}
+
+	query := r.URL.Query()
+	data, e := models.GetSchemaExtensions(query.Get("category"), query.Get("domain"))
+	if e != "" {
+		w.WriteHeader(http.StatusInternalServerError)
+		u.Respond(w, u.Message(false, "Error while getting schema extensions: "+e))
+		u.ErrLog("Error while getting schema extensions", "GET SCHEMA EXTENSIONS", e, r)
+		return
+	}
+	resp := u.Message(true, "successfully got schema extensions")
+	resp["data"] = map[string]interface{}{"objects": data}
+	u.Respond(w, resp)
+}
+
+// swagger:operation GET /api/schemas/extensions/{id} schemas GetSchemaExtension
+// Gets a schema extension.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID of the extension'
+//     required: true
+//     type: string
+//
+// responses:
+//
+//	'200':
+//	    description: 'Found. A response body will be returned with
+//	    the extension.'
+//	'404':
+//	    description: Not Found. An error message will be returned.
+var GetSchemaExtension = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetSchemaExtension ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Content-Type", "application/json")
+		w.Header().Add("Allow", "GET, PUT, DELETE, OPTIONS, HEAD")
+		return
+	}
+
+	id, err := getObjID(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error while converting ID to ObjectID"))
+		u.ErrLog("Error while converting ID to ObjectID", "GET SCHEMA EXTENSION", "", r)
+		return
+	}
+
+	var resp map[string]interface{}
+	data, e := models.GetSchemaExtension(id)
+	if data == nil {
+		w.WriteHeader(http.StatusNotFound)
+		resp = u.Message(false, "Error while getting schema extension: "+e)
+		u.ErrLog("Error while getting schema extension", "GET SCHEMA EXTENSION", e, r)
+	} else {
+		resp = u.Message(true, "successfully got schema extension")
+		resp["data"] = data
+	}
+	u.Respond(w, resp)
+}
+
+// swagger:operation PUT /api/schemas/extensions/{id} schemas UpdateSchemaExtension
+// Replaces a schema extension.
+// The body is the same as for its creation.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID of the extension'
+//     required: true
+//     type: string
+//
+// responses:
+//
+//	'200':
+//	    description: 'Updated. A response body will be returned with
+//	    the extension.'
+//	'400':
+//	    description: 'Bad request. The schema is not valid.'
+//	'403':
+//	    description: 'Forbidden. The user is not an admin.'
+//	'404':
+//	    description: Not Found. An error message will be returned.
+//	'409':
+//	    description: 'Conflict. An extension already exists for this
+//	    category and domain.'
+var UpdateSchemaExtension = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 UpdateSchemaExtension ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if !requireAdmin(w, r, "UPDATE SCHEMA EXTENSION") {
+		return
+	}
+
+	id, err := getObjID(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error while converting ID to ObjectID"))
+		u.ErrLog("Error while converting ID to ObjectID", "UPDATE SCHEMA EXTENSION", "", r)
+		return
+	}
+	ext := map[string]interface{}{}
+	if err := json.NewDecoder(r.Body).Decode(&ext); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error while decoding request body"))
+		u.ErrLog("Error while decoding request body", "UPDATE SCHEMA EXTENSION", "", r)
+		return
+	}
+
+	resp, e := models.UpdateSchemaExtension(id, ext)
+	respondSchemaExtension(w, r, e, http.StatusOK, "UPDATE SCHEMA EXTENSION")
+	u.Respond(w, resp)
+}
+
+// swagger:operation DELETE /api/schemas/extensions/{id} schemas DeleteSchemaExtension
+// Deletes a schema extension.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID of the extension'
+//     required: true
+//     type: string
+//
+// responses:
+//
+//	'204':
+//	    description: 'Deleted.'
+//	'403':
+//	    description: 'Forbidden. The user is not an admin.'
+//	'404':
+//	    description: Not Found. An error message will be returned.
+var DeleteSchemaExtension = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 DeleteSchemaExtension ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if !requireAdmin(w, r, "DELETE SCHEMA EXTENSION") {
+		return
+	}
+
+	id, err := getObjID(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error while converting ID to ObjectID"))
+		u.ErrLog("Error while converting ID to ObjectID", "DELETE SCHEMA EXTENSION", "", r)
+		return
+	}
+
+	resp, e := models.DeleteSchemaExtension(id)
+	respondSchemaExtension(w, r, e, http.StatusNoContent, "DELETE SCHEMA EXTENSION")
+	if e != "" {
+		u.Respond(w, resp)
+	}
+}
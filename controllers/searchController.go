@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"p3/models"
+	u "p3/utils"
+	"strconv"
+)
+
+// swagger:operation GET /api/search search Search
+// Searches objects and templates of all collections.
+// Names, hierarchyNames, slugs, descriptions and some attributes
+// (search_attributes in the .env file) are searched. Results are
+// ranked, exact and prefix matches of the name first.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: q
+//     in: query
+//     description: 'Text to search'
+//     required: true
+//     type: string
+//     default: "srv-db-0"
+//   - name: page
+//     in: query
+//     description: 'Page of results, starting at 1 (default)'
+//     required: false
+//     type: integer
+//   - name: limit
+//     in: query
+//     description: 'Results per page, 20 by default and at most 100'
+//     required: false
+//     type: integer
+//
+// responses:
+//
+//	'200':
+//	    description: 'Found. A response body will be returned with the
+//	    total of results and the page of results, each with its type
+//	    (collection) and path (hierarchyName).'
+//	'400':
+//	    description: Bad request. An error message will be returned.
+var Search = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 Search ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Content-Type", "application/json")
+		w.Header().Add("Allow", "GET, OPTIONS, HEAD")
+		return
+	}
+
+	query := r.URL.Query()
+	page, limit := 1, 20
+	var e error
+	if p := query.Get("page"); p != "" {
+		page, e = strconv.Atoi(p)
+	}
+	if l := query.Get("limit"); l != "" && e == nil {
+		limit, e = strconv.Atoi(l)
+	}
+	if e != nil || limit > 100 {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error: page and limit should be"+
+			" numbers, limit should not be more than 100"))
+		u.ErrLog("Error while parsing query parameters", "SEARCH", "", r)
+		return
+	}
+
+	resp, e1 := models.Search(query.Get("q"), page, limit)
+	switch e1 {
+	case "":
+	case "invalid":
+		w.WriteHeader(http.StatusBadRequest)
+		u.ErrLog("Error while searching", "SEARCH", e1, r)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		u.ErrLog("Error while searching", "SEARCH", e1, r)
+	}
+	u.Respond(w, resp)
+}
+
+// swagger:operation GET /api/complete search Complete
+// Completes a hierarchyName.
+// The objects whose name completes the prefix are returned: the
+// children of the part of the prefix before its last dot, whose name
+// starts with what follows it.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: prefix
+//     in: query
+//     description: 'Beginning of a hierarchyName, tenants are
+//     completed if it has no dot'
+//     required: false
+//     type: string
+//     default: "DEMO.ALPHA.B"
+//   - name: limit
+//     in: query
+//     description: 'Max number of objects, 50 by default and at most 1000'
+//     required: false
+//     type: integer
+//
+// responses:
+//
+//	'200':
+//	    description: 'Completed. A response body will be returned with
+//	    the hierarchyName and category of the objects, sorted by name.'
+//	'400':
+//	    description: Bad request. An error message will be returned.
+var Complete = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 Complete ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Content-Type", "application/json")
+		w.Header().Add("Allow", "GET, OPTIONS, HEAD")
+		return
+	}
+
+	query := r.URL.Query()
+	limit := 50
+	if l := query.Get("limit"); l != "" {
+		var e error
+		if limit, e = strconv.Atoi(l); e != nil || limit > 1000 {
+			w.WriteHeader(http.StatusBadRequest)
+			u.Respond(w, u.Message(false,
+				"Error: limit should be a number not more than 1000"))
+			u.ErrLog("Error while parsing query parameters", "COMPLETE", "", r)
+			return
+		}
+	}
+
+	resp, e := models.Complete(query.Get("prefix"), limit)
+	switch e {
+	case "":
+	case "invalid":
+		w.WriteHeader(http.StatusBadRequest)
+		u.ErrLog("Error while completing", "COMPLETE", e, r)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		u.ErrLog("Error while completing", "COMPLETE", e, r)
+	}
+	u.Respond(w, resp)
+}
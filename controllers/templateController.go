@@ -0,0 +1,428 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"p3/models"
+	u "p3/utils"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// getTemplateEntity: the template entity in the URL,
+// obj-templates gives obj_template
+func getTemplateEntity(r *http.Request) string {
+	return strings.Replace(strings.TrimSuffix(mux.Vars(r)["entity"], "s"), "-", "_", 1)
+}
+
+// swagger:operation GET /api/{templates}/{slug}/usages templates GetTemplateUsages
+// Gets the objects using a template.
+// The objects whose template attribute is the slug of the template
+// (buildings, rooms, racks, devices or stray-devices) are returned.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: templates
+//     in: path
+//     description: 'Only values of "obj-templates", "room-templates"
+//     and "bldg-templates" are acceptable'
+//     required: true
+//     type: string
+//     default: "obj-templates"
+//   - name: slug
+//     in: path
+//     description: 'Slug of the template'
+//     required: true
+//     type: string
+//     default: "ibm-example"
+//
+// responses:
+//
+//	'200':
+//	    description: 'Found. A response body will be returned with
+//	    the id, category and hierarchyName of each object using
+//	    the template.'
+//	'404':
+//	    description: Not Found. An error message will be returned.
+var GetTemplateUsages = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetTemplateUsages ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Content-Type", "application/json")
+		w.Header().Add("Allow", "GET, OPTIONS, HEAD")
+		return
+	}
+
+	entity := u.EntityStrToInt(getTemplateEntity(r))
+	resp, e := models.GetTemplateUsages(entity, mux.Vars(r)["slug"])
+	switch e {
+	case "":
+	case "mongo: no documents in result":
+		w.WriteHeader(http.StatusNotFound)
+		u.ErrLog("Error while getting template usages", "GET TEMPLATE USAGES", e, r)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		u.ErrLog("Error while getting template usages", "GET TEMPLATE USAGES", e, r)
+	}
+	u.Respond(w, resp)
+}
+
+// swagger:operation GET /api/{templates}/{slug}/versions templates GetTemplateVersions
+// Gets the versions of a template.
+// Each update of a template creates a new version, previous versions
+// can't be changed and can be got as "slug@version".
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: templates
+//     in: path
+//     description: 'Only values of "obj-templates", "room-templates"
+//     and "bldg-templates" are acceptable'
+//     required: true
+//     type: string
+//     default: "obj-templates"
+//   - name: slug
+//     in: path
+//     description: 'Slug of the template'
+//     required: true
+//     type: string
+//     default: "ibm-example"
+//
+// responses:
+//
+//	'200':
+//	    description: 'Found. A response body will be returned with
+//	    the current version and the list of versions of the template.'
+//	'404':
+//	    description: Not Found. An error message will be returned.
+var GetTemplateVersions = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetTemplateVersions ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Content-Type", "application/json")
+		w.Header().Add("Allow", "GET, OPTIONS, HEAD")
+		return
+	}
+
+	entity := getTemplateEntity(r)
+	resp, e := models.GetTemplateVersions(entity, mux.Vars(r)["slug"])
+	switch e {
+	case "":
+	case "mongo: no documents in result":
+		w.WriteHeader(http.StatusNotFound)
+		u.ErrLog("Error while getting template versions", "GET TEMPLATE VERSIONS", e, r)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		u.ErrLog("Error while getting template versions", "GET TEMPLATE VERSIONS", e, r)
+	}
+	u.Respond(w, resp)
+}
+
+// swagger:operation POST /api/{templates}/{slug}/upgrade templates UpgradeTemplateInstances
+// Upgrades the objects built from a template to one of its versions.
+// The attributes coming from the template (size, height, colors...)
+// are updated unless they were changed on the object, the components
+// and slots created with instantiate=true are added, updated or
+// removed. Objects with conflicts (ex: an occupied slot that would
+// disappear, a device that would not fit anymore) are not upgraded.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: templates
+//     in: path
+//     description: 'Only values of "obj-templates", "room-templates"
+//     and "bldg-templates" are acceptable'
+//     required: true
+//     type: string
+//     default: "obj-templates"
+//   - name: slug
+//     in: path
+//     description: 'Slug of the template'
+//     required: true
+//     type: string
+//     default: "ibm-example"
+//   - name: to
+//     in: body
+//     description: 'Version to upgrade to, the current one if not given'
+//     required: false
+//     type: int
+//   - name: from
+//     in: body
+//     description: 'Only upgrade the objects built from this version'
+//     required: false
+//     type: int
+//   - name: root
+//     in: body
+//     description: 'Only upgrade the objects of this subtree
+//     (hierarchyName)'
+//     required: false
+//     type: string
+//   - name: dryRun
+//     in: query
+//     description: 'If true, the changes and conflicts are
+//     returned but nothing is upgraded'
+//     required: false
+//     type: boolean
+//
+// responses:
+//
+//	'200':
+//	    description: 'Upgraded (or previewed). A response body will be
+//	    returned with the changes and conflicts of each object.'
+//	'400':
+//	    description: Bad request. An error message will be returned.
+//	'404':
+//	    description: Not Found. An error message will be returned.
+//	'503':
+//	    description: 'Unavailable. A rack of the instances is locked by
+//	    another request, the request can be retried (Retry-After).'
+var UpgradeTemplateInstances = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 UpgradeTemplateInstances ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Content-Type", "application/json")
+		w.Header().Add("Allow", "POST, OPTIONS")
+		return
+	}
+
+	body := struct {
+		From int64  `json:"from"`
+		To   int64  `json:"to"`
+		Root string `json:"root"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && err != io.EOF {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error while decoding request body"))
+		u.ErrLog("Error while decoding request body", "UPGRADE TEMPLATE INSTANCES", "", r)
+		return
+	}
+
+	entity := u.EntityStrToInt(getTemplateEntity(r))
+	resp, e := models.UpgradeTemplateInstances(entity, mux.Vars(r)["slug"],
+		body.From, body.To, body.Root, r.URL.Query().Get("dryRun") == "true")
+	switch e {
+	case "":
+	case "invalid version":
+		w.WriteHeader(http.StatusBadRequest)
+		u.ErrLog("Error while upgrading template instances", "UPGRADE TEMPLATE INSTANCES", e, r)
+	case "mongo: no documents in result":
+		w.WriteHeader(http.StatusNotFound)
+		u.ErrLog("Error while upgrading template instances", "UPGRADE TEMPLATE INSTANCES", e, r)
+	case "locked":
+		setLocked(w)
+		u.ErrLog("Error while upgrading template instances", "UPGRADE TEMPLATE INSTANCES", e, r)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		u.ErrLog("Error while upgrading template instances", "UPGRADE TEMPLATE INSTANCES", e, r)
+	}
+	u.Respond(w, resp)
+}
+
+// Maximum size of a template bundle
+const maxBundleSize = 64 << 20
+
+// swagger:operation POST /api/templates/bundle templates ImportTemplateBundle
+// Imports a bundle of templates.
+// The bundle is a tar (or tar.gz) or zip archive of JSON files of
+// obj, room and bldg templates. The collection of each template is
+// given by its folder or file name (ex: obj_template/a.json,
+// room_template1.json), or else by its category. All the templates
+// are validated first, nothing is imported if one is not valid.
+// Templates are created, or updated (new version) if their slug exists.
+// ---
+// consumes:
+// - application/x-tar
+// - application/zip
+// - multipart/form-data
+// produces:
+// - application/json
+// parameters:
+//   - name: bundle
+//     in: body
+//     description: 'The archive, or its "file" field if multipart'
+//     required: true
+//     type: file
+//   - name: dryRun
+//     in: query
+//     description: 'If true, the files are validated and the
+//     result of each one is returned but nothing is imported'
+//     required: false
+//     type: boolean
+//
+// responses:
+//
+//	'200':
+//	    description: 'Imported (or checked). A response body will be
+//	    returned with the status of each file: created, updated,
+//	    unchanged or ignored.'
+//	'400':
+//	    description: 'Bad request. The archive or one of the templates
+//	    is not valid, the status and errors of each file are returned.'
+//	'409':
+//	    description: 'Conflict. A template was changed by another request
+//	    during the import, nothing was imported.'
+//	'500':
+//	    description: 'Internal error, nothing was imported.'
+var ImportTemplateBundle = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 ImportTemplateBundle ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	if r.Method == "OPTIONS" {
+		w.Header().Add("Content-Type", "application/json")
+		w.Header().Add("Allow", "GET, POST, OPTIONS, HEAD")
+		return
+	}
+
+	var data []byte
+	var err error
+	r.Body = http.MaxBytesReader(w, r.Body, maxBundleSize)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		var file io.ReadCloser
+		if file, _, err = r.FormFile("file"); err == nil {
+			data, err = io.ReadAll(file)
+			file.Close()
+		}
+	} else {
+		data, err = io.ReadAll(r.Body)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error while reading the bundle: "+err.Error()))
+		u.ErrLog("Error while reading the bundle", "IMPORT TEMPLATE BUNDLE", "", r)
+		return
+	}
+	files, err := u.ReadArchive(data)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Error while reading the bundle: "+err.Error()))
+		u.ErrLog("Error while reading the bundle", "IMPORT TEMPLATE BUNDLE", "", r)
+		return
+	}
+
+	resp, e := models.ImportTemplateBundle(files, r.URL.Query().Get("dryRun") == "true")
+	switch e {
+	case "":
+	case "invalid":
+		w.WriteHeader(http.StatusBadRequest)
+		u.ErrLog("Error while importing the bundle", "IMPORT TEMPLATE BUNDLE", e, r)
+	case "conflict":
+		w.WriteHeader(http.StatusConflict)
+		u.ErrLog("Error while importing the bundle", "IMPORT TEMPLATE BUNDLE", e, r)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		u.ErrLog("Error while importing the bundle", "IMPORT TEMPLATE BUNDLE", e, r)
+	}
+	u.Respond(w, resp)
+}
+
+// swagger:operation GET /api/templates/bundle templates ExportTemplateBundle
+// Exports templates as a bundle.
+// The archive has one folder per template collection (obj_template,
+// room_template, bldg_template) with a JSON file per template, it
+// can be imported again with POST /api/templates/bundle.
+// ---
+// produces:
+// - application/x-tar
+// - application/zip
+// parameters:
+//   - name: templates
+//     in: query
+//     description: 'Comma separated template collections to export
+//     ("obj-templates", "room-templates", "bldg-templates"), all
+//     of them if not given'
+//     required: false
+//     type: string
+//   - name: slugs
+//     in: query
+//     description: 'Comma separated slugs of the templates to export'
+//     required: false
+//     type: string
+//   - name: tags
+//     in: query
+//     description: 'Only export the templates with one of these
+//     comma separated tags'
+//     required: false
+//     type: string
+//   - name: format
+//     in: query
+//     description: 'tar (default) or zip'
+//     required: false
+//     type: string
+//
+// responses:
+//
+//	'200':
+//	    description: 'The archive of the templates.'
+//	'400':
+//	    description: Bad request. An error message will be returned.
+var ExportTemplateBundle = func(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 ExportTemplateBundle ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	query := r.URL.Query()
+	split := func(value string) []string {
+		list := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list
+	}
+	entities := []int{}
+	for _, template := range split(query.Get("templates")) {
+		entity := u.EntityStrToInt(strings.TrimSuffix(strings.Replace(template, "-", "_", 1), "s"))
+		if entity != u.OBJTMPL && entity != u.ROOMTMPL && entity != u.BLDGTMPL {
+			w.WriteHeader(http.StatusBadRequest)
+			u.Respond(w, u.Message(false, "Invalid templates: '"+template+
+				"', expected obj-templates, room-templates or bldg-templates"))
+			u.ErrLog("Invalid templates", "EXPORT TEMPLATE BUNDLE", "", r)
+			return
+		}
+		entities = append(entities, entity)
+	}
+	format := query.Get("format")
+	if format != "" && format != "tar" && format != "zip" {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.Message(false, "Invalid format: '"+format+"', expected tar or zip"))
+		u.ErrLog("Invalid format", "EXPORT TEMPLATE BUNDLE", "", r)
+		return
+	}
+
+	files, e := models.ExportTemplateBundle(entities, split(query.Get("slugs")), split(query.Get("tags")))
+	if e != "" {
+		w.WriteHeader(http.StatusInternalServerError)
+		u.Respond(w, u.Message(false, "Error while exporting the templates: "+e))
+		u.ErrLog("Error while exporting the templates", "EXPORT TEMPLATE BUNDLE", e, r)
+		return
+	}
+
+	if format == "zip" {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="templates.zip"`)
+		u.WriteZip(w, files)
+	} else {
+		w.Header().Set("Content-Type", "application/x-tar")
+		w.Header().Set("Content-Disposition", `attachment; filename="templates.tar"`)
+		u.WriteTar(w, files)
+	}
+}
@@ -31,6 +31,9 @@ db.createCollection('sensor');
 db.createCollection('stray_device');
 db.createCollection('stray_sensor');
 
+//Domains
+db.createCollection('domain');
+
 
 //Enforce unique Tenant Names
 db.tenant.createIndex( {"name":1}, { unique: true } );
@@ -49,6 +52,21 @@ db.room_template.createIndex({slug:1}, { unique: true });
 db.obj_template.createIndex({slug:1}, { unique: true });
 db.bldg_template.createIndex({slug:1}, { unique: true });
 
+//Immutable versions of the templates
+db.createCollection('template_version');
+db.template_version.createIndex({collection:1, slug:1, version:1}, { unique: true });
+
+
+//Assets, their content is stored once
+db.createCollection('asset');
+db.asset.createIndex({hash:1}, { unique: true });
+
+//Attachments and notes of the objects
+db.createCollection('attachment');
+db.attachment.createIndex({objectId:1, createdDate:-1});
+db.attachment.createIndex({asset:1});
+db.createCollection('note');
+db.note.createIndex({objectId:1, createdDate:-1});
 
 //Unique children restriction for nonhierarchal objects and sensors
 db.ac.createIndex({parentId:1, name:1}, { unique: true });
@@ -64,4 +82,14 @@ db.group.createIndex({parentId:1, name:1}, { unique: true });
 
 //Enforce unique stray objects
 db.stray_device.createIndex({parentId:1,name:1}, { unique: true });
-db.stray_sensor.createIndex({name:1}, { unique: true });
\ No newline at end of file
+db.stray_sensor.createIndex({name:1}, { unique: true });
+
+//Enforce unique domains, subdomains are named after their parent
+db.domain.createIndex({hierarchyName:1}, { unique: true });
+
+//Responses of create requests sent with an Idempotency-Key
+db.createCollection('idempotency_key');
+db.idempotency_key.createIndex({expiresAt:1}, { expireAfterSeconds: 0 });
+
+//Background jobs
+db.createCollection('job');
@@ -2,8 +2,10 @@ package main
 
 import (
 	"fmt"
+	"p3/api"
 	"p3/app"
 	"p3/controllers"
+	"p3/models"
 
 	"net/http"
 	"os"
@@ -20,7 +22,7 @@ var dmatch mux.MatcherFunc = func(request *http.Request, match *mux.RouteMatch)
 	//https://stackoverflow.com/questions/21664489/
 	//golang-mux-routing-wildcard-custom-func-match
 	println("Checking MATCH")
-	return regexp.MustCompile(`^(\/api\/(tenants|sites|buildings|rooms|acs|panels|cabinets|groups|corridors|racks|devices|sensors|stray-(devices|sensors)|(room|obj|bldg)-templates)\?.*)$`).
+	return regexp.MustCompile(`^(\/api\/(tenants|sites|buildings|rooms|acs|panels|cabinets|groups|corridors|racks|devices|sensors|stray-(devices|sensors)|(room|obj|bldg)-templates|domains)\?.*)$`).
 		MatchString(request.URL.String())
 }
 
@@ -78,7 +80,132 @@ func Router(jwt func(next http.Handler) http.Handler) *mux.Router {
 	router.HandleFunc("/api/hierarchy",
 		controllers.GetCompleteHierarchy).Methods("GET", "OPTIONS", "HEAD")
 
+	// Long-running operations
+	router.HandleFunc("/api/jobs",
+		controllers.CreateJob).Methods("POST")
+
+	router.HandleFunc("/api/jobs/{id:[a-zA-Z0-9]{24}}",
+		controllers.GetJob).Methods("GET", "HEAD", "OPTIONS")
+
+	router.HandleFunc("/api/jobs/{id:[a-zA-Z0-9]{24}}",
+		controllers.CancelJob).Methods("DELETE")
+
+	// Schema extensions
+	router.HandleFunc("/api/schemas/extensions",
+		controllers.GetSchemaExtensions).Methods("GET", "HEAD", "OPTIONS")
+
+	router.HandleFunc("/api/schemas/extensions",
+		controllers.CreateSchemaExtension).Methods("POST")
+
+	router.HandleFunc("/api/schemas/extensions/{id:[a-zA-Z0-9]{24}}",
+		controllers.GetSchemaExtension).Methods("GET", "HEAD", "OPTIONS")
+
+	router.HandleFunc("/api/schemas/extensions/{id:[a-zA-Z0-9]{24}}",
+		controllers.UpdateSchemaExtension).Methods("PUT")
+
+	router.HandleFunc("/api/schemas/extensions/{id:[a-zA-Z0-9]{24}}",
+		controllers.DeleteSchemaExtension).Methods("DELETE")
+
+	// Subtree operations
+	router.HandleFunc("/api/objects/{name}/move",
+		controllers.MoveObject).Methods("POST")
+
+	router.HandleFunc("/api/objects/{name}/clone",
+		controllers.CloneObject).Methods("POST")
+
+	// Attachments and notes
+	router.HandleFunc("/api/objects/{name}/attachments",
+		controllers.GetObjectAttachments).Methods("GET", "HEAD", "OPTIONS")
+
+	router.HandleFunc("/api/objects/{name}/attachments",
+		controllers.CreateAttachment).Methods("POST")
+
+	router.HandleFunc("/api/objects/{name}/attachments/{id:[a-zA-Z0-9]{24}}",
+		controllers.GetAttachment).Methods("GET", "HEAD", "OPTIONS")
+
+	router.HandleFunc("/api/objects/{name}/attachments/{id:[a-zA-Z0-9]{24}}",
+		controllers.DeleteAttachment).Methods("DELETE")
+
+	router.HandleFunc("/api/objects/{name}/notes",
+		controllers.GetObjectNotes).Methods("GET", "HEAD", "OPTIONS")
+
+	router.HandleFunc("/api/objects/{name}/notes",
+		controllers.CreateNote).Methods("POST")
+
+	router.HandleFunc("/api/objects/{name}/notes/{id:[a-zA-Z0-9]{24}}",
+		controllers.UpdateNote).Methods("PUT", "OPTIONS")
+
+	router.HandleFunc("/api/objects/{name}/notes/{id:[a-zA-Z0-9]{24}}",
+		controllers.DeleteNote).Methods("DELETE")
+
+	router.HandleFunc("/api/graphql",
+		controllers.GraphQL).Methods("GET", "POST", "OPTIONS")
+
+	router.HandleFunc("/api/diff",
+		controllers.GetDiff).Methods("GET", "HEAD", "OPTIONS")
+
+	router.HandleFunc("/api/search",
+		controllers.Search).Methods("GET", "HEAD", "OPTIONS")
+
+	router.HandleFunc("/api/complete",
+		controllers.Complete).Methods("GET", "HEAD", "OPTIONS")
+
+	// Rack and chassis occupancy
+	router.HandleFunc("/api/racks/{id:[a-zA-Z0-9]{24}}/occupancy",
+		controllers.GetRackOccupancy).Methods("GET", "HEAD", "OPTIONS")
+
+	router.HandleFunc("/api/racks/{name}/occupancy",
+		controllers.GetRackOccupancy).Methods("GET", "HEAD", "OPTIONS")
+
+	router.HandleFunc("/api/devices/{id:[a-zA-Z0-9]{24}}/slots",
+		controllers.GetDeviceSlots).Methods("GET", "HEAD", "OPTIONS")
+
+	router.HandleFunc("/api/devices/{name}/slots",
+		controllers.GetDeviceSlots).Methods("GET", "HEAD", "OPTIONS")
+
+	// Room collisions
+	router.HandleFunc("/api/rooms/{id:[a-zA-Z0-9]{24}}/collisions",
+		controllers.GetRoomCollisions).Methods("GET", "HEAD", "OPTIONS")
+
+	router.HandleFunc("/api/rooms/{name}/collisions",
+		controllers.GetRoomCollisions).Methods("GET", "HEAD", "OPTIONS")
+
+	// Assets
+	router.HandleFunc("/api/assets",
+		controllers.CreateAsset).Methods("POST", "OPTIONS")
+
+	router.HandleFunc("/api/assets",
+		controllers.GetAssets).Methods("GET", "HEAD")
+
+	router.HandleFunc("/api/assets/{id:[a-zA-Z0-9]{24}}",
+		controllers.GetAsset).Methods("GET", "HEAD", "OPTIONS")
+
+	router.HandleFunc("/api/assets/{id:[a-zA-Z0-9]{24}}",
+		controllers.DeleteAsset).Methods("DELETE")
+
+	router.HandleFunc("/api/assets/{id:[a-zA-Z0-9]{24}}/usages",
+		controllers.GetAssetUsages).Methods("GET", "HEAD", "OPTIONS")
+
+	// Templates
+	router.HandleFunc("/api/templates/bundle",
+		controllers.ImportTemplateBundle).Methods("POST", "OPTIONS")
+
+	router.HandleFunc("/api/templates/bundle",
+		controllers.ExportTemplateBundle).Methods("GET", "HEAD")
+
+	router.HandleFunc("/api/{entity:obj-templates|room-templates|bldg-templates}/{slug}/usages",
+		controllers.GetTemplateUsages).Methods("GET", "HEAD", "OPTIONS")
+
+	router.HandleFunc("/api/{entity:obj-templates|room-templates|bldg-templates}/{slug}/versions",
+		controllers.GetTemplateVersions).Methods("GET", "HEAD", "OPTIONS")
+
+	router.HandleFunc("/api/{entity:obj-templates|room-templates|bldg-templates}/{slug}/upgrade",
+		controllers.UpgradeTemplateInstances).Methods("POST", "OPTIONS")
+
 	// ------ GET ------ //
+	router.HandleFunc("/api/objects",
+		controllers.GetObjectsByPath).Methods("GET", "HEAD", "OPTIONS")
+
 	router.HandleFunc("/api/objects/{name}",
 		controllers.GetGenericObject).Methods("GET", "HEAD", "OPTIONS")
 
@@ -141,6 +268,12 @@ func Router(jwt func(next http.Handler) http.Handler) *mux.Router {
 		controllers.DeleteEntity).Methods("DELETE")
 
 	// UPDATE ENTITY
+	router.HandleFunc("/api/{entity}s/{id:[a-zA-Z0-9]{24}}/tags",
+		controllers.UpdateTags).Methods("PATCH")
+
+	router.HandleFunc("/api/{entity}s/{name}/tags",
+		controllers.UpdateTags).Methods("PATCH")
+
 	router.HandleFunc("/api/{entity}s/{id:[a-zA-Z0-9]{24}}",
 		controllers.UpdateEntity).Methods("PUT", "PATCH")
 
@@ -169,6 +302,18 @@ func main() {
 	//https://medium.com/@matryer/writing-middleware-in-golang-and-how-go-makes-it-so-much-fun-4375c1246e81
 	router := Router(app.JwtAuthentication)
 
+	//Resume jobs left pending by a previous run
+	models.StartJobRunner()
+
+	//Indexes of the search and the completion
+	models.EnsureSearchIndexes()
+	models.EnsureHierarchyNameIndexes()
+	models.EnsureAssetIndexes()
+	models.EnsureHistoryIndexes()
+
+	//Objects created before domains were checked
+	models.CreateMissingDomains()
+
 	//Get port from .env file, no port was specified
 	//So this should return an empty string when
 	//tested locally
@@ -179,6 +324,17 @@ func main() {
 
 	fmt.Println(port)
 
+	//gRPC API on its own port
+	grpcPort := os.Getenv("grpc_port")
+	if grpcPort == "" {
+		grpcPort = "3002"
+	}
+	go func() {
+		if err := api.Serve(grpcPort); err != nil {
+			fmt.Println("gRPC server stopped:", err)
+		}
+	}()
+
 	//Start app, localhost:8000/api
 	err := http.ListenAndServe(":"+port, router)
 	if err != nil {
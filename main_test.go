@@ -2,41 +2,82 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"p3/models"
 	u "p3/utils"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/go-playground/assert/v2"
 )
 
 func TestMain(m *testing.M) {
+	models.EnsureSearchIndexes()
+	models.EnsureHierarchyNameIndexes()
+	models.EnsureAssetIndexes()
+	models.EnsureHistoryIndexes()
+	createDomains()
 	exitCode := m.Run()
 	//teardown()
 	os.Exit(exitCode)
 }
 
+// createDomains: domains the test objects belong to,
+// they may already exist from a previous run
+func createDomains() {
+	for _, hierarchyName := range []string{"DEMO", "SomeDomain", "NEW", "EXTFIN", "EXTFIN.PAY"} {
+		domain := map[string]interface{}{
+			"category":    "domain",
+			"description": []interface{}{},
+			"attributes":  map[string]interface{}{"color": "FFFFFF"},
+		}
+		if i := strings.LastIndex(hierarchyName, "."); i >= 0 {
+			domain["parentId"] = hierarchyName[:i]
+			domain["name"] = hierarchyName[i+1:]
+		} else {
+			domain["name"] = hierarchyName
+		}
+		models.CreateEntity(u.DOMAIN, domain)
+	}
+}
+
 func teardown() {
 	ctx, _ := u.Connect()
 	models.GetDB().Drop(ctx)
 }
 
+// JwtAuthSkip: no token in the tests, the user
+// sending a request can be given with X-Test-User
 var JwtAuthSkip = func(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user := r.Header.Get("X-Test-User"); user != "" {
+			r = r.WithContext(context.WithValue(r.Context(), "user", user))
+		}
 		next.ServeHTTP(w, r)
 	})
 }
 
 func makeRequest(method, url string, requestBody []byte) *httptest.ResponseRecorder {
+	return makeRequestWithHeaders(method, url, requestBody, nil)
+}
+
+func makeRequestWithHeaders(method, url string, requestBody []byte, headers map[string]string) *httptest.ResponseRecorder {
 	router := Router(JwtAuthSkip)
 	recorder := httptest.NewRecorder()
 	request, _ := http.NewRequest(method, url, bytes.NewBuffer(requestBody))
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
 	router.ServeHTTP(recorder, request)
 	return recorder
 }
@@ -170,3 +211,1949 @@ func TestObjects(t *testing.T) {
 	assert.Equal(t, 0,
 		len(response["data"].(map[string]interface{})["tree"].(map[string]interface{})))
 }
+
+func TestConditionalRequests(t *testing.T) {
+	requestBody := []byte(`{
+		"name": "ETAGTENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF"
+		}
+	}`)
+	recorder := makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	// GET returns the first revision
+	recorder = makeRequest("GET", "/api/tenants/ETAGTENANT", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	etag := recorder.Header().Get("ETag")
+	assert.Equal(t, `"1"`, etag)
+
+	// Unchanged object is not sent again
+	recorder = makeRequestWithHeaders("GET", "/api/tenants/ETAGTENANT", nil,
+		map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+
+	// Update with the right revision
+	requestBody = []byte(`{"description": ["first"]}`)
+	recorder = makeRequestWithHeaders("PATCH", "/api/tenants/ETAGTENANT", requestBody,
+		map[string]string{"If-Match": etag})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `"2"`, recorder.Header().Get("ETag"))
+
+	// A second writer with the old revision is refused
+	requestBody = []byte(`{"description": ["second"]}`)
+	recorder = makeRequestWithHeaders("PATCH", "/api/tenants/ETAGTENANT", requestBody,
+		map[string]string{"If-Match": etag})
+	assert.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+
+	recorder = makeRequestWithHeaders("DELETE", "/api/tenants/ETAGTENANT", nil,
+		map[string]string{"If-Match": etag})
+	assert.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+
+	recorder = makeRequestWithHeaders("DELETE", "/api/tenants/ETAGTENANT", nil,
+		map[string]string{"If-Match": `"2"`})
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	tenant := func(description string) []byte {
+		return []byte(`{
+			"name": "RACETENANT",
+			"category": "tenant",
+			"description": ["` + description + `"],
+			"domain": "DEMO",
+			"attributes": {
+				"color": "FFFFFF"
+			}
+		}`)
+	}
+	recorder := makeRequest("POST", "/api/tenants", tenant("created"))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	// Writers without If-Match never give the same
+	// revision to different contents
+	var mutex sync.Mutex
+	etags := map[string]int{}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			recorder := makeRequest("PUT", "/api/tenants/RACETENANT", tenant(strconv.Itoa(i)))
+			if recorder.Code == http.StatusOK {
+				mutex.Lock()
+				etags[recorder.Header().Get("ETag")]++
+				mutex.Unlock()
+			} else {
+				assert.Equal(t, http.StatusConflict, recorder.Code)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for etag, count := range etags {
+		assert.Equal(t, etag+":1", etag+":"+strconv.Itoa(count))
+	}
+
+	recorder = makeRequest("DELETE", "/api/tenants/RACETENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestStandardPatch(t *testing.T) {
+	var response map[string]interface{}
+	requestBody := []byte(`{
+		"name": "PATCHTENANT",
+		"category": "tenant",
+		"description": ["first"],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF",
+			"mainContact": "Moi"
+		}
+	}`)
+	recorder := makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	// JSON Patch: append to description and remove an attribute
+	requestBody = []byte(`[
+		{"op": "add", "path": "/description/-", "value": "second"},
+		{"op": "remove", "path": "/attributes/mainContact"}
+	]`)
+	recorder = makeRequestWithHeaders("PATCH", "/api/tenants/PATCHTENANT", requestBody,
+		map[string]string{"Content-Type": "application/json-patch+json"})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"first", "second"}, data["description"])
+	_, exists := data["attributes"].(map[string]interface{})["mainContact"]
+	assert.Equal(t, false, exists)
+
+	// A failing test operation aborts the whole patch
+	requestBody = []byte(`[
+		{"op": "test", "path": "/domain", "value": "OTHER"},
+		{"op": "replace", "path": "/domain", "value": "NEW"}
+	]`)
+	recorder = makeRequestWithHeaders("PATCH", "/api/tenants/PATCHTENANT", requestBody,
+		map[string]string{"Content-Type": "application/json-patch+json"})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Merge Patch: the result must still match the schema
+	requestBody = []byte(`{"attributes": {"color": null}}`)
+	recorder = makeRequestWithHeaders("PATCH", "/api/tenants/PATCHTENANT", requestBody,
+		map[string]string{"Content-Type": "application/merge-patch+json"})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	requestBody = []byte(`{"domain": "NEW", "attributes": {"mainPhone": "0612345678"}}`)
+	recorder = makeRequestWithHeaders("PATCH", "/api/tenants/PATCHTENANT", requestBody,
+		map[string]string{"Content-Type": "application/merge-patch+json"})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	data = response["data"].(map[string]interface{})
+	assert.Equal(t, "NEW", data["domain"])
+	assert.Equal(t, "FFFFFF", data["attributes"].(map[string]interface{})["color"])
+
+	recorder = makeRequest("DELETE", "/api/tenants/PATCHTENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestIdempotentCreate(t *testing.T) {
+	var first, replay map[string]interface{}
+	requestBody := []byte(`{
+		"name": "IDEMPOTENTTENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF"
+		}
+	}`)
+	// Keys outlive the test, make it unique
+	headers := map[string]string{"Idempotency-Key": "import-job-" + strconv.FormatInt(time.Now().UnixNano(), 10)}
+	recorder := makeRequestWithHeaders("POST", "/api/tenants", requestBody, headers)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &first)
+
+	// Retry gets the same response, nothing is created
+	recorder = makeRequestWithHeaders("POST", "/api/tenants", requestBody, headers)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, "true", recorder.Header().Get("Idempotent-Replayed"))
+	json.Unmarshal(recorder.Body.Bytes(), &replay)
+	assert.Equal(t, first["data"].(map[string]interface{})["id"],
+		replay["data"].(map[string]interface{})["id"])
+
+	// Same key with another body
+	requestBody = []byte(`{
+		"name": "IDEMPOTENTTENANT2",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF"
+		}
+	}`)
+	recorder = makeRequestWithHeaders("POST", "/api/tenants", requestBody, headers)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+	recorder = makeRequest("DELETE", "/api/tenants/IDEMPOTENTTENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	// A key whose request stopped without response is taken over
+	// once its lease expired
+	key := "lease-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	_, _, e := models.ReserveIdempotencyKey(key, "hash")
+	assert.Equal(t, "", e)
+	_, _, e = models.ReserveIdempotencyKey(key, "hash")
+	assert.Equal(t, "in progress", e)
+	ctx, cancel := u.Connect()
+	defer cancel()
+	models.GetDB().Collection("idempotency_key").UpdateOne(ctx, map[string]interface{}{"_id": key},
+		map[string]interface{}{"$set": map[string]interface{}{"lockedUntil": time.Now().Add(-time.Second)}})
+	_, _, e = models.ReserveIdempotencyKey(key, "hash")
+	assert.Equal(t, "", e)
+	models.ReleaseIdempotencyKey(key)
+}
+
+func TestJobs(t *testing.T) {
+	var response map[string]interface{}
+	requestBody := []byte(`{
+		"name": "JOBTENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF"
+		}
+	}`)
+	recorder := makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	// Unknown job type
+	recorder = makeRequest("POST", "/api/jobs", []byte(`{"type": "banana"}`))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Asynchronous delete
+	recorder = makeRequest("DELETE", "/api/tenants/JOBTENANT?async=true", nil)
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	location := recorder.Header().Get("Location")
+
+	status := ""
+	for i := 0; i < 50 && status != "done"; i++ {
+		time.Sleep(100 * time.Millisecond)
+		recorder = makeRequest("GET", location, nil)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		status = response["data"].(map[string]interface{})["status"].(string)
+	}
+	assert.Equal(t, "done", status)
+
+	// Jobs are only seen by the user who submitted them
+	other := map[string]string{"X-Test-User": "other@test.com"}
+	recorder = makeRequestWithHeaders("GET", location, nil, other)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	recorder = makeRequestWithHeaders("DELETE", location, nil, other)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// Finished jobs can't be cancelled
+	recorder = makeRequest("DELETE", location, nil)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	recorder = makeRequest("GET", "/api/tenants/JOBTENANT", nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+// Racks created from the example by room
+var (
+	roomRacks      = map[string]int{}
+	roomRacksMutex sync.Mutex
+)
+
+// rackPosition: posXYZ of the nth rack (from 0) of the example room,
+// x -13 to 0m and y -2.9 to 0m. The first one is at the position of
+// the example (x -4.67 to -3.87m), up to 10 others fit on its left
+func rackPosition(n int) string {
+	x := -4.6666666666667
+	if n > 0 {
+		x = -13 + 0.8*float64(n-1)
+	}
+	return `{"x":` + strconv.FormatFloat(x, 'f', -1, 64) + `,"y":-2,"z":0}`
+}
+
+// createFromExample: creates an object of entStr from its schema
+// example, with the given parent and name. Returns its id
+func createFromExample(t *testing.T, entStr, parentId, name string) string {
+	return createFromExampleWith(t, entStr, parentId, name, nil)
+}
+
+// createFromExampleWith: same as createFromExample, some attributes
+// of the example replaced (ex: the position of a second rack)
+func createFromExampleWith(t *testing.T, entStr, parentId, name string, attributes map[string]interface{}) string {
+	var response map[string]interface{}
+	data, _ := ioutil.ReadFile("models/schemas/" + entStr + "_schema.json")
+	var obj map[string]interface{}
+	json.Unmarshal(data, &obj)
+	obj = obj["examples"].([]interface{})[0].(map[string]interface{})
+	obj["parentId"] = parentId
+	obj["name"] = name
+	if entStr == "rack" {
+		// Racks of the same room don't overlap
+		roomRacksMutex.Lock()
+		if n := roomRacks[parentId]; n > 0 {
+			obj["attributes"].(map[string]interface{})["posXYZ"] = rackPosition(n)
+		}
+		roomRacks[parentId]++
+		roomRacksMutex.Unlock()
+	}
+	for k, v := range attributes {
+		obj["attributes"].(map[string]interface{})[k] = v
+	}
+	data, _ = json.Marshal(obj)
+
+	recorder := makeRequest("POST", "/api/"+entStr+"s", data)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	return response["data"].(map[string]interface{})["id"].(string)
+}
+
+func TestMoveObject(t *testing.T) {
+	var response map[string]interface{}
+	requestBody := []byte(`{
+		"name": "MOVETENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF"
+		}
+	}`)
+	recorder := makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	tenantId := response["data"].(map[string]interface{})["id"].(string)
+
+	siteId := createFromExample(t, "site", tenantId, "S1")
+	bldgId := createFromExample(t, "building", siteId, "B1")
+	room1Id := createFromExample(t, "room", bldgId, "R1")
+	createFromExample(t, "room", bldgId, "R2")
+	rackId := createFromExample(t, "rack", room1Id, "A01")
+	createFromExample(t, "device", rackId, "D1")
+
+	// A rack can't go under a building
+	recorder = makeRequest("POST", "/api/objects/MOVETENANT.S1.B1.R1.A01/move",
+		[]byte(`{"parent": "MOVETENANT.S1.B1"}`))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Move the rack and its device
+	recorder = makeRequest("POST", "/api/objects/MOVETENANT.S1.B1.R1.A01/move",
+		[]byte(`{"parent": "MOVETENANT.S1.B1.R2"}`))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, 2, len(response["data"].([]interface{})))
+
+	recorder = makeRequest("GET", "/api/devices/MOVETENANT.S1.B1.R2.A01.D1", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = makeRequest("GET", "/api/racks/MOVETENANT.S1.B1.R1.A01", nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// Name clash
+	createFromExample(t, "rack", room1Id, "A01")
+	recorder = makeRequest("POST", "/api/objects/MOVETENANT.S1.B1.R1.A01/move",
+		[]byte(`{"parent": "MOVETENANT.S1.B1.R2"}`))
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	recorder = makeRequest("DELETE", "/api/tenants/MOVETENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestCloneObject(t *testing.T) {
+	var response map[string]interface{}
+	requestBody := []byte(`{
+		"name": "CLONETENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF"
+		}
+	}`)
+	recorder := makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	tenantId := response["data"].(map[string]interface{})["id"].(string)
+
+	siteId := createFromExample(t, "site", tenantId, "S1")
+	bldgId := createFromExample(t, "building", siteId, "B1")
+	roomId := createFromExample(t, "room", bldgId, "R1")
+	rackId := createFromExample(t, "rack", roomId, "A01")
+	createFromExample(t, "device", rackId, "D1")
+
+	// Copy the room with its rack and device
+	recorder = makeRequest("POST", "/api/objects/CLONETENANT.S1.B1.R1/clone",
+		[]byte(`{"parent": "CLONETENANT.S1.B1", "name": "R2",
+			"attributes": {"description": "copy"}}`))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, 3, len(response["data"].([]interface{})))
+
+	recorder = makeRequest("GET", "/api/devices/CLONETENANT.S1.B1.R2.A01.D1", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = makeRequest("GET", "/api/devices/CLONETENANT.S1.B1.R1.A01.D1", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// Name clash
+	recorder = makeRequest("POST", "/api/objects/CLONETENANT.S1.B1.R1/clone",
+		[]byte(`{"parent": "CLONETENANT.S1.B1", "name": "R2"}`))
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	recorder = makeRequest("DELETE", "/api/tenants/CLONETENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestDiff(t *testing.T) {
+	var response map[string]interface{}
+	requestBody := []byte(`{
+		"name": "DIFFTENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF"
+		}
+	}`)
+	recorder := makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	tenantId := response["data"].(map[string]interface{})["id"].(string)
+
+	siteId := createFromExample(t, "site", tenantId, "S1")
+	bldgId := createFromExample(t, "building", siteId, "B1")
+	room1Id := createFromExample(t, "room", bldgId, "R1")
+	room2Id := createFromExample(t, "room", bldgId, "R2")
+	rackId := createFromExample(t, "rack", room1Id, "A01")
+	createFromExample(t, "rack", room2Id, "A01")
+	deviceId := createFromExample(t, "device", rackId, "D1")
+
+	recorder = makeRequest("GET",
+		"/api/diff?left=DIFFTENANT.S1.B1.R1&right=DIFFTENANT.S1.B1.R2", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	diff := response["data"].(map[string]interface{})
+	assert.Equal(t, 1, len(diff["removed"].([]interface{})))
+	assert.Equal(t, "A01.D1",
+		diff["removed"].([]interface{})[0].(map[string]interface{})["name"])
+	assert.Equal(t, 0, len(diff["added"].([]interface{})))
+
+	recorder = makeRequest("GET",
+		"/api/diff?left=DIFFTENANT.S1.B1.R1&right=DIFFTENANT.S1.B1.R2&format=unified", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, true, strings.Contains(recorder.Body.String(), "- A01.D1 (device)"))
+
+	recorder = makeRequest("GET", "/api/diff?left=DIFFTENANT.S1.B1.R1", nil)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Compare the room with itself as it was before changes
+	time.Sleep(10 * time.Millisecond)
+	before := url.QueryEscape("DIFFTENANT.S1.B1.R1@" + time.Now().UTC().Format(time.RFC3339Nano))
+	time.Sleep(10 * time.Millisecond)
+	recorder = makeRequest("PATCH", "/api/racks/"+rackId, []byte(`{"description": ["changed"]}`))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = makeRequest("DELETE", "/api/devices/"+deviceId, nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	recorder = makeRequest("GET", "/api/diff?left="+before+"&right=DIFFTENANT.S1.B1.R1", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	diff = response["data"].(map[string]interface{})
+	assert.Equal(t, 1, len(diff["removed"].([]interface{})))
+	assert.Equal(t, "A01.D1",
+		diff["removed"].([]interface{})[0].(map[string]interface{})["name"])
+	assert.Equal(t, 1, len(diff["changed"].([]interface{})))
+	changed := diff["changed"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "A01", changed["name"])
+	assert.Equal(t, "description",
+		changed["fields"].([]interface{})[0].(map[string]interface{})["field"])
+
+	recorder = makeRequest("GET", "/api/diff?left="+before+"&right="+before, nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	diff = response["data"].(map[string]interface{})
+	assert.Equal(t, 0, len(diff["removed"].([]interface{})))
+	assert.Equal(t, 0, len(diff["changed"].([]interface{})))
+
+	// The room didn't exist then
+	recorder = makeRequest("GET",
+		"/api/diff?left=DIFFTENANT.S1.B1.R1@2023-01-01&right=DIFFTENANT.S1.B1.R1", nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	recorder = makeRequest("GET",
+		"/api/diff?left=DIFFTENANT.S1.B1.R1@yesterday&right=DIFFTENANT.S1.B1.R1", nil)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = makeRequest("DELETE", "/api/tenants/DIFFTENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestGraphQL(t *testing.T) {
+	var response map[string]interface{}
+	requestBody := []byte(`{
+		"name": "GQLTENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF"
+		}
+	}`)
+	recorder := makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	tenantId := response["data"].(map[string]interface{})["id"].(string)
+	createFromExample(t, "site", tenantId, "S1")
+	createFromExample(t, "site", tenantId, "S2")
+
+	// Navigate down and up
+	requestBody = []byte(`{"query":
+		"{ tenant(hierarchyName: \"GQLTENANT\") { name sites(name: \"S2\") { hierarchyName parent { name } } } }"}`)
+	recorder = makeRequest("POST", "/api/graphql", requestBody)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	tenant := response["data"].(map[string]interface{})["tenant"].(map[string]interface{})
+	sites := tenant["sites"].([]interface{})
+	assert.Equal(t, 1, len(sites))
+	site := sites[0].(map[string]interface{})
+	assert.Equal(t, "GQLTENANT.S2", site["hierarchyName"])
+	assert.Equal(t, "GQLTENANT", site["parent"].(map[string]interface{})["name"])
+
+	// Mutations are validated
+	requestBody = []byte(`{"query":
+		"mutation { createSite(data: {name: \"S3\", category: \"site\"}) { id } }"}`)
+	recorder = makeRequest("POST", "/api/graphql", requestBody)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, 1, len(response["errors"].([]interface{})))
+
+	// Mutations can't be sent with GET, even after a comment or a fragment
+	for _, query := range []string{
+		"mutation { deleteSite(hierarchyName: \"GQLTENANT.S2\") }",
+		"# comment\nmutation { deleteSite(hierarchyName: \"GQLTENANT.S2\") }",
+		"fragment f on Site { id }\nmutation { deleteSite(hierarchyName: \"GQLTENANT.S2\") }",
+	} {
+		recorder = makeRequest("GET", "/api/graphql?query="+url.QueryEscape(query), nil)
+		assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	}
+	recorder = makeRequest("GET", "/api/graphql?query="+
+		url.QueryEscape("query Sites { tenant(hierarchyName: \"GQLTENANT\") { name } }"), nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = makeRequest("GET", "/api/sites/GQLTENANT.S2", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = makeRequest("DELETE", "/api/tenants/GQLTENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestGetObjectsByPath(t *testing.T) {
+	var response map[string]interface{}
+	requestBody := []byte(`{
+		"name": "PATHTENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF"
+		}
+	}`)
+	recorder := makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	tenantId := response["data"].(map[string]interface{})["id"].(string)
+
+	siteId := createFromExample(t, "site", tenantId, "S1")
+	bldgId := createFromExample(t, "building", siteId, "B1")
+	room1Id := createFromExample(t, "room", bldgId, "R1")
+	room2Id := createFromExample(t, "room", bldgId, "R2")
+	rackId := createFromExample(t, "rack", room1Id, "rack1")
+	createFromExample(t, "rack", room2Id, "rack2")
+	createFromExample(t, "device", rackId, "rackserver")
+
+	count := func(path string) int {
+		recorder := makeRequest("GET", "/api/objects?"+path, nil)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		return len(response["data"].(map[string]interface{})["objects"].([]interface{}))
+	}
+	assert.Equal(t, 2, count("path=PATHTENANT.S1.B1.*.rack*"))
+	assert.Equal(t, 3, count("path=PATHTENANT.**.rack*"))
+	assert.Equal(t, 2, count("path=PATHTENANT.**.rack*&category=rack"))
+	assert.Equal(t, 1, count("path=PATHTENANT.S1.B1.{R1,R3}"))
+	// Dots are not wildcards
+	assert.Equal(t, 0, count("path=PATHTENANT.S1XB1"))
+
+	recorder = makeRequest("GET", "/api/objects?path=PATHTENANT.{S1", nil)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = makeRequest("DELETE", "/api/tenants/PATHTENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestSearch(t *testing.T) {
+	var response map[string]interface{}
+	requestBody := []byte(`{
+		"name": "SEARCHTENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF"
+		}
+	}`)
+	recorder := makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	tenantId := response["data"].(map[string]interface{})["id"].(string)
+
+	siteId := createFromExample(t, "site", tenantId, "S1")
+	bldgId := createFromExample(t, "building", siteId, "B1")
+	roomId := createFromExample(t, "room", bldgId, "R1")
+	rackId := createFromExample(t, "rack", roomId, "A01")
+	createFromExample(t, "device", rackId, "searchsrv01")
+	createFromExample(t, "device", rackId, "searchsrv02")
+
+	recorder = makeRequest("GET", "/api/search?q=searchsrv01", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	objects := response["data"].(map[string]interface{})["objects"].([]interface{})
+	assert.Equal(t, true, len(objects) > 0)
+	first := objects[0].(map[string]interface{})
+	assert.Equal(t, "device", first["type"])
+	assert.Equal(t, "SEARCHTENANT.S1.B1.R1.A01.searchsrv01", first["path"])
+
+	// Pagination
+	recorder = makeRequest("GET", "/api/search?q=SEARCHTENANT&limit=1&page=2", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, 1, len(data["objects"].([]interface{})))
+	assert.Equal(t, true, data["total"].(float64) > 1)
+
+	recorder = makeRequest("GET", "/api/search?q=", nil)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = makeRequest("GET", "/api/search?q=x&limit=1000", nil)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = makeRequest("DELETE", "/api/tenants/SEARCHTENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestComplete(t *testing.T) {
+	var response map[string]interface{}
+	requestBody := []byte(`{
+		"name": "COMPLETETENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF"
+		}
+	}`)
+	recorder := makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	tenantId := response["data"].(map[string]interface{})["id"].(string)
+
+	siteId := createFromExample(t, "site", tenantId, "S1")
+	bldgId := createFromExample(t, "building", siteId, "B1")
+	createFromExample(t, "building", siteId, "B2")
+	createFromExample(t, "building", siteId, "C1")
+	createFromExample(t, "room", bldgId, "R1")
+
+	complete := func(query string) []interface{} {
+		recorder := makeRequest("GET", "/api/complete?"+query, nil)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		return response["data"].(map[string]interface{})["objects"].([]interface{})
+	}
+	objects := complete("prefix=COMPLETETENANT.S1.B")
+	assert.Equal(t, 2, len(objects))
+	assert.Equal(t, "COMPLETETENANT.S1.B1", objects[0].(map[string]interface{})["hierarchyName"])
+	assert.Equal(t, "building", objects[0].(map[string]interface{})["category"])
+	assert.Equal(t, 3, len(complete("prefix=COMPLETETENANT.S1.")))
+	assert.Equal(t, 1, len(complete("prefix=COMPLETETENANT.S1.&limit=1")))
+	assert.Equal(t, 1, len(complete("prefix=COMPLETETENANT")))
+	// Dots are not wildcards
+	assert.Equal(t, 0, len(complete("prefix=COMPLETETENANTXS1.")))
+
+	recorder = makeRequest("DELETE", "/api/tenants/COMPLETETENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestTags(t *testing.T) {
+	var response map[string]interface{}
+	requestBody := []byte(`{
+		"name": "TAGSTENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF"
+		},
+		"tags": {"env": "prod"}
+	}`)
+	recorder := makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	tenantId := response["data"].(map[string]interface{})["id"].(string)
+
+	siteId := createFromExample(t, "site", tenantId, "S1")
+	bldgId := createFromExample(t, "building", siteId, "B1")
+	roomId := createFromExample(t, "room", bldgId, "R1")
+	createFromExample(t, "rack", roomId, "A01")
+	createFromExample(t, "rack", roomId, "A02")
+
+	recorder = makeRequest("PATCH", "/api/racks/TAGSTENANT.S1.B1.R1.A01/tags",
+		[]byte(`{"env": "prod", "owner": "team-db", "decommission": ""}`))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = makeRequest("PATCH", "/api/racks/TAGSTENANT.S1.B1.R1.A02/tags",
+		[]byte(`{"env": "prod", "owner": "x"}`))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	// Removing a tag
+	recorder = makeRequest("PATCH", "/api/racks/TAGSTENANT.S1.B1.R1.A01/tags",
+		[]byte(`{"decommission": null}`))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	// Invalid key
+	recorder = makeRequest("PATCH", "/api/racks/TAGSTENANT.S1.B1.R1.A01/tags",
+		[]byte(`{"a.b": "c"}`))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	count := func(url string) int {
+		recorder := makeRequest("GET", url, nil)
+		if recorder.Code != http.StatusOK {
+			return 0
+		}
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		return len(response["data"].(map[string]interface{})["objects"].([]interface{}))
+	}
+	assert.Equal(t, 2, count("/api/racks?tags=env=prod&category=rack"))
+	assert.Equal(t, 1, count("/api/racks?tags=env=prod,owner!=x"))
+	assert.Equal(t, 0, count("/api/racks?tags=decommission"))
+	assert.Equal(t, 2, count("/api/objects?path=TAGSTENANT.**&tags=owner"))
+	assert.Equal(t, 1, count("/api/objects?path=TAGSTENANT*&tags=env==prod,!owner"))
+	// Lists of the children of an ancestor
+	assert.Equal(t, 1, count("/api/buildings/"+bldgId+"/racks?tags=owner=x"))
+	assert.Equal(t, 1, count("/api/rooms/"+roomId+"/racks?tags=owner=team-db"))
+
+	for _, url := range []string{"/api/racks?tags=a.b=c",
+		"/api/objects/TAGSTENANT?tags=a.b=c",
+		"/api/tenants/TAGSTENANT/all?tags=a.b=c",
+		"/api/buildings/" + bldgId + "/racks?tags=a.b=c",
+		"/api/rooms/" + roomId + "/racks?tags=a.b=c"} {
+		recorder = makeRequest("GET", url, nil)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	}
+
+	recorder = makeRequest("DELETE", "/api/tenants/TAGSTENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestSchemaExtensions(t *testing.T) {
+	var response map[string]interface{}
+	requestBody := []byte(`{
+		"name": "EXTTENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF"
+		}
+	}`)
+	recorder := makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	tenantId := response["data"].(map[string]interface{})["id"].(string)
+
+	siteId := createFromExample(t, "site", tenantId, "S1")
+	bldgId := createFromExample(t, "building", siteId, "B1")
+	roomId := createFromExample(t, "room", bldgId, "R1")
+
+	createRack := func(name, domain string, attributes map[string]interface{}) int {
+		data, _ := ioutil.ReadFile("models/schemas/rack_schema.json")
+		var obj map[string]interface{}
+		json.Unmarshal(data, &obj)
+		obj = obj["examples"].([]interface{})[0].(map[string]interface{})
+		obj["parentId"] = roomId
+		obj["name"] = name
+		obj["domain"] = domain
+		for k, v := range attributes {
+			obj["attributes"].(map[string]interface{})[k] = v
+		}
+		data, _ = json.Marshal(obj)
+		return makeRequest("POST", "/api/racks", data).Code
+	}
+
+	// Only admins change the extensions
+	os.Setenv("admins", "someone@test.com, admin@test.com")
+	admin := map[string]string{"X-Test-User": "admin@test.com"}
+	extension := []byte(`{"category": "rack", "domain": "EXTFIN", "description": "asset tags",
+		"schema": {"properties": {"attributes": {"required": ["assetTag"],
+			"properties": {"assetTag": {"type": "string"}}}}}}`)
+	recorder = makeRequest("POST", "/api/schemas/extensions", extension)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	recorder = makeRequestWithHeaders("POST", "/api/schemas/extensions", extension,
+		map[string]string{"X-Test-User": "user@test.com"})
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// Invalid schema
+	recorder = makeRequestWithHeaders("POST", "/api/schemas/extensions",
+		[]byte(`{"category": "rack", "schema": {"type": 3}}`), admin)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = makeRequestWithHeaders("POST", "/api/schemas/extensions", extension, admin)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	extId := response["data"].(map[string]interface{})["id"].(string)
+
+	recorder = makeRequestWithHeaders("POST", "/api/schemas/extensions",
+		[]byte(`{"category": "rack", "domain": "EXTFIN", "schema": {}}`), admin)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	// Applied at once, to the domain and its subdomains only
+	assert.Equal(t, http.StatusBadRequest, createRack("A01", "EXTFIN", nil))
+	assert.Equal(t, http.StatusBadRequest, createRack("A01", "EXTFIN.PAY", nil))
+	assert.Equal(t, http.StatusCreated, createRack("A01", "EXTFIN",
+		map[string]interface{}{"assetTag": "T1"}))
+	assert.Equal(t, http.StatusCreated, createRack("A02", "DEMO",
+		map[string]interface{}{"posXYZ": rackPosition(1)}))
+
+	// Flat patches are checked on the patched object
+	recorder = makeRequest("PATCH", "/api/racks/EXTTENANT.S1.B1.R1.A01",
+		[]byte(`{"attributes.assetTag": 5}`))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = makeRequest("PATCH", "/api/racks/EXTTENANT.S1.B1.R1.A01",
+		[]byte(`{"attributes.assetTag": "T2"}`))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// Extensions apply to an entity, not to the objects with the
+	// same category field (ex: obj_templates of category device)
+	recorder = makeRequestWithHeaders("POST", "/api/schemas/extensions",
+		[]byte(`{"category": "bldg", "schema": {}}`), admin)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = makeRequestWithHeaders("POST", "/api/schemas/extensions",
+		[]byte(`{"category": "device", "schema": {"properties": {"attributes":
+			{"required": ["assetTag"]}}}}`), admin)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	deviceExtId := response["data"].(map[string]interface{})["id"].(string)
+	recorder = makeRequest("POST", "/api/obj-templates", []byte(`{
+		"slug": "ext-test-device",
+		"description": "device",
+		"category": "device",
+		"sizeWDHmm": [440, 700, 44],
+		"fbxModel": "",
+		"attributes": {},
+		"colors": [],
+		"components": [],
+		"slots": []
+	}`))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	recorder = makeRequestWithHeaders("DELETE", "/api/schemas/extensions/"+deviceExtId, nil, admin)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	recorder = makeRequest("DELETE", "/api/obj-templates/ext-test-device", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	// An update replaces the whole extension
+	recorder = makeRequestWithHeaders("PUT", "/api/schemas/extensions/"+extId,
+		[]byte(`{"category": "rack", "domain": "EXTFIN", "schema": {}}`), admin)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	_, described := response["data"].(map[string]interface{})["description"]
+	assert.Equal(t, false, described)
+
+	recorder = makeRequest("DELETE", "/api/schemas/extensions/"+extId, nil)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	recorder = makeRequestWithHeaders("DELETE", "/api/schemas/extensions/"+extId, nil, admin)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, http.StatusCreated, createRack("A03", "EXTFIN",
+		map[string]interface{}{"posXYZ": rackPosition(2)}))
+
+	recorder = makeRequest("DELETE", "/api/tenants/EXTTENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestDomains(t *testing.T) {
+	var response map[string]interface{}
+	createDomain := func(name, parentId string) *httptest.ResponseRecorder {
+		domain := map[string]interface{}{
+			"name":        name,
+			"category":    "domain",
+			"description": []string{"test domain"},
+			"attributes":  map[string]interface{}{"color": "00ED00"},
+		}
+		if parentId != "" {
+			domain["parentId"] = parentId
+		}
+		data, _ := json.Marshal(domain)
+		return makeRequest("POST", "/api/domains", data)
+	}
+
+	recorder := createDomain("DOMORG", "")
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	recorder = createDomain("IT", "DOMORG")
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	recorder = createDomain("NET", "DOMORG.IT")
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, "DOMORG.IT.NET", response["data"].(map[string]interface{})["hierarchyName"])
+	recorder = createDomain("NET", "DOMORG.IT")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = createDomain("X", "DOMORG.NOPE")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Objects must reference an existing domain
+	requestBody := []byte(`{
+		"name": "DOMTENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DOMORG.NOPE",
+		"attributes": {
+			"color": "FFFFFF"
+		}
+	}`)
+	recorder = makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	requestBody = bytes.Replace(requestBody, []byte("DOMORG.NOPE"), []byte("DOMORG.IT"), 1)
+	recorder = makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	tenantId := response["data"].(map[string]interface{})["id"].(string)
+
+	// Children default to the domain of their parent
+	data, _ := ioutil.ReadFile("models/schemas/site_schema.json")
+	var site map[string]interface{}
+	json.Unmarshal(data, &site)
+	site = site["examples"].([]interface{})[0].(map[string]interface{})
+	site["parentId"] = tenantId
+	site["name"] = "DOMS1"
+	delete(site, "domain")
+	data, _ = json.Marshal(site)
+	recorder = makeRequest("POST", "/api/sites", data)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, "DOMORG.IT", response["data"].(map[string]interface{})["domain"])
+
+	recorder = makeRequest("PATCH", "/api/sites/DOMTENANT.DOMS1",
+		[]byte(`{"domain": "DOMORG.IT.NET"}`))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = makeRequest("PATCH", "/api/sites/DOMTENANT.DOMS1",
+		[]byte(`{"domain": "DOMORG.NOPE"}`))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Used domains can't be deleted
+	recorder = makeRequest("DELETE", "/api/domains/DOMORG.IT", nil)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	// Renames propagate to subdomains and objects
+	recorder = makeRequest("PATCH", "/api/domains/DOMORG", []byte(`{"name": "DOMORG2"}`))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = makeRequest("GET", "/api/domains/DOMORG2.IT.NET", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = makeRequest("GET", "/api/tenants/DOMTENANT", nil)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, "DOMORG2.IT", response["data"].(map[string]interface{})["domain"])
+	recorder = makeRequest("GET", "/api/sites/DOMTENANT.DOMS1", nil)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, "DOMORG2.IT.NET", response["data"].(map[string]interface{})["domain"])
+
+	// Objects created before domains were checked get theirs
+	ctx, cancel := u.Connect()
+	models.GetDB().Collection("tenant").InsertOne(ctx, map[string]interface{}{
+		"name": "DOMLEGACY", "hierarchyName": "DOMLEGACY", "category": "tenant",
+		"domain": "DOMOLD.SUB"})
+	cancel()
+	models.CreateMissingDomains()
+	recorder = makeRequest("GET", "/api/domains/DOMOLD.SUB", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = makeRequest("DELETE", "/api/tenants/DOMLEGACY", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	recorder = makeRequest("DELETE", "/api/tenants/DOMTENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	for _, name := range []string{"DOMORG2.IT.NET", "DOMORG2.IT", "DOMORG2", "DOMOLD.SUB", "DOMOLD"} {
+		recorder = makeRequest("DELETE", "/api/domains/"+name, nil)
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+	}
+}
+
+func TestRackOccupancy(t *testing.T) {
+	var response map[string]interface{}
+	requestBody := []byte(`{
+		"name": "OCCTENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF"
+		}
+	}`)
+	recorder := makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	tenantId := response["data"].(map[string]interface{})["id"].(string)
+
+	siteId := createFromExample(t, "site", tenantId, "S1")
+	bldgId := createFromExample(t, "building", siteId, "B1")
+	roomId := createFromExample(t, "room", bldgId, "R1")
+	rackId := createFromExample(t, "rack", roomId, "A01") // 47U
+
+	createDevice := func(name, posU, sizeU string) int {
+		data, _ := ioutil.ReadFile("models/schemas/device_schema.json")
+		var obj map[string]interface{}
+		json.Unmarshal(data, &obj)
+		obj = obj["examples"].([]interface{})[0].(map[string]interface{})
+		obj["parentId"] = rackId
+		obj["name"] = name
+		obj["attributes"].(map[string]interface{})["posU"] = posU
+		obj["attributes"].(map[string]interface{})["sizeU"] = sizeU
+		data, _ = json.Marshal(obj)
+		return makeRequest("POST", "/api/devices", data).Code
+	}
+
+	assert.Equal(t, http.StatusCreated, createDevice("D1", "10", "2"))
+	assert.Equal(t, http.StatusCreated, createDevice("D2", "12", "1"))
+	assert.Equal(t, http.StatusBadRequest, createDevice("D3", "11", "1"))
+	assert.Equal(t, http.StatusBadRequest, createDevice("D3", "46", "3"))
+	assert.Equal(t, http.StatusBadRequest, createDevice("D3", "0", "1"))
+
+	// Updates are checked too, but not against the device itself
+	recorder = makeRequest("PATCH", "/api/devices/OCCTENANT.S1.B1.R1.A01.D1",
+		[]byte(`{"attributes": {"posU": "9"}}`))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = makeRequest("PATCH", "/api/devices/OCCTENANT.S1.B1.R1.A01.D1",
+		[]byte(`{"attributes": {"sizeU": "4"}}`))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, true, strings.Contains(response["message"].(string), "OCCTENANT.S1.B1.R1.A01.D2"))
+
+	recorder = makeRequest("GET", "/api/racks/OCCTENANT.S1.B1.R1.A01/occupancy", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, 47.0, data["heightU"])
+	assert.Equal(t, 2, len(data["used"].([]interface{})))
+	free := data["free"].([]interface{})
+	assert.Equal(t, 3, len(free))
+	assert.Equal(t, map[string]interface{}{"posU": 11.0, "sizeU": 1.0}, free[1])
+
+	recorder = makeRequest("DELETE", "/api/tenants/OCCTENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestAutoPosU(t *testing.T) {
+	var response map[string]interface{}
+	requestBody := []byte(`{
+		"name": "AUTOTENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF"
+		}
+	}`)
+	recorder := makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	tenantId := response["data"].(map[string]interface{})["id"].(string)
+
+	siteId := createFromExample(t, "site", tenantId, "S1")
+	bldgId := createFromExample(t, "building", siteId, "B1")
+	roomId := createFromExample(t, "room", bldgId, "R1")
+	rackId := createFromExample(t, "rack", roomId, "A01") // 47U
+
+	createDevice := func(name, posU, sizeU string) (int, string) {
+		var response map[string]interface{}
+		data, _ := ioutil.ReadFile("models/schemas/device_schema.json")
+		var obj map[string]interface{}
+		json.Unmarshal(data, &obj)
+		obj = obj["examples"].([]interface{})[0].(map[string]interface{})
+		obj["parentId"] = rackId
+		obj["name"] = name
+		obj["attributes"].(map[string]interface{})["posU"] = posU
+		obj["attributes"].(map[string]interface{})["sizeU"] = sizeU
+		data, _ = json.Marshal(obj)
+		recorder := makeRequest("POST", "/api/devices", data)
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		if recorder.Code != http.StatusCreated {
+			return recorder.Code, ""
+		}
+		attrs := response["data"].(map[string]interface{})["attributes"].(map[string]interface{})
+		return recorder.Code, attrs["posU"].(string)
+	}
+
+	code, posU := createDevice("D1", "auto", "2")
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, "1", posU)
+	_, posU = createDevice("D2", "auto:top", "2")
+	assert.Equal(t, "46", posU)
+	_, posU = createDevice("D3", "auto:10-20", "2")
+	assert.Equal(t, "10", posU)
+	_, posU = createDevice("D4", "auto", "8")
+	assert.Equal(t, "12", posU)
+	code, _ = createDevice("D5", "auto", "30")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = createDevice("D5", "auto:middle", "1")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// Concurrent allocations get different ranges
+	positions := make(chan string, 5)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, posU := createDevice("C"+strconv.Itoa(i), "auto", "1")
+			positions <- posU
+		}(i)
+	}
+	wg.Wait()
+	close(positions)
+	allocated := map[string]bool{}
+	for posU := range positions {
+		assert.NotEqual(t, "", posU)
+		allocated[posU] = true
+	}
+	assert.Equal(t, 5, len(allocated))
+
+	// Concurrent updates can't move two devices to the same range
+	codes := make(chan int, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes <- makeRequest("PATCH", "/api/devices/AUTOTENANT.S1.B1.R1.A01.C"+strconv.Itoa(i),
+				[]byte(`{"attributes.posU": "40"}`)).Code
+		}(i)
+	}
+	wg.Wait()
+	close(codes)
+	moved := 0
+	for code := range codes {
+		if code == http.StatusOK {
+			moved++
+		} else {
+			assert.Equal(t, http.StatusBadRequest, code)
+		}
+	}
+	assert.Equal(t, 1, moved)
+
+	recorder = makeRequest("DELETE", "/api/tenants/AUTOTENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestDeviceSlots(t *testing.T) {
+	var response map[string]interface{}
+	requestBody := []byte(`{
+		"slug": "slots-test-chassis",
+		"description": "chassis",
+		"category": "device",
+		"sizeWDHmm": [440, 700, 400],
+		"fbxModel": "",
+		"attributes": {"type": "chassis"},
+		"colors": [],
+		"components": [],
+		"slots": [
+			{"location": "blade1", "type": "blade", "elemOrient": "vertical",
+			"elemPos": [0, 0, 0], "elemSize": [45, 250, 400], "labelPos": "front"},
+			{"location": "blade2", "type": "blade", "elemOrient": "vertical",
+			"elemPos": [50, 0, 0], "elemSize": [45, 250, 400], "labelPos": "front"},
+			{"location": "small", "type": "card", "elemOrient": "horizontal",
+			"elemPos": [100, 0, 0], "elemSize": [10, 10, 10], "labelPos": "front"}
+		]
+	}`)
+	recorder := makeRequest("POST", "/api/obj-templates", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	requestBody = []byte(`{
+		"name": "SLOTTENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF"
+		}
+	}`)
+	recorder = makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	tenantId := response["data"].(map[string]interface{})["id"].(string)
+
+	siteId := createFromExample(t, "site", tenantId, "S1")
+	bldgId := createFromExample(t, "building", siteId, "B1")
+	roomId := createFromExample(t, "room", bldgId, "R1")
+	rackId := createFromExample(t, "rack", roomId, "A01")
+
+	// Example device: 388.4mm x 205.9mm, 40.1mm high
+	createDevice := func(parentId, name string, attributes map[string]interface{}) int {
+		data, _ := ioutil.ReadFile("models/schemas/device_schema.json")
+		var obj map[string]interface{}
+		json.Unmarshal(data, &obj)
+		obj = obj["examples"].([]interface{})[0].(map[string]interface{})
+		obj["parentId"] = parentId
+		obj["name"] = name
+		delete(obj["attributes"].(map[string]interface{}), "slot")
+		for k, v := range attributes {
+			obj["attributes"].(map[string]interface{})[k] = v
+		}
+		data, _ = json.Marshal(obj)
+		return makeRequest("POST", "/api/devices", data).Code
+	}
+
+	assert.Equal(t, http.StatusCreated, createDevice(rackId, "chassis",
+		map[string]interface{}{"template": "slots-test-chassis"}))
+	chassis := "SLOTTENANT.S1.B1.R1.A01.chassis"
+	assert.Equal(t, http.StatusCreated, createDevice(chassis, "blade-a",
+		map[string]interface{}{"slot": "blade1"}))
+	assert.Equal(t, http.StatusBadRequest, createDevice(chassis, "blade-b",
+		map[string]interface{}{"slot": "blade1"}))
+	assert.Equal(t, http.StatusBadRequest, createDevice(chassis, "blade-b",
+		map[string]interface{}{"slot": "blade9"}))
+	assert.Equal(t, http.StatusBadRequest, createDevice(chassis, "blade-b", nil))
+	assert.Equal(t, http.StatusBadRequest, createDevice(chassis, "blade-b",
+		map[string]interface{}{"slot": "small"}))
+
+	recorder = makeRequest("GET", "/api/devices/"+chassis+"/slots", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	slots := response["data"].(map[string]interface{})["slots"].([]interface{})
+	assert.Equal(t, 3, len(slots))
+	assert.Equal(t, true, slots[0].(map[string]interface{})["used"])
+	assert.Equal(t, "blade-a",
+		slots[0].(map[string]interface{})["device"].(map[string]interface{})["name"])
+	assert.Equal(t, false, slots[1].(map[string]interface{})["used"])
+
+	// The device itself is not a clash
+	recorder = makeRequest("PATCH", "/api/devices/"+chassis+".blade-a",
+		[]byte(`{"attributes": {"slot": "blade2"}}`))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = makeRequest("DELETE", "/api/tenants/SLOTTENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	recorder = makeRequest("DELETE", "/api/obj-templates/slots-test-chassis", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestInstantiateTemplate(t *testing.T) {
+	var response map[string]interface{}
+	requestBody := []byte(`{
+		"slug": "instantiate-test-rack",
+		"description": "rack",
+		"category": "rack",
+		"sizeWDHmm": [600, 1200, 2000],
+		"fbxModel": "",
+		"attributes": {"vendor": "IBM"},
+		"colors": [{"name": "pdu", "value": "ff0000"}],
+		"components": [
+			{"location": "pdu", "type": "pdu", "elemOrient": "vertical",
+			"elemPos": [0, 0, 0], "elemSize": [50, 100, 1800], "labelPos": "front",
+			"color": "@pdu"}
+		],
+		"slots": [
+			{"location": "u01", "type": "u", "elemOrient": "horizontal",
+			"elemPos": [58, 0, 0], "elemSize": [482, 1138, 44], "labelPos": "front"}
+		]
+	}`)
+	recorder := makeRequest("POST", "/api/obj-templates", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	requestBody = []byte(`{
+		"slug": "instantiate-test-bad",
+		"description": "rack",
+		"category": "rack",
+		"sizeWDHmm": [600, 1200, 2000],
+		"fbxModel": "",
+		"attributes": {},
+		"colors": [],
+		"components": [
+			{"location": "not a name", "type": "pdu", "elemOrient": "vertical",
+			"elemPos": [0, 0, 0], "elemSize": [50, 100, 1800], "labelPos": "front"}
+		],
+		"slots": []
+	}`)
+	recorder = makeRequest("POST", "/api/obj-templates", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	requestBody = []byte(`{
+		"slug": "instantiate-test-outside",
+		"description": "rack",
+		"category": "rack",
+		"sizeWDHmm": [600, 1200, 2000],
+		"fbxModel": "",
+		"attributes": {},
+		"colors": [],
+		"components": [
+			{"location": "pdu", "type": "pdu", "elemOrient": "vertical",
+			"elemPos": [580, 0, 0], "elemSize": [50, 100, 1800], "labelPos": "front"}
+		],
+		"slots": []
+	}`)
+	recorder = makeRequest("POST", "/api/obj-templates", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	requestBody = []byte(`{
+		"slug": "instantiate-test-room",
+		"category": "room",
+		"axisOrientation": "+x+y",
+		"sizeWDHm": [9.6, 22.8, 3],
+		"floorUnit": "t",
+		"technicalArea": [5, 0, 0, 0],
+		"reservedArea": [3, 1, 1, 3],
+		"separators": [{"startPosXYm": [3, 0], "endPosXYm": [3, 12], "type": "wireframe"}],
+		"pillars": [],
+		"colors": [],
+		"tiles": [],
+		"rows": []
+	}`)
+	recorder = makeRequest("POST", "/api/room-templates", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	requestBody = []byte(`{
+		"name": "INSTTENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF"
+		}
+	}`)
+	recorder = makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	tenantId := response["data"].(map[string]interface{})["id"].(string)
+	siteId := createFromExample(t, "site", tenantId, "S1")
+	bldgId := createFromExample(t, "building", siteId, "B1")
+
+	// Room: size, height and areas come from the template
+	requestBody = []byte(`{
+		"name": "R1", "category": "room", "description": [], "domain": "DEMO",
+		"parentId": "` + bldgId + `",
+		"attributes": {"template": "instantiate-test-room", "posXY": "{\"x\":0,\"y\":0}",
+			"posXYUnit": "m", "rotation": "0"}
+	}`)
+	recorder = makeRequest("POST", "/api/rooms?instantiate=true", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	room := response["data"].(map[string]interface{})
+	attrs := room["attributes"].(map[string]interface{})
+	assert.Equal(t, `{"x":9.6,"y":22.8}`, attrs["size"])
+	assert.Equal(t, "3", attrs["height"])
+	assert.Equal(t, "[3,1,1,3]", attrs["reservedArea"])
+	assert.Equal(t, "[5,0,0,0]", attrs["technicalArea"])
+	roomId := room["id"].(string)
+
+	// Rack: its components and slots are created as devices
+	requestBody = []byte(`{
+		"name": "A01", "category": "rack", "description": [], "domain": "DEMO",
+		"parentId": "` + roomId + `",
+		"attributes": {"template": "instantiate-test-rack", "orientation": "front",
+			"posXYUnit": "m", "posXYZ": "{\"x\":1,\"y\":1,\"z\":0}"}
+	}`)
+	recorder = makeRequest("POST", "/api/racks?instantiate=true", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	attrs = response["data"].(map[string]interface{})["attributes"].(map[string]interface{})
+	assert.Equal(t, "2000", attrs["height"])
+	assert.Equal(t, "mm", attrs["heightUnit"])
+	assert.Equal(t, "IBM", attrs["vendor"])
+	assert.Equal(t, 2, len(response["data"].(map[string]interface{})["children"].([]interface{})))
+
+	recorder = makeRequest("GET", "/api/objects/INSTTENANT.S1.B1.R1.A01.pdu", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	attrs = response["data"].(map[string]interface{})["attributes"].(map[string]interface{})
+	assert.Equal(t, "ff0000", attrs["color"])
+	assert.Equal(t, "component", attrs["templateElement"])
+	recorder = makeRequest("GET", "/api/objects/INSTTENANT.S1.B1.R1.A01.u01", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// Nothing is created when a component is not valid
+	requestBody = []byte(`{
+		"name": "A02", "category": "rack", "description": [], "domain": "DEMO",
+		"parentId": "` + roomId + `",
+		"attributes": {"template": "instantiate-test-bad", "orientation": "front",
+			"posXYUnit": "m", "posXYZ": "{\"x\":3,\"y\":1,\"z\":0}"}
+	}`)
+	recorder = makeRequest("POST", "/api/racks?instantiate=true", requestBody)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = makeRequest("GET", "/api/objects/INSTTENANT.S1.B1.R1.A02", nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// Nor when a component is outside the rack
+	requestBody = []byte(`{
+		"name": "A03", "category": "rack", "description": [], "domain": "DEMO",
+		"parentId": "` + roomId + `",
+		"attributes": {"template": "instantiate-test-outside", "orientation": "front",
+			"posXYUnit": "m", "posXYZ": "{\"x\":5,\"y\":1,\"z\":0}"}
+	}`)
+	recorder = makeRequest("POST", "/api/racks?instantiate=true", requestBody)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = makeRequest("GET", "/api/objects/INSTTENANT.S1.B1.R1.A03", nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = makeRequest("DELETE", "/api/tenants/INSTTENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	for _, slug := range []string{"instantiate-test-rack", "instantiate-test-bad",
+		"instantiate-test-outside"} {
+		recorder = makeRequest("DELETE", "/api/obj-templates/"+slug, nil)
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+	}
+	recorder = makeRequest("DELETE", "/api/room-templates/instantiate-test-room", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestTemplateUsages(t *testing.T) {
+	var response map[string]interface{}
+	requestBody := []byte(`{
+		"slug": "usages-test-rack",
+		"description": "rack",
+		"category": "rack",
+		"sizeWDHmm": [600, 1200, 2000],
+		"fbxModel": "",
+		"attributes": {},
+		"colors": [],
+		"components": [],
+		"slots": []
+	}`)
+	recorder := makeRequest("POST", "/api/obj-templates", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	requestBody = []byte(`{
+		"name": "USAGETENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF"
+		}
+	}`)
+	recorder = makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	tenantId := response["data"].(map[string]interface{})["id"].(string)
+	siteId := createFromExample(t, "site", tenantId, "S1")
+	bldgId := createFromExample(t, "building", siteId, "B1")
+	roomId := createFromExample(t, "room", bldgId, "R1")
+
+	createRack := func(name, template string, n int) int {
+		data, _ := ioutil.ReadFile("models/schemas/rack_schema.json")
+		var obj map[string]interface{}
+		json.Unmarshal(data, &obj)
+		obj = obj["examples"].([]interface{})[0].(map[string]interface{})
+		obj["parentId"] = roomId
+		obj["name"] = name
+		obj["attributes"].(map[string]interface{})["template"] = template
+		obj["attributes"].(map[string]interface{})["posXYZ"] = rackPosition(n)
+		data, _ = json.Marshal(obj)
+		return makeRequest("POST", "/api/racks", data).Code
+	}
+	assert.Equal(t, http.StatusBadRequest, createRack("A01", "usages-test-missing", 0))
+	assert.Equal(t, http.StatusCreated, createRack("A01", "usages-test-rack", 0))
+	assert.Equal(t, http.StatusCreated, createRack("A02", "", 1))
+
+	recorder = makeRequest("PATCH", "/api/racks/USAGETENANT.S1.B1.R1.A02",
+		[]byte(`{"attributes": {"template": "usages-test-missing"}}`))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = makeRequest("GET", "/api/obj-templates/usages-test-rack/usages", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	usages := response["data"].(map[string]interface{})["usages"].([]interface{})
+	assert.Equal(t, 1, len(usages))
+	assert.Equal(t, "USAGETENANT.S1.B1.R1.A01", usages[0].(map[string]interface{})["hierarchyName"])
+
+	// A template in use is only deleted with force
+	recorder = makeRequest("DELETE", "/api/obj-templates/usages-test-rack", nil)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	recorder = makeRequest("DELETE", "/api/obj-templates/usages-test-rack?force=true", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	recorder = makeRequest("GET", "/api/objects/USAGETENANT.S1.B1.R1.A01", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, "",
+		response["data"].(map[string]interface{})["attributes"].(map[string]interface{})["template"])
+
+	recorder = makeRequest("DELETE", "/api/tenants/USAGETENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestTemplateVersions(t *testing.T) {
+	var response map[string]interface{}
+	template := func(slots string, height int) []byte {
+		return []byte(`{
+			"slug": "versions-test-chassis",
+			"description": "chassis",
+			"category": "device",
+			"sizeWDHmm": [440, 700, ` + strconv.Itoa(height) + `],
+			"fbxModel": "",
+			"attributes": {"type": "chassis"},
+			"colors": [],
+			"components": [],
+			"slots": [` + slots + `]
+		}`)
+	}
+	s1 := `{"location": "s1", "type": "blade", "elemOrient": "horizontal",
+		"elemPos": [0, 0, 0], "elemSize": [400, 250, 50], "labelPos": "front"}`
+	s2 := `{"location": "s2", "type": "blade", "elemOrient": "horizontal",
+		"elemPos": [0, 0, 50], "elemSize": [400, 250, 50], "labelPos": "front"}`
+	recorder := makeRequest("POST", "/api/obj-templates", template(s1+","+s2, 100))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	requestBody := []byte(`{
+		"name": "VERSIONTENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF"
+		}
+	}`)
+	recorder = makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	tenantId := response["data"].(map[string]interface{})["id"].(string)
+	siteId := createFromExample(t, "site", tenantId, "S1")
+	bldgId := createFromExample(t, "building", siteId, "B1")
+	roomId := createFromExample(t, "room", bldgId, "R1")
+	rackId := createFromExample(t, "rack", roomId, "A01")
+
+	requestBody = []byte(`{
+		"name": "chassis", "category": "device", "description": [], "domain": "DEMO",
+		"parentId": "` + rackId + `",
+		"attributes": {"template": "versions-test-chassis@1", "orientation": "front"}
+	}`)
+	recorder = makeRequest("POST", "/api/devices?instantiate=true", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	attrs := response["data"].(map[string]interface{})["attributes"].(map[string]interface{})
+	assert.Equal(t, "versions-test-chassis", attrs["template"])
+	assert.Equal(t, "1", attrs["templateVersion"])
+
+	chassis := "VERSIONTENANT.S1.B1.R1.A01.chassis"
+	data, _ := ioutil.ReadFile("models/schemas/device_schema.json")
+	var blade map[string]interface{}
+	json.Unmarshal(data, &blade)
+	blade = blade["examples"].([]interface{})[0].(map[string]interface{})
+	blade["parentId"] = chassis
+	blade["name"] = "blade"
+	blade["attributes"].(map[string]interface{})["slot"] = "s2"
+	data, _ = json.Marshal(blade)
+	recorder = makeRequest("POST", "/api/devices", data)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	// Version 2: s2 removed, higher chassis
+	recorder = makeRequest("PUT", "/api/obj-templates/versions-test-chassis", template(s1, 150))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = makeRequest("GET", "/api/obj-templates/versions-test-chassis@1", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, 2, len(response["data"].(map[string]interface{})["slots"].([]interface{})))
+	recorder = makeRequest("GET", "/api/obj-templates/versions-test-chassis/versions", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, 2, len(response["data"].(map[string]interface{})["versions"].([]interface{})))
+
+	// The occupied slot s2 would disappear
+	recorder = makeRequest("POST", "/api/obj-templates/versions-test-chassis/upgrade?dryRun=true",
+		[]byte(`{"root": "VERSIONTENANT"}`))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, float64(1), response["data"].(map[string]interface{})["conflicts"])
+
+	recorder = makeRequest("DELETE", "/api/devices/"+chassis+".blade", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	recorder = makeRequest("POST", "/api/obj-templates/versions-test-chassis/upgrade",
+		[]byte(`{"root": "VERSIONTENANT", "to": 2}`))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, float64(1), response["data"].(map[string]interface{})["upgraded"])
+
+	recorder = makeRequest("GET", "/api/objects/"+chassis, nil)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	attrs = response["data"].(map[string]interface{})["attributes"].(map[string]interface{})
+	assert.Equal(t, "2", attrs["templateVersion"])
+	assert.Equal(t, "150", attrs["height"])
+	recorder = makeRequest("GET", "/api/objects/"+chassis+".s2", nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// Concurrent updates: each version is what its writer sent
+	var mutex sync.Mutex
+	heights := map[string]float64{}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(height int) {
+			defer wg.Done()
+			var response map[string]interface{}
+			recorder := makeRequest("PUT", "/api/obj-templates/versions-test-chassis", template(s1, height))
+			if recorder.Code != http.StatusOK {
+				assert.Equal(t, http.StatusConflict, recorder.Code)
+				return
+			}
+			json.Unmarshal(recorder.Body.Bytes(), &response)
+			version := strconv.FormatFloat(response["data"].(map[string]interface{})["version"].(float64), 'f', -1, 64)
+			mutex.Lock()
+			_, twice := heights[version]
+			assert.Equal(t, false, twice)
+			heights[version] = float64(height)
+			mutex.Unlock()
+		}(200 + i)
+	}
+	wg.Wait()
+	for version, height := range heights {
+		recorder = makeRequest("GET", "/api/obj-templates/versions-test-chassis@"+version, nil)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, height, response["data"].(map[string]interface{})["sizeWDHmm"].([]interface{})[2])
+	}
+
+	recorder = makeRequest("DELETE", "/api/tenants/VERSIONTENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	recorder = makeRequest("DELETE", "/api/obj-templates/versions-test-chassis", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestTemplateBundle(t *testing.T) {
+	var response map[string]interface{}
+	entries, _ := os.ReadDir("models/schemas/test_data/OK")
+	files := []u.ArchiveFile{{Name: "README.md", Data: []byte("Test templates")}}
+	slugs := map[string]string{}
+	for _, entry := range entries {
+		data, _ := ioutil.ReadFile("models/schemas/test_data/OK/" + entry.Name())
+		files = append(files, u.ArchiveFile{Name: "bundle/" + entry.Name(), Data: data})
+		var template map[string]interface{}
+		json.Unmarshal(data, &template)
+		entity := strings.Replace(strings.TrimRight(strings.TrimSuffix(entry.Name(), ".json"), "0123456789"), "_", "-", 1)
+		slugs[template["slug"].(string)] = entity
+		makeRequest("DELETE", "/api/"+entity+"s/"+template["slug"].(string), nil)
+	}
+	bundle := bytes.Buffer{}
+	u.WriteTar(&bundle, files)
+
+	recorder := makeRequest("POST", "/api/templates/bundle?dryRun=true", bundle.Bytes())
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	results := response["data"].(map[string]interface{})["files"].([]interface{})
+	assert.Equal(t, len(files), len(results))
+	assert.Equal(t, "ignored", results[0].(map[string]interface{})["status"])
+	assert.Equal(t, "created", results[1].(map[string]interface{})["status"])
+	recorder = makeRequest("GET", "/api/obj-templates/ibm-ns1200", nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = makeRequest("POST", "/api/templates/bundle", bundle.Bytes())
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = makeRequest("GET", "/api/obj-templates/ibm-ns1200", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// Importing the same bundle again changes nothing
+	recorder = makeRequest("POST", "/api/templates/bundle", bundle.Bytes())
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	results = response["data"].(map[string]interface{})["files"].([]interface{})
+	assert.Equal(t, "unchanged", results[1].(map[string]interface{})["status"])
+
+	// Nothing is imported from a bundle with an invalid template
+	invalid, _ := ioutil.ReadFile("models/schemas/test_data/KO/obj_template4.json")
+	valid := []byte(`{"slug": "bundle-test-valid", "description": "valid", "category": "device",
+		"sizeWDHmm": [1, 1, 1], "fbxModel": "", "attributes": {}, "colors": [],
+		"components": [], "slots": []}`)
+	bundle = bytes.Buffer{}
+	u.WriteZip(&bundle, []u.ArchiveFile{{Name: "obj_template/valid.json", Data: valid},
+		{Name: "obj_template/invalid.json", Data: invalid}})
+	recorder = makeRequest("POST", "/api/templates/bundle", bundle.Bytes())
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = makeRequest("GET", "/api/obj-templates/bundle-test-valid", nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = makeRequest("GET", "/api/templates/bundle?templates=room-templates&format=zip", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	exported, e := u.ReadArchive(recorder.Body.Bytes())
+	assert.Equal(t, nil, e)
+	found := false
+	for _, file := range exported {
+		assert.Equal(t, true, strings.HasPrefix(file.Name, "room_template/"))
+		found = found || file.Name == "room_template/bnpp-md-1ss-srv.json"
+	}
+	assert.Equal(t, true, found)
+
+	for slug, entity := range slugs {
+		recorder = makeRequest("DELETE", "/api/"+entity+"s/"+slug, nil)
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+	}
+}
+
+func TestAssets(t *testing.T) {
+	var response map[string]interface{}
+	content := []byte("Kaydara FBX Binary  \x00 asset test " + time.Now().String())
+	recorder := makeRequestWithHeaders("POST", "/api/assets?filename=rack.fbx", content,
+		map[string]string{"Content-Type": "application/octet-stream"})
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	asset := response["data"].(map[string]interface{})
+	id, ref := asset["id"].(string), asset["ref"].(string)
+	assert.Equal(t, "asset:"+id, ref)
+
+	// The same content is stored once
+	recorder = makeRequest("POST", "/api/assets", content)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, id, response["data"].(map[string]interface{})["id"])
+
+	recorder = makeRequestWithHeaders("GET", "/api/assets/"+id, nil,
+		map[string]string{"Range": "bytes=0-6"})
+	assert.Equal(t, http.StatusPartialContent, recorder.Code)
+	assert.Equal(t, "Kaydara", recorder.Body.String())
+	recorder = makeRequestWithHeaders("GET", "/api/assets/"+id, nil,
+		map[string]string{"Range": "bytes=8-13"})
+	assert.Equal(t, http.StatusPartialContent, recorder.Code)
+	assert.Equal(t, "FBX Bi", recorder.Body.String())
+	recorder = makeRequest("GET", "/api/assets/"+id, nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, content, recorder.Body.Bytes())
+
+	requestBody := []byte(`{
+		"name": "ASSETTENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF",
+			"photo": "asset:000000000000000000000000"
+		}
+	}`)
+	recorder = makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = makeRequest("POST", "/api/tenants",
+		bytes.Replace(requestBody, []byte("asset:000000000000000000000000"), []byte(ref), 1))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	// Assets in use are kept
+	recorder = makeRequest("GET", "/api/assets/"+id+"/usages", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, 1.0, response["data"].(map[string]interface{})["count"])
+	recorder = makeRequest("DELETE", "/api/assets/"+id, nil)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	// Deleting the object orphans the asset
+	recorder = makeRequest("DELETE", "/api/tenants/ASSETTENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	recorder = makeRequest("GET", "/api/assets?orphaned=true", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, true, strings.Contains(recorder.Body.String(), id))
+
+	recorder = makeRequest("DELETE", "/api/assets/"+id, nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	recorder = makeRequest("GET", "/api/assets/"+id, nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// A template version keeps the asset it was saved with
+	content = []byte("Kaydara FBX Binary  \x00 template test " + time.Now().String())
+	recorder = makeRequest("POST", "/api/assets?filename=chassis.fbx", content)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	id, ref = response["data"].(map[string]interface{})["id"].(string),
+		response["data"].(map[string]interface{})["ref"].(string)
+	template := func(fbxModel string) []byte {
+		return []byte(`{
+			"slug": "assets-test-chassis",
+			"description": "chassis",
+			"category": "device",
+			"sizeWDHmm": [440, 700, 100],
+			"fbxModel": "` + fbxModel + `",
+			"attributes": {"type": "chassis"},
+			"colors": [],
+			"components": [],
+			"slots": []
+		}`)
+	}
+	recorder = makeRequest("POST", "/api/obj-templates", template(ref))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	recorder = makeRequest("PUT", "/api/obj-templates/assets-test-chassis", template(""))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = makeRequest("GET", "/api/assets/"+id+"/usages", nil)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, 1.0, response["data"].(map[string]interface{})["count"])
+	recorder = makeRequest("DELETE", "/api/assets/"+id, nil)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	// Until the template and its versions are deleted
+	recorder = makeRequest("DELETE", "/api/obj-templates/assets-test-chassis", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	recorder = makeRequest("DELETE", "/api/assets/"+id, nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestAttachmentsAndNotes(t *testing.T) {
+	var response map[string]interface{}
+	requestBody := []byte(`{
+		"name": "NOTETENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF"
+		}
+	}`)
+	recorder := makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	tenantId := response["data"].(map[string]interface{})["id"].(string)
+
+	siteId := createFromExample(t, "site", tenantId, "S1")
+	bldgId := createFromExample(t, "building", siteId, "B1")
+	room1Id := createFromExample(t, "room", bldgId, "R1")
+	createFromExample(t, "room", bldgId, "R2")
+	createFromExample(t, "rack", room1Id, "A01")
+
+	rack := "/api/objects/NOTETENANT.S1.B1.R1.A01"
+	content := []byte("%PDF-1.4 cabling plan " + time.Now().String())
+	recorder = makeRequest("POST", rack+"/attachments?filename=cabling.pdf&description=plan", content)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	attachmentId := response["data"].(map[string]interface{})["id"].(string)
+	assert.Equal(t, "plan", response["data"].(map[string]interface{})["description"])
+
+	recorder = makeRequest("POST", rack+"/notes", []byte(`{"text": "first"}`))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	recorder = makeRequest("POST", rack+"/notes", []byte(`{"text": "second"}`))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	recorder = makeRequest("POST", rack+"/notes", []byte(`{"text": ""}`))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Newest first
+	recorder = makeRequest("GET", rack+"/notes", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	notes := response["data"].(map[string]interface{})["objects"].([]interface{})
+	assert.Equal(t, 2, len(notes))
+	assert.Equal(t, "second", notes[0].(map[string]interface{})["text"])
+
+	// They follow the rack when it is moved
+	recorder = makeRequest("POST", rack+"/move", []byte(`{"parent": "NOTETENANT.S1.B1.R2"}`))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	rack = "/api/objects/NOTETENANT.S1.B1.R2.A01"
+	recorder = makeRequest("GET", rack+"/attachments/"+attachmentId, nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, content, recorder.Body.Bytes())
+
+	// The copy gets its own notes
+	clone, _ := json.Marshal(map[string]interface{}{"parent": "NOTETENANT.S1.B1.R2",
+		"name": "A02", "attributes": map[string]interface{}{"posXYZ": rackPosition(1)}})
+	recorder = makeRequest("POST", rack+"/clone", clone)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	recorder = makeRequest("GET", "/api/objects/NOTETENANT.S1.B1.R2.A02/notes", nil)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	notes = response["data"].(map[string]interface{})["objects"].([]interface{})
+	assert.Equal(t, 2, len(notes))
+	noteId := notes[1].(map[string]interface{})["id"].(string)
+	recorder = makeRequest("DELETE", "/api/objects/NOTETENANT.S1.B1.R2.A02/notes/"+noteId, nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	recorder = makeRequest("GET", rack+"/notes", nil)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, 2, len(response["data"].(map[string]interface{})["objects"].([]interface{})))
+
+	recorder = makeRequest("DELETE", "/api/tenants/NOTETENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	recorder = makeRequest("GET", "/api/assets?orphaned=true", nil)
+	assert.Equal(t, true, strings.Contains(recorder.Body.String(), "cabling"))
+}
+
+func TestRoomCollisions(t *testing.T) {
+	var response map[string]interface{}
+	requestBody := []byte(`{
+		"name": "COLLTENANT",
+		"category": "tenant",
+		"description": [],
+		"domain": "DEMO",
+		"attributes": {
+			"color": "FFFFFF"
+		}
+	}`)
+	recorder := makeRequest("POST", "/api/tenants", requestBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	tenantId := response["data"].(map[string]interface{})["id"].(string)
+
+	siteId := createFromExample(t, "site", tenantId, "S1")
+	bldgId := createFromExample(t, "building", siteId, "B1")
+	roomId := createFromExample(t, "room", bldgId, "R1") // +x+y: x -13 to 0m, y -2.9 to 0m
+	// The example rack fits in the example room: x -4.67 to -3.87m, y -2 to -1m
+	createFromExample(t, "rack", roomId, "A01")
+
+	createRack := func(parentId, posXYUnit, posXYZ string) int {
+		data, _ := ioutil.ReadFile("models/schemas/rack_schema.json")
+		var obj map[string]interface{}
+		json.Unmarshal(data, &obj)
+		obj = obj["examples"].([]interface{})[0].(map[string]interface{})
+		obj["parentId"] = parentId
+		obj["name"] = "A02"
+		obj["attributes"].(map[string]interface{})["posXYUnit"] = posXYUnit
+		obj["attributes"].(map[string]interface{})["posXYZ"] = posXYZ
+		data, _ = json.Marshal(obj)
+		return makeRequest("POST", "/api/racks", data).Code
+	}
+	assert.Equal(t, http.StatusBadRequest, createRack(roomId, "m", `{"x":-4.2,"y":-2,"z":0}`))
+	assert.Equal(t, http.StatusBadRequest, createRack(roomId, "m", `{"x":-0.5,"y":-2,"z":0}`))
+	assert.Equal(t, http.StatusBadRequest, createRack(roomId, "m", `{"x":-2,"y":-0.5,"z":0}`))
+	// 4 and 3 tiles: x -2.4 to -1.6m, y -1.8 to -0.8m
+	assert.Equal(t, http.StatusCreated, createRack(roomId, "t", `{"x":-4,"y":-3,"z":0}`))
+
+	// 7 tiles: x -4.2 to -3.4m
+	recorder = makeRequest("PATCH", "/api/racks/COLLTENANT.S1.B1.R1.A02",
+		[]byte(`{"attributes": {"posXYZ": "{\"x\":-7,\"y\":-3,\"z\":0}"}}`))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	// Turned to the left, the rack is 1m wide: x -3 to -2m
+	recorder = makeRequest("PATCH", "/api/racks/COLLTENANT.S1.B1.R1.A01",
+		[]byte(`{"attributes": {"orientation": "left", "posXYZ": "{\"x\":-3,\"y\":-2.5,\"z\":0}"}}`))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = makeRequest("GET", "/api/rooms/COLLTENANT.S1.B1.R1/collisions", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	collisions := response["data"].(map[string]interface{})
+	assert.Equal(t, 2.0, collisions["objects"])
+	assert.Equal(t, 0, len(collisions["outside"].([]interface{})))
+	assert.Equal(t, 0, len(collisions["overlaps"].([]interface{})))
+
+	// Objects extend along the axes of their room
+	roomId2 := createFromExampleWith(t, "room", bldgId, "R2", // x 0 to 13m, y 0 to 2.9m
+		map[string]interface{}{"axisOrientation": "-x-y"})
+	assert.Equal(t, http.StatusBadRequest, createRack(roomId2, "m", `{"x":0.5,"y":2,"z":0}`))
+	assert.Equal(t, http.StatusCreated, createRack(roomId2, "m", `{"x":4,"y":2,"z":0}`))
+
+	// Existing data: the room gets smaller
+	recorder = makeRequest("PATCH", "/api/rooms/COLLTENANT.S1.B1.R1",
+		[]byte(`{"attributes": {"size": "{\"x\":-3.5,\"y\":-2.9}"}}`))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = makeRequest("GET", "/api/rooms/"+roomId+"/collisions", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	outside := response["data"].(map[string]interface{})["outside"].([]interface{})
+	assert.Equal(t, 1, len(outside))
+	assert.Equal(t, "COLLTENANT.S1.B1.R1.A01", outside[0].(map[string]interface{})["hierarchyName"])
+
+	// Only the changes of a footprint are checked
+	recorder = makeRequest("PATCH", "/api/racks/COLLTENANT.S1.B1.R1.A01",
+		[]byte(`{"attributes": {"height": "42"}}`))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = makeRequest("PATCH", "/api/racks/COLLTENANT.S1.B1.R1.A01",
+		[]byte(`{"attributes": {"posXYZ": "{\"x\":-5,\"y\":-2,\"z\":0}"}}`))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Unless checks are off
+	os.Setenv("room_collisions", "off")
+	defer os.Unsetenv("room_collisions")
+	recorder = makeRequest("PATCH", "/api/racks/COLLTENANT.S1.B1.R1.A01",
+		[]byte(`{"attributes": {"posXYZ": "{\"x\":-5,\"y\":-2,\"z\":0}"}}`))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = makeRequest("DELETE", "/api/tenants/COLLTENANT", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	u "p3/utils"
 	"regexp"
+	"strings"
 
 	"github.com/dgrijalva/jwt-go"
 	"go.mongodb.org/mongo-driver/bson"
@@ -17,6 +18,18 @@ type Token struct {
 	jwt.StandardClaims
 }
 
+// IsAdmin: admins can change what applies to all the objects
+// (ex: schema extensions). They are listed, separated by commas,
+// in the admins setting of the .env file
+func IsAdmin(email string) bool {
+	for _, admin := range strings.Split(os.Getenv("admins"), ",") {
+		if admin = strings.TrimSpace(admin); admin != "" && admin == email {
+			return true
+		}
+	}
+	return false
+}
+
 // a struct for rep user account
 type Account struct {
 	Email    string `json:"email"`
@@ -0,0 +1,194 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// assetStore: where the content of the assets is kept, each
+// content is stored once under its SHA-256 hash
+type assetStore interface {
+	put(hash string, data []byte) error
+	open(hash string) (io.ReadSeekCloser, error)
+	remove(hash string) error
+}
+
+// getAssetStore: the assets are stored in the assets_dir folder if
+// it is set in the .env file, otherwise in mongo with GridFS
+func getAssetStore() assetStore {
+	if dir := strings.TrimSpace(os.Getenv("assets_dir")); dir != "" {
+		return fsAssetStore{dir: dir}
+	}
+	return gridfsAssetStore{}
+}
+
+// gridfsAssetStore: assets in the "assets" GridFS bucket,
+// the id of each file is the hash of its content
+type gridfsAssetStore struct{}
+
+func (gridfsAssetStore) bucket() (*gridfs.Bucket, error) {
+	bucket, e := gridfs.NewBucket(GetDB(), options.GridFSBucket().SetName("assets"))
+	if e != nil {
+		return nil, e
+	}
+	bucket.SetReadDeadline(time.Now().Add(5 * time.Minute))
+	bucket.SetWriteDeadline(time.Now().Add(5 * time.Minute))
+	return bucket, nil
+}
+
+func (s gridfsAssetStore) put(hash string, data []byte) error {
+	bucket, e := s.bucket()
+	if e != nil {
+		return e
+	}
+	// A previous upload may have been interrupted
+	if e := bucket.Delete(hash); e != nil && e != gridfs.ErrFileNotFound {
+		return e
+	}
+	return bucket.UploadFromStreamWithID(hash, hash, bytes.NewReader(data))
+}
+
+// gridfsAsset: a GridFS file that can seek, so that the content
+// is streamed instead of read in memory. A stream is opened at the
+// current offset on the first read after a seek
+type gridfsAsset struct {
+	bucket *gridfs.Bucket
+	hash   string
+	size   int64
+	offset int64
+	stream *gridfs.DownloadStream
+}
+
+func (s gridfsAssetStore) open(hash string) (io.ReadSeekCloser, error) {
+	bucket, e := s.bucket()
+	if e != nil {
+		return nil, e
+	}
+	stream, e := bucket.OpenDownloadStream(hash)
+	if e != nil {
+		return nil, e
+	}
+	return &gridfsAsset{bucket: bucket, hash: hash,
+		size: stream.GetFile().Length, stream: stream}, nil
+}
+
+func (a *gridfsAsset) Read(p []byte) (int, error) {
+	if a.offset >= a.size {
+		return 0, io.EOF
+	}
+	if a.stream == nil {
+		stream, e := a.bucket.OpenDownloadStream(a.hash)
+		if e != nil {
+			return 0, e
+		}
+		// The chunks before offset are not kept
+		if _, e := stream.Skip(a.offset); e != nil {
+			stream.Close()
+			return 0, e
+		}
+		a.stream = stream
+	}
+	n, e := a.stream.Read(p)
+	a.offset += int64(n)
+	return n, e
+}
+
+func (a *gridfsAsset) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += a.offset
+	case io.SeekEnd:
+		offset += a.size
+	}
+	if offset < 0 {
+		return 0, errors.New("seek before the start of the asset")
+	}
+	if offset != a.offset {
+		a.Close()
+		a.offset = offset
+	}
+	return offset, nil
+}
+
+func (a *gridfsAsset) Close() error {
+	if a.stream == nil {
+		return nil
+	}
+	e := a.stream.Close()
+	a.stream = nil
+	return e
+}
+
+func (s gridfsAssetStore) remove(hash string) error {
+	bucket, e := s.bucket()
+	if e != nil {
+		return e
+	}
+	if e := bucket.Delete(hash); e != nil && e != gridfs.ErrFileNotFound {
+		return e
+	}
+	return nil
+}
+
+// fsAssetStore: assets in a folder, as <dir>/<hash[:2]>/<hash>
+type fsAssetStore struct {
+	dir string
+}
+
+func (s fsAssetStore) path(hash string) (string, error) {
+	if len(hash) < 3 || strings.ContainsAny(hash, `/\.`) {
+		return "", errors.New("invalid asset hash")
+	}
+	return filepath.Join(s.dir, hash[:2], hash), nil
+}
+
+func (s fsAssetStore) put(hash string, data []byte) error {
+	path, e := s.path(hash)
+	if e != nil {
+		return e
+	}
+	if e := os.MkdirAll(filepath.Dir(path), 0755); e != nil {
+		return e
+	}
+	// Written aside then renamed so that a file is never half written
+	tmp, e := os.CreateTemp(filepath.Dir(path), "."+hash+"-*")
+	if e != nil {
+		return e
+	}
+	defer os.Remove(tmp.Name())
+	if _, e := tmp.Write(data); e != nil {
+		tmp.Close()
+		return e
+	}
+	if e := tmp.Close(); e != nil {
+		return e
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s fsAssetStore) open(hash string) (io.ReadSeekCloser, error) {
+	path, e := s.path(hash)
+	if e != nil {
+		return nil, e
+	}
+	return os.Open(path)
+}
+
+func (s fsAssetStore) remove(hash string) error {
+	path, e := s.path(hash)
+	if e != nil {
+		return e
+	}
+	if e := os.Remove(path); e != nil && !os.IsNotExist(e) {
+		return e
+	}
+	return nil
+}
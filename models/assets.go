@@ -0,0 +1,389 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	u "p3/utils"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Metadata of the stored assets (FBX models, photos...), one
+// document per distinct content, found by the hash of its content
+const assetCollection = "asset"
+
+// AssetRefPrefix: templates and objects reference an asset
+// with "asset:<id>" in one of the assetFields
+const AssetRefPrefix = "asset:"
+
+// assetFields: fields of the templates and attributes of
+// the objects that can reference an asset
+var assetFields = []string{"fbxModel", "photo"}
+
+// assetUsers: entities whose objects can reference an asset
+var assetUsers = []int{u.TENANT, u.SITE, u.BLDG, u.ROOM, u.RACK, u.DEVICE,
+	u.AC, u.CABINET, u.CORRIDOR, u.PWRPNL, u.SENSOR, u.GROUP,
+	u.ROOMTMPL, u.OBJTMPL, u.BLDGTMPL, u.STRAYDEV, u.STRAYSENSOR}
+
+var assetIndexOnce sync.Once
+
+func ensureAssetIndex() {
+	assetIndexOnce.Do(func() {
+		ctx, cancel := u.Connect()
+		defer cancel()
+		_, e := GetDB().Collection(assetCollection).Indexes().CreateOne(ctx,
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "hash", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			})
+		if e != nil {
+			println("Unable to create asset index:", e.Error())
+		}
+	})
+}
+
+// EnsureAssetIndexes: creates the indexes on the asset fields
+// that getAssetUsages looks up each time an asset may be released
+func EnsureAssetIndexes() {
+	indexes := map[string][]string{attachmentCollection: {"asset"}}
+	for _, user := range assetUsers {
+		prefix := "attributes."
+		if isTemplate(user) {
+			prefix = ""
+		}
+		for _, field := range assetFields {
+			indexes[u.EntityToString(user)] = append(indexes[u.EntityToString(user)], prefix+field)
+		}
+	}
+	for _, field := range assetFields {
+		indexes[templateVersionCollection] = append(indexes[templateVersionCollection],
+			"template."+field)
+	}
+	for collection, fields := range indexes {
+		keys := []mongo.IndexModel{}
+		for _, field := range fields {
+			keys = append(keys, mongo.IndexModel{Keys: bson.D{{Key: field, Value: 1}},
+				Options: options.Index().SetSparse(true)})
+		}
+		ctx, cancel := u.Connect()
+		_, e := GetDB().Collection(collection).Indexes().CreateMany(ctx, keys)
+		cancel()
+		if e != nil {
+			println("Unable to create asset indexes of "+collection+":", e.Error())
+		}
+	}
+}
+
+// GetAssetMaxSize: maximum size in bytes of an asset, configurable
+// with assets_max_size in the .env file (100MB by default)
+func GetAssetMaxSize() int64 {
+	if size, e := strconv.ParseInt(strings.TrimSpace(os.Getenv("assets_max_size")), 10, 64); e == nil && size > 0 {
+		return size
+	}
+	return 100 << 20
+}
+
+// assetFieldsFilter: matches the documents of an entity
+// referencing ref (any asset if ref is nil)
+func assetFieldsFilter(entity int, ref interface{}) bson.M {
+	prefix := "attributes."
+	if isTemplate(entity) {
+		prefix = ""
+	}
+	return assetPathsFilter(prefix, ref)
+}
+
+// assetPathsFilter: matches the documents whose asset fields
+// under prefix (ex: "attributes.") reference ref
+func assetPathsFilter(prefix string, ref interface{}) bson.M {
+	if ref == nil {
+		ref = primitive.Regex{Pattern: "^" + AssetRefPrefix}
+	}
+	filters := bson.A{}
+	for _, field := range assetFields {
+		filters = append(filters, bson.M{prefix + field: ref})
+	}
+	return bson.M{"$or": filters}
+}
+
+// docAssetRefs: assets referenced by a template or an object,
+// or by the fields of a patch (ex: "attributes.fbxModel")
+func docAssetRefs(doc map[string]interface{}) []string {
+	refs := []string{}
+	add := func(value interface{}) {
+		if ref, ok := value.(string); ok && strings.HasPrefix(ref, AssetRefPrefix) {
+			refs = append(refs, ref)
+		}
+	}
+	attrs, _ := doc["attributes"].(map[string]interface{})
+	for _, field := range assetFields {
+		add(doc[field])
+		add(doc["attributes."+field])
+		add(attrs[field])
+	}
+	return refs
+}
+
+// removedAssetRefs: assets referenced by old but not by new
+func removedAssetRefs(old, new map[string]interface{}) []string {
+	kept := map[string]bool{}
+	for _, ref := range docAssetRefs(new) {
+		kept[ref] = true
+	}
+	removed := []string{}
+	for _, ref := range docAssetRefs(old) {
+		if !kept[ref] {
+			removed = append(removed, ref)
+		}
+	}
+	return removed
+}
+
+// assetRefId: id of the asset referenced by "asset:<id>"
+func assetRefId(ref string) (primitive.ObjectID, bool) {
+	id, e := primitive.ObjectIDFromHex(strings.TrimPrefix(ref, AssetRefPrefix))
+	return id, e == nil
+}
+
+// validateAssetRefs: the assets referenced by a template or an
+// object should exist. They are not orphaned anymore once referenced
+func validateAssetRefs(doc map[string]interface{}) (map[string]interface{}, bool) {
+	ids := bson.A{}
+	for _, ref := range docAssetRefs(doc) {
+		id, ok := assetRefId(ref)
+		if !ok {
+			return u.Message(false, "Invalid asset reference "+ref+
+				", expected "+AssetRefPrefix+"<id>"), false
+		}
+		if asset, e := GetEntity(bson.M{"_id": id}, assetCollection, u.RequestFilters{}); asset == nil {
+			if e != "mongo: no documents in result" {
+				return u.Message(false, "Unable to check the asset: "+e), false
+			}
+			return u.Message(false, "The asset "+ref+" does not exist"), false
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) > 0 {
+		ctx, cancel := u.Connect()
+		defer cancel()
+		GetDB().Collection(assetCollection).UpdateMany(ctx,
+			bson.M{"_id": bson.M{"$in": ids}, "orphaned": true},
+			bson.M{"$set": bson.M{"orphaned": false}, "$unset": bson.M{"orphanedDate": ""}})
+	}
+	return nil, true
+}
+
+// getAssetUsages: templates (and their versions), objects
+// and attachments of objects referencing an asset
+func getAssetUsages(id primitive.ObjectID) ([]map[string]interface{}, string) {
+	usages := []map[string]interface{}{}
+	for _, user := range assetUsers {
+		entStr := u.EntityToString(user)
+		objs, e := GetManyEntities(entStr, assetFieldsFilter(user, AssetRefPrefix+id.Hex()), u.RequestFilters{})
+		if e != "" {
+			return nil, e
+		}
+		for _, obj := range objs {
+			usage := map[string]interface{}{"id": obj["id"], "category": entStr}
+			if isTemplate(user) {
+				usage["slug"] = obj["slug"]
+			} else {
+				usage["hierarchyName"] = getHierarchyName(obj)
+			}
+			usages = append(usages, usage)
+		}
+	}
+	// Files attached to objects
+	attachments, e := GetManyEntities(attachmentCollection,
+		bson.M{"asset": AssetRefPrefix + id.Hex()}, u.RequestFilters{})
+	if e != "" {
+		return nil, e
+	}
+	for _, attachment := range attachments {
+		usages = append(usages, map[string]interface{}{"id": attachment["id"],
+			"category": attachmentCollection, "objectId": attachment["objectId"]})
+	}
+	// Previous versions of the templates keep their assets,
+	// the template is saved as it was under "template"
+	versions, e := GetManyEntities(templateVersionCollection,
+		assetPathsFilter("template.", AssetRefPrefix+id.Hex()), u.RequestFilters{})
+	if e != "" {
+		return nil, e
+	}
+	for _, version := range versions {
+		usages = append(usages, map[string]interface{}{"id": version["id"],
+			"category": version["collection"], "slug": version["slug"], "version": version["version"]})
+	}
+	return usages, ""
+}
+
+// releaseAssets: assets that were referenced by deleted or updated
+// objects are marked as orphaned if nothing references them anymore,
+// so that they can be listed (GET /api/assets?orphaned=true) and
+// deleted instead of being kept unnoticed
+func releaseAssets(refs []string) {
+	done := map[string]bool{}
+	for _, ref := range refs {
+		id, ok := assetRefId(ref)
+		if !ok || done[ref] {
+			continue
+		}
+		done[ref] = true
+		usages, e := getAssetUsages(id)
+		if e != "" || len(usages) > 0 {
+			continue
+		}
+		ctx, cancel := u.Connect()
+		c, err := GetDB().Collection(assetCollection).UpdateOne(ctx,
+			bson.M{"_id": id, "orphaned": bson.M{"$ne": true}},
+			bson.M{"$set": bson.M{"orphaned": true,
+				"orphanedDate": primitive.NewDateTimeFromTime(time.Now())}})
+		cancel()
+		if err == nil && c.ModifiedCount > 0 {
+			println("The asset " + id.Hex() + " is not referenced anymore")
+			publishEvent("orphaned", assetCollection, map[string]interface{}{"id": id})
+		}
+	}
+}
+
+// CreateAsset: stores a file as an asset. A file whose content is
+// already stored is not stored again: the existing asset is returned,
+// with deduplicated set to true
+func CreateAsset(data []byte, filename, contentType string) (map[string]interface{}, string) {
+	if int64(len(data)) > GetAssetMaxSize() {
+		return u.Message(false, "The asset is too large, the maximum size is "+
+			strconv.FormatInt(GetAssetMaxSize(), 10)+" bytes"), "too large"
+	}
+	if len(data) == 0 {
+		return u.Message(false, "The asset is empty"), "invalid"
+	}
+	ensureAssetIndex()
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	existing, e := GetEntity(bson.M{"hash": hash}, assetCollection, u.RequestFilters{})
+	if existing == nil && e != "mongo: no documents in result" {
+		return u.Message(false, "Error while getting the asset: "+e), e
+	}
+	if existing != nil {
+		existing["ref"] = AssetRefPrefix + existing["id"].(primitive.ObjectID).Hex()
+		existing["deduplicated"] = true
+		resp := u.Message(true, "the asset already exists")
+		resp["data"] = existing
+		return resp, ""
+	}
+
+	if err := getAssetStore().put(hash, data); err != nil {
+		return u.Message(false, "Error while storing the asset: "+err.Error()), err.Error()
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	asset := map[string]interface{}{
+		"_id":         primitive.NewObjectID(),
+		"hash":        hash,
+		"size":        int64(len(data)),
+		"filename":    filename,
+		"contentType": contentType,
+		"createdDate": primitive.NewDateTimeFromTime(time.Now()),
+	}
+	ctx, cancel := u.Connect()
+	defer cancel()
+	if _, err := GetDB().Collection(assetCollection).InsertOne(ctx, asset); err != nil {
+		if strings.Contains(err.Error(), "E11000") {
+			// The same content was uploaded at the same time
+			return CreateAsset(data, filename, contentType)
+		}
+		return u.Message(false, "Error while creating the asset: "+err.Error()), err.Error()
+	}
+	asset = fixID(asset)
+	asset["ref"] = AssetRefPrefix + asset["id"].(primitive.ObjectID).Hex()
+	asset["deduplicated"] = false
+	publishEvent("created", assetCollection, asset)
+
+	resp := u.Message(true, "successfully created the asset")
+	resp["data"] = asset
+	return resp, ""
+}
+
+// GetAssets: metadata of the assets, only of the orphaned ones if orphaned
+func GetAssets(orphaned bool) (map[string]interface{}, string) {
+	req := bson.M{}
+	if orphaned {
+		req["orphaned"] = true
+	}
+	assets, e := GetManyEntities(assetCollection, req, u.RequestFilters{})
+	if e != "" {
+		return u.Message(false, "Error while getting the assets: "+e), e
+	}
+	for _, asset := range assets {
+		asset["ref"] = AssetRefPrefix + asset["id"].(primitive.ObjectID).Hex()
+	}
+	resp := u.Message(true, "successfully got the assets")
+	resp["data"] = map[string]interface{}{"objects": assets}
+	return resp, ""
+}
+
+// OpenAsset: metadata and content of an asset, the content must be closed
+func OpenAsset(id primitive.ObjectID) (map[string]interface{}, io.ReadSeekCloser, string) {
+	asset, e := GetEntity(bson.M{"_id": id}, assetCollection, u.RequestFilters{})
+	if asset == nil {
+		return u.Message(false, "Error while getting the asset: "+e), nil, e
+	}
+	content, err := getAssetStore().open(asset["hash"].(string))
+	if err != nil {
+		return u.Message(false, "Error while reading the asset: "+err.Error()), nil, err.Error()
+	}
+	return asset, content, ""
+}
+
+// GetAssetUsages: templates and objects referencing an asset
+func GetAssetUsages(id primitive.ObjectID) (map[string]interface{}, string) {
+	if asset, e := GetEntity(bson.M{"_id": id}, assetCollection, u.RequestFilters{}); asset == nil {
+		return u.Message(false, "Error while getting the asset: "+e), e
+	}
+	usages, e := getAssetUsages(id)
+	if e != "" {
+		return u.Message(false, "Error while getting the usages of the asset: "+e), e
+	}
+	resp := u.Message(true, "successfully got asset usages")
+	resp["data"] = map[string]interface{}{"ref": AssetRefPrefix + id.Hex(),
+		"count": len(usages), "usages": usages}
+	return resp, ""
+}
+
+// DeleteAsset: deletes an asset that no template or object references
+func DeleteAsset(id primitive.ObjectID) (map[string]interface{}, string) {
+	asset, e := GetEntity(bson.M{"_id": id}, assetCollection, u.RequestFilters{})
+	if asset == nil {
+		return u.Message(false, "Error while getting the asset: "+e), e
+	}
+	usages, e := getAssetUsages(id)
+	if e != "" {
+		return u.Message(false, "Error while getting the usages of the asset: "+e), e
+	}
+	if len(usages) > 0 {
+		resp := u.Message(false, "The asset "+AssetRefPrefix+id.Hex()+" is used by "+
+			strconv.Itoa(len(usages))+" template(s) or object(s)")
+		resp["data"] = usages
+		return resp, "in use"
+	}
+
+	resp, e := DeleteEntityManual(assetCollection, bson.M{"_id": id})
+	if e != "" {
+		return resp, e
+	}
+	if err := getAssetStore().remove(asset["hash"].(string)); err != nil {
+		println("Unable to remove the content of the asset " + id.Hex() + ": " + err.Error())
+	}
+	return resp, ""
+}
@@ -0,0 +1,298 @@
+package models
+
+import (
+	"io"
+	u "p3/utils"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Attachments (photos, PDFs...) and notes of the objects. They
+// reference their object by id so that they follow it when moved
+const (
+	attachmentCollection = "attachment"
+	noteCollection       = "note"
+)
+
+// objectSubtree: ids of an object and of its descendants and the
+// assets they reference, gathered before they are deleted
+type objectSubtree struct {
+	ids    []primitive.ObjectID
+	assets []string
+}
+
+// getObjectSubtree: the object matching req and its descendants
+func getObjectSubtree(entity string, req bson.M) objectSubtree {
+	subtree := objectSubtree{}
+	obj, _ := GetEntity(req, entity, u.RequestFilters{})
+	if obj == nil {
+		return subtree
+	}
+	subtree.ids = append(subtree.ids, obj["id"].(primitive.ObjectID))
+	subtree.assets = docAssetRefs(obj)
+
+	filters := u.RequestFilters{FieldsToShow: []string{"_id"}}
+	for _, field := range assetFields {
+		filters.FieldsToShow = append(filters.FieldsToShow, "attributes."+field)
+	}
+	pattern := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(getHierarchyName(obj)) + `\.`}
+	for _, childEnt := range descendantCollections(entity) {
+		children, _ := GetManyEntities(u.EntityToString(childEnt),
+			bson.M{"hierarchyName": pattern}, filters)
+		for _, child := range children {
+			subtree.ids = append(subtree.ids, child["id"].(primitive.ObjectID))
+			subtree.assets = append(subtree.assets, docAssetRefs(child)...)
+		}
+	}
+	return subtree
+}
+
+// releaseObjectSubtree: once the objects are deleted, their
+// attachments and notes are deleted too and the assets that
+// nothing references anymore are marked as orphaned
+func releaseObjectSubtree(subtree objectSubtree) {
+	if len(subtree.ids) == 0 {
+		return
+	}
+	assets := subtree.assets
+	req := bson.M{"objectId": bson.M{"$in": subtree.ids}}
+	attachments, _ := GetManyEntities(attachmentCollection, req, u.RequestFilters{})
+	for _, attachment := range attachments {
+		assets = append(assets, attachment["asset"].(string))
+	}
+
+	// By batches, as there may be many objects
+	for i := 0; i < len(subtree.ids); i += deleteBatchSize {
+		end := i + deleteBatchSize
+		if end > len(subtree.ids) {
+			end = len(subtree.ids)
+		}
+		ids := subtree.ids[i:end]
+		ctx, cancel := u.Connect()
+		for _, collection := range []string{attachmentCollection, noteCollection} {
+			_, e := GetDB().Collection(collection).DeleteMany(ctx,
+				bson.M{"objectId": bson.M{"$in": ids}})
+			if e != nil {
+				println("Unable to delete the " + collection + "s of deleted objects: " + e.Error())
+			}
+		}
+		cancel()
+	}
+	releaseAssets(assets)
+}
+
+// cloneObjectItems: copies of the attachments and notes of the
+// objects of ids, for their copies (the assets are shared)
+func cloneObjectItems(ids map[string]primitive.ObjectID, now primitive.DateTime) ([]docChange, string) {
+	sources := bson.A{}
+	for id := range ids {
+		objId, _ := primitive.ObjectIDFromHex(id)
+		sources = append(sources, objId)
+	}
+	changes := []docChange{}
+	for _, collection := range []string{attachmentCollection, noteCollection} {
+		items, e := GetManyEntities(collection,
+			bson.M{"objectId": bson.M{"$in": sources}}, u.RequestFilters{})
+		if e != "" {
+			return nil, e
+		}
+		for _, item := range items {
+			doc := bson.M{}
+			for key, value := range item {
+				doc[key] = value
+			}
+			delete(doc, "id")
+			doc["_id"] = primitive.NewObjectID()
+			doc["objectId"] = ids[item["objectId"].(primitive.ObjectID).Hex()]
+			doc["lastUpdated"] = now
+			changes = append(changes, docChange{collection: collection,
+				id: doc["_id"].(primitive.ObjectID), new: doc})
+		}
+	}
+	return changes, ""
+}
+
+// getObjectItems: attachments or notes of an object, newest first
+func getObjectItems(collection string, obj map[string]interface{}) ([]map[string]interface{}, string) {
+	ctx, cancel := u.Connect()
+	defer cancel()
+	c, err := GetDB().Collection(collection).Find(ctx,
+		bson.M{"objectId": obj["id"]},
+		options.Find().SetSort(bson.D{{Key: "createdDate", Value: -1}, {Key: "_id", Value: -1}}))
+	if err != nil {
+		return nil, err.Error()
+	}
+	return ExtractCursor(c, ctx)
+}
+
+// getObjectItem: the attachment or note id of the object hierarchyName
+func getObjectItem(collection, hierarchyName, id string) (map[string]interface{}, string) {
+	obj, _ := findObjectByName(hierarchyName)
+	if obj == nil {
+		return u.Message(false, "Unable to find object "+hierarchyName), "not found"
+	}
+	itemId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return u.Message(false, "Invalid "+collection+" id "+id), "not found"
+	}
+	item, e := GetEntity(bson.M{"_id": itemId, "objectId": obj["id"]}, collection, u.RequestFilters{})
+	if item == nil {
+		if e == "mongo: no documents in result" {
+			return u.Message(false, "Unable to find "+collection+" "+id+" of "+hierarchyName), "not found"
+		}
+		return u.Message(false, "Error while getting the "+collection+": "+e), e
+	}
+	return item, ""
+}
+
+// GetObjectItems: attachments or notes (collection) of the
+// object hierarchyName, the newest first
+func GetObjectItems(collection, hierarchyName string) (map[string]interface{}, string) {
+	obj, _ := findObjectByName(hierarchyName)
+	if obj == nil {
+		return u.Message(false, "Unable to find object "+hierarchyName), "not found"
+	}
+	items, e := getObjectItems(collection, obj)
+	if e != "" {
+		return u.Message(false, "Error while getting the "+collection+"s: "+e), e
+	}
+	resp := u.Message(true, "successfully got the "+collection+"s of "+hierarchyName)
+	resp["data"] = map[string]interface{}{"objects": items}
+	return resp, ""
+}
+
+// CreateAttachment: stores a file as an asset and attaches it
+// to the object hierarchyName
+func CreateAttachment(hierarchyName string, data []byte, filename, contentType, description, author string) (map[string]interface{}, string) {
+	obj, entity := findObjectByName(hierarchyName)
+	if obj == nil {
+		return u.Message(false, "Unable to find object "+hierarchyName), "not found"
+	}
+	resp, e := CreateAsset(data, filename, contentType)
+	if e != "" {
+		return resp, e
+	}
+	asset := resp["data"].(map[string]interface{})
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	attachment := map[string]interface{}{
+		"_id":         primitive.NewObjectID(),
+		"objectId":    obj["id"],
+		"category":    u.EntityToString(entity),
+		"asset":       asset["ref"],
+		"filename":    filename,
+		"contentType": asset["contentType"],
+		"size":        asset["size"],
+		"description": description,
+		"author":      author,
+		"createdDate": now,
+		"lastUpdated": now,
+	}
+	ctx, cancel := u.Connect()
+	defer cancel()
+	if _, err := GetDB().Collection(attachmentCollection).InsertOne(ctx, attachment); err != nil {
+		return u.Message(false, "Error while creating the attachment: "+err.Error()), err.Error()
+	}
+	attachment = fixID(attachment)
+	publishEvent("created", attachmentCollection, attachment)
+
+	resp = u.Message(true, "successfully attached the file to "+hierarchyName)
+	resp["data"] = attachment
+	return resp, ""
+}
+
+// OpenAttachment: an attachment of the object hierarchyName and
+// the content of its asset, the content must be closed
+func OpenAttachment(hierarchyName, id string) (map[string]interface{}, io.ReadSeekCloser, string) {
+	attachment, e := getObjectItem(attachmentCollection, hierarchyName, id)
+	if e != "" {
+		return attachment, nil, e
+	}
+	assetId, _ := assetRefId(attachment["asset"].(string))
+	asset, content, e := OpenAsset(assetId)
+	if e != "" {
+		return asset, nil, e
+	}
+	// The content is served with the name it was attached with
+	asset["filename"] = attachment["filename"]
+	return asset, content, ""
+}
+
+// CreateNote: adds a note to the object hierarchyName
+func CreateNote(hierarchyName, text, author string) (map[string]interface{}, string) {
+	obj, entity := findObjectByName(hierarchyName)
+	if obj == nil {
+		return u.Message(false, "Unable to find object "+hierarchyName), "not found"
+	}
+	if strings.TrimSpace(text) == "" {
+		return u.Message(false, "The text of the note is required"), "invalid"
+	}
+	now := primitive.NewDateTimeFromTime(time.Now())
+	note := map[string]interface{}{
+		"_id":         primitive.NewObjectID(),
+		"objectId":    obj["id"],
+		"category":    u.EntityToString(entity),
+		"text":        text,
+		"author":      author,
+		"createdDate": now,
+		"lastUpdated": now,
+	}
+	ctx, cancel := u.Connect()
+	defer cancel()
+	if _, err := GetDB().Collection(noteCollection).InsertOne(ctx, note); err != nil {
+		return u.Message(false, "Error while creating the note: "+err.Error()), err.Error()
+	}
+	note = fixID(note)
+	publishEvent("created", noteCollection, note)
+
+	resp := u.Message(true, "successfully added the note to "+hierarchyName)
+	resp["data"] = note
+	return resp, ""
+}
+
+// UpdateNote: changes the text of a note of the object hierarchyName
+func UpdateNote(hierarchyName, id, text, author string) (map[string]interface{}, string) {
+	note, e := getObjectItem(noteCollection, hierarchyName, id)
+	if e != "" {
+		return note, e
+	}
+	if strings.TrimSpace(text) == "" {
+		return u.Message(false, "The text of the note is required"), "invalid"
+	}
+	now := primitive.NewDateTimeFromTime(time.Now())
+	ctx, cancel := u.Connect()
+	defer cancel()
+	_, err := GetDB().Collection(noteCollection).UpdateOne(ctx, bson.M{"_id": note["id"]},
+		bson.M{"$set": bson.M{"text": text, "lastUpdated": now, "updatedBy": author}})
+	if err != nil {
+		return u.Message(false, "Error while updating the note: "+err.Error()), err.Error()
+	}
+	note["text"] = text
+	note["lastUpdated"] = now
+	note["updatedBy"] = author
+	publishEvent("updated", noteCollection, note)
+
+	resp := u.Message(true, "successfully updated the note")
+	resp["data"] = note
+	return resp, ""
+}
+
+// DeleteObjectItem: deletes an attachment or a note (collection) of
+// the object hierarchyName. The asset of a deleted attachment is
+// marked as orphaned if nothing else references it
+func DeleteObjectItem(collection, hierarchyName, id string) (map[string]interface{}, string) {
+	item, e := getObjectItem(collection, hierarchyName, id)
+	if e != "" {
+		return item, e
+	}
+	resp, e := DeleteEntityManual(collection, bson.M{"_id": item["id"]})
+	if e == "" && collection == attachmentCollection {
+		releaseAssets([]string{item["asset"].(string)})
+	}
+	return resp, e
+}
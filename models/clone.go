@@ -0,0 +1,180 @@
+package models
+
+import (
+	"encoding/json"
+	u "p3/utils"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// copyDocument: copy of an object ready to be inserted
+// again, without its id, dates and revision
+func copyDocument(obj map[string]interface{}) map[string]interface{} {
+	doc := map[string]interface{}{}
+	for key, value := range obj {
+		switch key {
+		case "id", "createdDate", "lastUpdated", "revision":
+		case "attributes":
+			attrs := map[string]interface{}{}
+			if v, ok := value.(map[string]interface{}); ok {
+				for attr, attrValue := range v {
+					attrs[attr] = attrValue
+				}
+			}
+			doc[key] = attrs
+		default:
+			doc[key] = value
+		}
+	}
+	return doc
+}
+
+// remapContent: rewrites the content of a copied group or corridor
+// so that it names the copies of the objects it named in the source
+func remapContent(doc map[string]interface{}, oldParentName, newParentName string, names map[string]string) {
+	content := getContent(doc)
+	if len(content) == 0 {
+		return
+	}
+	for i, item := range content {
+		if newName, ok := names[oldParentName+"."+item]; ok {
+			content[i] = strings.TrimPrefix(newName, newParentName+".")
+		}
+	}
+	doc["attributes"].(map[string]interface{})["content"] = strings.Join(content, ",")
+}
+
+// CloneObject: copies the object hierarchyName and all its descendants
+// under the object parentName, naming the copy name. attributes
+// overrides attributes of the copy. Every copy is created or none
+func CloneObject(hierarchyName, parentName, name string,
+	attributes map[string]interface{}) (map[string]interface{}, string) {
+	obj, entity := findObjectByName(hierarchyName)
+	if obj == nil {
+		return u.Message(false, "Unable to find object "+hierarchyName), "not found"
+	}
+	if name == "" || strings.Contains(name, ".") {
+		return u.Message(false, "A name without dots is required for the copy"), "invalid"
+	}
+	parent, parentEnt, resp, e := getNewParent(hierarchyName, entity, parentName)
+	if e != "" {
+		return resp, e
+	}
+
+	parentId := parent["id"].(primitive.ObjectID).Hex()
+	newName := getHierarchyName(parent) + "." + name
+	if clash := checkNameClash(newName, parentEnt); clash != "" {
+		return u.Message(false, "An object named "+clash+" already exists"), "clash"
+	}
+
+	root := copyDocument(obj)
+	root["name"] = name
+	root["parentId"] = parentId
+	root["hierarchyName"] = newName
+	for attr, value := range attributes {
+		root["attributes"].(map[string]interface{})[attr] = value
+	}
+	// Values read from mongo are validated as JSON
+	data, _ := json.Marshal(root)
+	check := map[string]interface{}{}
+	json.Unmarshal(data, &check)
+	if resp, ok := validateJsonSchema(entity, check); !ok {
+		return resp, "invalid"
+	}
+	if entity == u.DEVICE {
+		unlock, resp, e := lockParentRack(parent)
+		if e != "" {
+			return resp, e
+		}
+		defer unlock()
+		if resp, ok := checkDevicePlacement(check, parent, nil); !ok {
+			return resp, "clash"
+		}
+	}
+	if hasFootprint(entity) {
+		unlock, resp, e := lockRooms(parent["id"].(primitive.ObjectID).Hex())
+		if e != "" {
+			return resp, e
+		}
+		defer unlock()
+		if resp, ok := checkRoomPlacement(check, parent, nil); !ok {
+			return resp, "clash"
+		}
+	}
+	switch entity {
+	case u.GROUP, u.CORRIDOR:
+		if missing := checkContentUnder(getContent(root), parentId, parentEnt); missing != "" {
+			return u.Message(false, "The content "+missing+" of the object"+
+				" does not exist under "+parentName), "invalid"
+		}
+	}
+
+	subtree, e := getSubtree(hierarchyName, entity)
+	if e != "" {
+		return u.Message(false, "Error while getting object children: "+e), e
+	}
+
+	// Copies get fresh ids, parentIds are remapped with them
+	ids := map[string]primitive.ObjectID{
+		obj["id"].(primitive.ObjectID).Hex(): primitive.NewObjectID()}
+	names := map[string]string{hierarchyName: newName}
+	for _, child := range subtree {
+		ids[child.data["id"].(primitive.ObjectID).Hex()] = primitive.NewObjectID()
+		names[child.data["hierarchyName"].(string)] =
+			newName + strings.TrimPrefix(child.data["hierarchyName"].(string), hierarchyName)
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	root["_id"] = ids[obj["id"].(primitive.ObjectID).Hex()]
+	root["createdDate"] = now
+	root["lastUpdated"] = now
+	root["revision"] = int64(1)
+	changes := []docChange{{collection: u.EntityToString(entity), id: root["_id"].(primitive.ObjectID), new: root}}
+	created := []interface{}{map[string]interface{}{
+		"id": root["_id"], "category": u.EntityToString(entity),
+		"sourceHierarchyName": hierarchyName, "hierarchyName": newName}}
+
+	for _, child := range subtree {
+		oldChildName := child.data["hierarchyName"].(string)
+		oldParentName := oldChildName[:strings.LastIndex(oldChildName, ".")]
+		doc := copyDocument(child.data)
+		doc["_id"] = ids[child.data["id"].(primitive.ObjectID).Hex()]
+		doc["parentId"] = ids[child.data["parentId"].(string)].Hex()
+		doc["hierarchyName"] = names[oldChildName]
+		doc["createdDate"] = now
+		doc["lastUpdated"] = now
+		doc["revision"] = int64(1)
+		if child.entity == u.GROUP || child.entity == u.CORRIDOR {
+			remapContent(doc, oldParentName, names[oldParentName], names)
+		}
+		changes = append(changes, docChange{collection: u.EntityToString(child.entity),
+			id: doc["_id"].(primitive.ObjectID), new: doc})
+		created = append(created, map[string]interface{}{
+			"id": doc["_id"], "category": u.EntityToString(child.entity),
+			"sourceHierarchyName": oldChildName, "hierarchyName": doc["hierarchyName"]})
+	}
+
+	// The copies get the attachments and notes of their source
+	items, e := cloneObjectItems(ids, now)
+	if e != "" {
+		return u.Message(false, "Error while getting object attachments and notes: "+e), e
+	}
+	changes = append(changes, items...)
+
+	if err := applyChanges(changes); err != nil {
+		if strings.Contains(err.Error(), "E11000") {
+			return u.Message(false, "Error while copying object: Duplicates not allowed"), "clash"
+		}
+		return u.Message(false, "Error while copying object: "+err.Error()), err.Error()
+	}
+
+	for _, change := range changes {
+		publishEvent("created", change.collection, fixID(change.new))
+	}
+
+	resp = u.Message(true, "successfully copied object")
+	resp["data"] = created
+	return resp, ""
+}
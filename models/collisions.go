@@ -0,0 +1,292 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"os"
+	u "p3/utils"
+	"reflect"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// footprintEntities: objects of a room that take up room on its floor.
+// Corridors are left out, they are made of racks
+var footprintEntities = []int{u.RACK, u.AC, u.CABINET, u.PWRPNL}
+
+func hasFootprint(entity int) bool {
+	for _, ent := range footprintEntities {
+		if ent == entity {
+			return true
+		}
+	}
+	return false
+}
+
+// footprintAttributes: the attributes giving the footprint of an object
+var footprintAttributes = []string{"posXYZ", "posXY", "posXYUnit", "size", "sizeUnit", "orientation"}
+
+// roomCollisionsChecked: placements are checked on writes unless
+// room_collisions is off in the .env file (ex: for existing data
+// not fixed yet, see GetRoomCollisions)
+func roomCollisionsChecked() bool {
+	return os.Getenv("room_collisions") != "off"
+}
+
+// lockRooms: locks the floors of the rooms of ids, so that
+// objects can't be placed concurrently where they would overlap
+func lockRooms(ids ...string) (func(), map[string]interface{}, string) {
+	keys := []string{}
+	for _, id := range ids {
+		keys = append(keys, "room:"+id)
+	}
+	unlock, err := acquireLocks(keys, 5*time.Second)
+	if errors.Is(err, errLockTimeout) {
+		return nil, u.Message(false, "Unable to place the object in the room: "+err.Error()), "locked"
+	} else if err != nil {
+		return nil, u.Message(false, "Unable to place the object in the room: "+err.Error()), "internal"
+	}
+	return unlock, nil, ""
+}
+
+// lockObjectRoom: locks the room of a rack, an AC, a cabinet
+// or a panel (given by its parentId) while it is placed
+func lockObjectRoom(entity int, obj map[string]interface{}) (func(), map[string]interface{}, string) {
+	if !hasFootprint(entity) || !roomCollisionsChecked() {
+		return func() {}, nil, ""
+	}
+	parentId, _ := obj["parentId"].(string)
+	if parentId == "" {
+		return func() {}, nil, ""
+	}
+	room := getParentObject(entity, parentId)
+	if room == nil {
+		return func() {}, nil, ""
+	}
+	return lockRooms(room["id"].(primitive.ObjectID).Hex())
+}
+
+// getRoomAxes: the axisOrientation of a room
+func getRoomAxes(room map[string]interface{}) string {
+	attrs, _ := room["attributes"].(map[string]interface{})
+	axes, _ := attrs["axisOrientation"].(string)
+	return axes
+}
+
+// getFootprint: the footprint of an object of a room, from its
+// posXYZ (or posXY), posXYUnit, size, sizeUnit and orientation,
+// along the axes of the room. ok is false for objects without
+// position or size
+func getFootprint(obj, room map[string]interface{}) (r u.Rect, ok bool, err error) {
+	attrs, _ := obj["attributes"].(map[string]interface{})
+	pos, _ := attrs["posXYZ"].(string)
+	if pos == "" {
+		pos, _ = attrs["posXY"].(string)
+	}
+	size, _ := attrs["size"].(string)
+	if pos == "" || size == "" {
+		return r, false, nil
+	}
+	posUnit, _ := attrs["posXYUnit"].(string)
+	sizeUnit, _ := attrs["sizeUnit"].(string)
+	orientation, _ := attrs["orientation"].(string)
+
+	p, err := u.ParseVector(pos)
+	if err != nil {
+		return r, true, err
+	}
+	s, err := u.ParseVector(size)
+	if err != nil {
+		return r, true, err
+	}
+	r, err = u.Footprint(p, posUnit, s, sizeUnit, orientation, getRoomAxes(room))
+	return r, true, err
+}
+
+// getRoomFootprint: the floor of a room, from its origin by its
+// size along its axes (axisOrientation). The size keeps its sign: a
+// +x+y room of size {"x":-13,"y":-2.9} goes from -13 to 0m along x
+// and from -2.9 to 0m along y, as the objects placed in it
+func getRoomFootprint(room map[string]interface{}) (u.Rect, error) {
+	attrs, _ := room["attributes"].(map[string]interface{})
+	size, _ := attrs["size"].(string)
+	sizeUnit, _ := attrs["sizeUnit"].(string)
+	s, err := u.ParseVector(size)
+	if err != nil {
+		return u.Rect{}, err
+	}
+	return u.Footprint(u.Vector{}, "m", s, sizeUnit, "", getRoomAxes(room))
+}
+
+// footprintChanged: the update of an object changes its footprint
+// or its room. Other updates are not checked, so that objects
+// already in collision can still be changed
+func footprintChanged(old, updated map[string]interface{}) bool {
+	if old["parentId"] != updated["parentId"] {
+		return true
+	}
+	oldAttrs, _ := old["attributes"].(map[string]interface{})
+	attrs, _ := updated["attributes"].(map[string]interface{})
+	for _, attr := range footprintAttributes {
+		if !reflect.DeepEqual(oldAttrs[attr], attrs[attr]) {
+			return true
+		}
+	}
+	return false
+}
+
+// describeFootprint: a footprint in m (rounded to the mm),
+// as x from-to, y from-to
+func describeFootprint(r u.Rect) string {
+	m := func(mm float64) string {
+		return formatFloat(math.Round(mm) / 1000)
+	}
+	return "x " + m(r.MinX) + " to " + m(r.MaxX) + "m, y " + m(r.MinY) + " to " + m(r.MaxY) + "m"
+}
+
+// getRoomObjects: the objects placed on the floor of a room
+func getRoomObjects(room map[string]interface{}) ([]subtreeObject, string) {
+	parentId := room["id"].(primitive.ObjectID).Hex()
+	objects := []subtreeObject{}
+	for _, entity := range footprintEntities {
+		objs, e := GetManyEntities(u.EntityToString(entity),
+			bson.M{"parentId": parentId}, u.RequestFilters{})
+		if e != "" {
+			return nil, e
+		}
+		for _, obj := range objs {
+			objects = append(objects, subtreeObject{entity, obj})
+		}
+	}
+	return objects, ""
+}
+
+// checkRoomPlacement: an object should be inside its room and
+// should not overlap the other objects of the room. exclude
+// is the ID of the object itself, when it is updated or moved
+func checkRoomPlacement(obj, room map[string]interface{}, exclude interface{}) (map[string]interface{}, bool) {
+	if !roomCollisionsChecked() {
+		return nil, true
+	}
+	r, ok, err := getFootprint(obj, room)
+	if !ok {
+		return nil, true
+	} else if err != nil {
+		return u.Message(false, "Unable to get the footprint of the object: "+err.Error()), false
+	}
+
+	// Rooms without a valid size can't be checked
+	if floor, err := getRoomFootprint(room); err == nil && !floor.Contains(r) {
+		return u.Message(false, "The object ("+describeFootprint(r)+") is outside room "+
+			getHierarchyName(room)+" ("+describeFootprint(floor)+")"), false
+	}
+
+	others, e := getRoomObjects(room)
+	if e != "" {
+		return u.Message(false, "Unable to get the objects of the room: "+e), false
+	}
+	for _, other := range others {
+		if exclude != nil && other.data["id"] == exclude {
+			continue
+		}
+		footprint, ok, err := getFootprint(other.data, room)
+		if !ok || err != nil {
+			continue
+		}
+		if r.Overlaps(footprint) {
+			return u.Message(false, "The object ("+describeFootprint(r)+") clashes with "+
+				u.EntityToString(other.entity)+" "+getHierarchyName(other.data)+
+				" ("+describeFootprint(footprint)+")"), false
+		}
+	}
+	return nil, true
+}
+
+// validateRoomPlacement: checks the footprint of a rack, an AC, a
+// cabinet or a panel in its room. old is the object before an update
+// (nil on creation), it is only checked if its footprint changed
+func validateRoomPlacement(entity int, obj, old map[string]interface{}) (map[string]interface{}, bool) {
+	if !hasFootprint(entity) {
+		return nil, true
+	}
+	var exclude interface{}
+	if old != nil {
+		if !footprintChanged(old, obj) {
+			return nil, true
+		}
+		exclude = old["id"]
+	}
+	parentId, _ := obj["parentId"].(string)
+	if parentId == "" {
+		return nil, true
+	}
+	room := getParentObject(entity, parentId)
+	if room == nil {
+		return nil, true
+	}
+	return checkRoomPlacement(obj, room, exclude)
+}
+
+// GetRoomCollisions: audit of the objects of a room, those outside
+// of it, those overlapping each other and those whose footprint
+// can't be computed
+func GetRoomCollisions(req bson.M) (map[string]interface{}, string) {
+	room, e := GetEntity(req, "room", u.RequestFilters{})
+	if room == nil {
+		return u.Message(false, "Error while getting room: "+e), e
+	}
+	floor, err := getRoomFootprint(room)
+	if err != nil {
+		return u.Message(false, "Unable to get the size of the room: "+err.Error()), "invalid"
+	}
+	objects, e := getRoomObjects(room)
+	if e != "" {
+		return u.Message(false, "Error while getting the objects of the room: "+e), e
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return getHierarchyName(objects[i].data) < getHierarchyName(objects[j].data)
+	})
+
+	describe := func(object subtreeObject, r u.Rect) map[string]interface{} {
+		return map[string]interface{}{"id": object.data["id"],
+			"category":      u.EntityToString(object.entity),
+			"hierarchyName": getHierarchyName(object.data), "footprint": describeFootprint(r)}
+	}
+	type placed struct {
+		object    subtreeObject
+		footprint u.Rect
+	}
+	checked := []placed{}
+	outside, overlaps, invalid := []interface{}{}, []interface{}{}, []interface{}{}
+	for _, object := range objects {
+		r, ok, err := getFootprint(object.data, room)
+		if !ok {
+			continue
+		} else if err != nil {
+			invalid = append(invalid, map[string]interface{}{"id": object.data["id"],
+				"category":      u.EntityToString(object.entity),
+				"hierarchyName": getHierarchyName(object.data), "message": err.Error()})
+			continue
+		}
+		if !floor.Contains(r) {
+			outside = append(outside, describe(object, r))
+		}
+		for _, other := range checked {
+			if r.Overlaps(other.footprint) {
+				overlaps = append(overlaps, []interface{}{
+					describe(other.object, other.footprint), describe(object, r)})
+			}
+		}
+		checked = append(checked, placed{object, r})
+	}
+
+	resp := u.Message(true, "successfully got room collisions")
+	resp["data"] = map[string]interface{}{
+		"id": room["id"], "hierarchyName": room["hierarchyName"],
+		"floor": describeFootprint(floor), "objects": len(checked),
+		"outside": outside, "overlaps": overlaps, "invalid": invalid}
+	return resp, ""
+}
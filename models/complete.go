@@ -0,0 +1,106 @@
+package models
+
+import (
+	u "p3/utils"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// completionEntities: collections of the hierarchy
+func completionEntities() []int {
+	entities := []int{}
+	for entity := u.TENANT; entity <= u.GROUP; entity++ {
+		entities = append(entities, entity)
+	}
+	return entities
+}
+
+// completionField: field holding the name in the hierarchy
+func completionField(entity int) string {
+	if entity == u.TENANT {
+		return "name"
+	}
+	return "hierarchyName"
+}
+
+// EnsureHierarchyNameIndexes: creates the indexes on hierarchyName
+// (name for tenants) that make Complete a range scan of the index
+func EnsureHierarchyNameIndexes() {
+	for _, entity := range completionEntities() {
+		ctx, cancel := u.Connect()
+		_, e := GetDB().Collection(u.EntityToString(entity)).Indexes().CreateOne(ctx,
+			mongo.IndexModel{Keys: bson.D{{Key: completionField(entity), Value: 1}}})
+		cancel()
+		if e != nil {
+			println("Unable to create hierarchyName index of "+u.EntityToString(entity)+":",
+				e.Error())
+		}
+	}
+}
+
+// Complete: objects of the hierarchy whose name completes prefix,
+// that is the children of the part of prefix before its last dot whose
+// name starts with what follows it. At most limit objects are returned,
+// sorted by name
+func Complete(prefix string, limit int) (map[string]interface{}, string) {
+	if limit < 1 {
+		return u.Message(false, "Error: limit should be positive"), "invalid"
+	}
+	// Anchored on a literal prefix, so that the index can be used
+	pattern := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(prefix) + "[^.]*$", Options: ""}
+	depth := strings.Count(prefix, ".")
+
+	results := []map[string]interface{}{}
+	for _, entity := range completionEntities() {
+		if (entity == u.TENANT) != (depth == 0) {
+			continue
+		}
+		field := completionField(entity)
+		ctx, cancel := u.Connect()
+		opts := options.Find().SetSort(bson.D{{Key: field, Value: 1}}).
+			SetLimit(int64(limit)).
+			SetProjection(bson.M{"_id": 0, "name": 1, "category": 1, field: 1})
+		cursor, e := GetDB().Collection(u.EntityToString(entity)).Find(ctx,
+			bson.M{field: pattern}, opts)
+		if e != nil {
+			cancel()
+			return u.Message(false, "Error while completing "+prefix+": "+e.Error()), e.Error()
+		}
+		data, e1 := ExtractCursor(cursor, ctx)
+		cancel()
+		if e1 != "" {
+			return u.Message(false, "Error while completing "+prefix+": "+e1), e1
+		}
+		for _, obj := range data {
+			if entity == u.TENANT {
+				obj["hierarchyName"] = obj["name"]
+			}
+			results = append(results, obj)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i]["hierarchyName"].(string) < results[j]["hierarchyName"].(string)
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	completions := []interface{}{}
+	for _, obj := range results {
+		completions = append(completions, map[string]interface{}{
+			"name":          obj["name"],
+			"hierarchyName": obj["hierarchyName"],
+			"category":      obj["category"],
+		})
+	}
+	resp := u.Message(true, "successfully completed "+prefix)
+	resp["data"] = map[string]interface{}{"objects": completions}
+	return resp, ""
+}
@@ -0,0 +1,256 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	u "p3/utils"
+	"sort"
+	"strings"
+)
+
+// Fields that differ between any two objects and are not compared
+var diffIgnoredFields = map[string]bool{
+	"id": true, "parentId": true, "hierarchyName": true, "name": true,
+	"createdDate": true, "lastUpdated": true, "revision": true, "children": true,
+}
+
+// diffNode: an object of a compared tree
+type diffNode struct {
+	category string
+	data     map[string]interface{}
+}
+
+// getDiffTree: the object name (hierarchyName, optionally followed by
+// @YYYY-MM-DD or @date-time for the object as it was at the end of that
+// day or at that time) and its descendants, by name relative to it
+func getDiffTree(name string) (map[string]diffNode, string) {
+	if i := strings.LastIndex(name, "@"); i >= 0 {
+		date, ok := parseDiffDate(name[i+1:])
+		if !ok {
+			return nil, "invalid"
+		}
+		return getPastDiffTree(name[:i], date)
+	}
+
+	root, entity := findObjectByName(name)
+	if root == nil {
+		return nil, "not found"
+	}
+	root["children"], _ = GetHierarchyByName(u.EntityToString(entity), name, 999, u.RequestFilters{})
+
+	tree := map[string]diffNode{}
+	flattenDiffTree(root, name, tree)
+	return tree, ""
+}
+
+func flattenDiffTree(node map[string]interface{}, rootName string, tree map[string]diffNode) {
+	addDiffNode(node, rootName, tree)
+	children, _ := node["children"].([]map[string]interface{})
+	for _, child := range children {
+		flattenDiffTree(child, rootName, tree)
+	}
+}
+
+func addDiffNode(node map[string]interface{}, rootName string, tree map[string]diffNode) {
+	relName := strings.TrimPrefix(strings.TrimPrefix(getHierarchyName(node), rootName), ".")
+	category, _ := node["category"].(string)
+	tree[relName] = diffNode{category, node}
+}
+
+// flattenFields: compared fields of an object, nested ones
+// are named with their path (ex: attributes.posXY)
+func flattenFields(prefix string, value interface{}, fields map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if prefix == "" && diffIgnoredFields[key] {
+				continue
+			}
+			name := key
+			if prefix != "" {
+				name = prefix + "." + key
+			}
+			flattenFields(name, child, fields)
+		}
+	default:
+		// Compare values as JSON, mongo and JSON types differ
+		data, _ := json.Marshal(v)
+		fields[prefix] = string(data)
+	}
+}
+
+func diffFields(left, right map[string]interface{}) []interface{} {
+	leftFields := map[string]interface{}{}
+	rightFields := map[string]interface{}{}
+	flattenFields("", left, leftFields)
+	flattenFields("", right, rightFields)
+
+	names := []string{}
+	for name := range leftFields {
+		names = append(names, name)
+	}
+	for name := range rightFields {
+		if _, ok := leftFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []interface{}{}
+	for _, name := range names {
+		if leftFields[name] != rightFields[name] {
+			change := map[string]interface{}{"field": name}
+			if v, ok := leftFields[name]; ok {
+				change["left"] = json.RawMessage(v.(string))
+			}
+			if v, ok := rightFields[name]; ok {
+				change["right"] = json.RawMessage(v.(string))
+			}
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// lastName: name of an object from its relative name
+func lastName(relName string) string {
+	return relName[strings.LastIndex(relName, ".")+1:]
+}
+
+// DiffObjects: compares the trees of the objects left and right,
+// matching their descendants by name relative to them. An object
+// found at a different place with the same name and category
+// on each side (and only once) is reported as moved
+func DiffObjects(left, right string) (map[string]interface{}, string) {
+	leftTree, e := getDiffTree(left)
+	if e != "" {
+		return u.Message(false, "Unable to get the tree of "+left+": "+e), e
+	}
+	rightTree, e := getDiffTree(right)
+	if e != "" {
+		return u.Message(false, "Unable to get the tree of "+right+": "+e), e
+	}
+
+	removedNames := []string{}
+	addedNames := []string{}
+	changed := []interface{}{}
+	for relName, leftNode := range leftTree {
+		rightNode, ok := rightTree[relName]
+		if !ok {
+			removedNames = append(removedNames, relName)
+			continue
+		}
+		fields := diffFields(leftNode.data, rightNode.data)
+		if len(fields) > 0 {
+			changed = append(changed, map[string]interface{}{
+				"name": relName, "category": rightNode.category, "fields": fields})
+		}
+	}
+	for relName := range rightTree {
+		if _, ok := leftTree[relName]; !ok {
+			addedNames = append(addedNames, relName)
+		}
+	}
+	sort.Strings(removedNames)
+	sort.Strings(addedNames)
+
+	// Pair removed and added objects with the same name and category
+	key := func(tree map[string]diffNode, relName string) string {
+		return tree[relName].category + "/" + lastName(relName)
+	}
+	count := map[string]int{}
+	for _, relName := range removedNames {
+		count["-"+key(leftTree, relName)]++
+	}
+	for _, relName := range addedNames {
+		count["+"+key(rightTree, relName)]++
+	}
+	movedTo := map[string]string{}
+	for _, relName := range addedNames {
+		k := key(rightTree, relName)
+		if count["-"+k] == 1 && count["+"+k] == 1 {
+			movedTo[k] = relName
+		}
+	}
+
+	removed := []interface{}{}
+	moved := []interface{}{}
+	for _, relName := range removedNames {
+		if to, ok := movedTo[key(leftTree, relName)]; ok {
+			moved = append(moved, map[string]interface{}{"from": relName, "to": to,
+				"category": leftTree[relName].category,
+				"fields":   diffFields(leftTree[relName].data, rightTree[to].data)})
+		} else {
+			removed = append(removed, map[string]interface{}{"name": relName,
+				"category": leftTree[relName].category})
+		}
+	}
+	added := []interface{}{}
+	for _, relName := range addedNames {
+		if to, ok := movedTo[key(rightTree, relName)]; !ok || to != relName {
+			added = append(added, map[string]interface{}{"name": relName,
+				"category": rightTree[relName].category})
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool {
+		return changed[i].(map[string]interface{})["name"].(string) <
+			changed[j].(map[string]interface{})["name"].(string)
+	})
+
+	resp := u.Message(true, "successfully compared objects")
+	resp["data"] = map[string]interface{}{
+		"left": left, "right": right,
+		"added": added, "removed": removed, "moved": moved, "changed": changed,
+	}
+	return resp, ""
+}
+
+// FormatUnifiedDiff: human readable version of the data of DiffObjects
+func FormatUnifiedDiff(diff map[string]interface{}) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", diff["left"], diff["right"])
+
+	writeFields := func(fields interface{}) {
+		for _, f := range fields.([]interface{}) {
+			field := f.(map[string]interface{})
+			if v, ok := field["left"]; ok {
+				fmt.Fprintf(&b, "-   %s: %s\n", field["field"], formatDiffValue(v))
+			}
+			if v, ok := field["right"]; ok {
+				fmt.Fprintf(&b, "+   %s: %s\n", field["field"], formatDiffValue(v))
+			}
+		}
+	}
+
+	for _, r := range diff["removed"].([]interface{}) {
+		obj := r.(map[string]interface{})
+		fmt.Fprintf(&b, "- %s (%s)\n", obj["name"], obj["category"])
+	}
+	for _, a := range diff["added"].([]interface{}) {
+		obj := a.(map[string]interface{})
+		fmt.Fprintf(&b, "+ %s (%s)\n", obj["name"], obj["category"])
+	}
+	for _, m := range diff["moved"].([]interface{}) {
+		obj := m.(map[string]interface{})
+		fmt.Fprintf(&b, "> %s -> %s (%s)\n", obj["from"], obj["to"], obj["category"])
+		writeFields(obj["fields"])
+	}
+	for _, c := range diff["changed"].([]interface{}) {
+		obj := c.(map[string]interface{})
+		name := obj["name"]
+		if name == "" {
+			name = "."
+		}
+		fmt.Fprintf(&b, "@@ %s (%s) @@\n", name, obj["category"])
+		writeFields(obj["fields"])
+	}
+	return b.String()
+}
+
+func formatDiffValue(v interface{}) string {
+	if raw, ok := v.(json.RawMessage); ok {
+		return string(raw)
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
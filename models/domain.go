@@ -0,0 +1,331 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	u "p3/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Domains nest like objects: the hierarchyName of a subdomain is
+// the one of its parent, a dot, its name. It has to be unique
+var domainIndexOnce sync.Once
+
+// domainEntities: collections of the objects belonging to a domain
+func domainEntities() []int {
+	entities := []int{}
+	for i := u.TENANT; i <= u.GROUP; i++ {
+		entities = append(entities, i)
+	}
+	return append(entities, u.STRAYDEV, u.STRAYSENSOR)
+}
+
+func hasDomain(entity int) bool {
+	for _, i := range domainEntities() {
+		if i == entity {
+			return true
+		}
+	}
+	return false
+}
+
+// domainRegex: matches a domain and its subdomains
+func domainRegex(hierarchyName string) primitive.Regex {
+	return primitive.Regex{Pattern: "^" + regexp.QuoteMeta(hierarchyName) + "(\\.|$)", Options: ""}
+}
+
+func ensureDomainIndex() {
+	domainIndexOnce.Do(func() {
+		ctx, cancel := u.Connect()
+		defer cancel()
+		_, e := GetDB().Collection(u.EntityToString(u.DOMAIN)).Indexes().CreateOne(ctx,
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "hierarchyName", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			})
+		if e != nil {
+			println("Unable to create domain index:", e.Error())
+		}
+	})
+}
+
+// getParentObject: the parent of an object given its parentId,
+// an ID or a hierarchyName, nil if not found
+func getParentObject(entity int, parentId string) map[string]interface{} {
+	var req bson.M
+	if objID, err := primitive.ObjectIDFromHex(parentId); err == nil {
+		req = bson.M{"_id": objID}
+	} else {
+		req = bson.M{"hierarchyName": parentId}
+	}
+
+	parentEntities := GetParentCollections(entity)
+	if entity == u.STRAYDEV || entity == u.STRAYSENSOR {
+		parentEntities = []int{u.STRAYDEV}
+	}
+	for _, parentEnt := range parentEntities {
+		if parent, _ := GetEntity(req, u.EntityToString(parentEnt), u.RequestFilters{}); parent != nil {
+			return parent
+		}
+	}
+	return nil
+}
+
+// inheritDomain: an object given without domain
+// gets the one of its parent
+func inheritDomain(entity int, t map[string]interface{}) {
+	if !hasDomain(entity) || entity == u.TENANT {
+		return
+	}
+	if domain, _ := t["domain"].(string); domain != "" {
+		return
+	}
+	parentId, _ := t["parentId"].(string)
+	if parentId == "" {
+		return
+	}
+	if parent := getParentObject(entity, parentId); parent != nil && parent["domain"] != nil {
+		t["domain"] = parent["domain"]
+	}
+}
+
+// validateDomain: the domain of an object should exist
+func validateDomain(domain interface{}) (map[string]interface{}, bool) {
+	name, ok := domain.(string)
+	if !ok || name == "" {
+		return u.Message(false, "Domain should be a domain name"), false
+	}
+	if d, _ := GetEntity(bson.M{"hierarchyName": name}, u.EntityToString(u.DOMAIN),
+		u.RequestFilters{}); d == nil {
+		return u.Message(false, "Domain not found: "+name), false
+	}
+	return nil, true
+}
+
+// validateParentDomain: sets the hierarchyName of a domain
+// from its optional parent domain, an ID or a hierarchyName
+func validateParentDomain(t map[string]interface{}) (map[string]interface{}, bool) {
+	ensureDomainIndex()
+	parentId, _ := t["parentId"].(string)
+	if parentId == "" {
+		delete(t, "parentId")
+		t["hierarchyName"] = t["name"]
+		return nil, true
+	}
+
+	var req bson.M
+	if objID, err := primitive.ObjectIDFromHex(parentId); err == nil {
+		req = bson.M{"_id": objID}
+	} else {
+		req = bson.M{"hierarchyName": parentId}
+	}
+	parent, _ := GetEntity(req, u.EntityToString(u.DOMAIN), u.RequestFilters{})
+	if parent == nil {
+		return u.Message(false, "ParentID should correspond to an existing domain"), false
+	}
+	// Stored by ID so that renaming the parent doesn't break it
+	t["parentId"] = parent["id"].(primitive.ObjectID).Hex()
+	t["hierarchyName"] = parent["hierarchyName"].(string) + "." + t["name"].(string)
+	return nil, true
+}
+
+// propagateDomainNameChange: subdomains, objects and schema
+// extensions of a renamed (or moved) domain follow it
+func propagateDomainNameChange(ctx context.Context, oldName, newName string) {
+	replace := func(field string) mongo.Pipeline {
+		return mongo.Pipeline{bson.D{{
+			Key: "$set", Value: bson.M{
+				field: bson.M{
+					"$replaceOne": bson.M{
+						"input":       "$" + field,
+						"find":        oldName,
+						"replacement": newName}}}}}}
+	}
+
+	_, e := GetDB().Collection(u.EntityToString(u.DOMAIN)).UpdateMany(ctx,
+		bson.M{"hierarchyName": primitive.Regex{
+			Pattern: "^" + regexp.QuoteMeta(oldName) + "\\.", Options: ""}},
+		replace("hierarchyName"))
+	if e != nil {
+		println(e.Error())
+	}
+	for _, entity := range domainEntities() {
+		e := updateObjects(ctx, u.EntityToString(entity),
+			bson.M{"domain": domainRegex(oldName)}, replace("domain"))
+		if e != nil {
+			println(e.Error())
+		}
+	}
+	_, e = GetDB().Collection(schemaExtensionCollection).UpdateMany(ctx,
+		bson.M{"domain": domainRegex(oldName)}, replace("domain"))
+	if e != nil {
+		println(e.Error())
+	}
+	InvalidateSchemaExtensions()
+}
+
+// Reasons why a domain can't be deleted
+var (
+	errDomainHasSubdomains = errors.New("The domain has subdomains, delete them first")
+	errDomainHasObjects    = errors.New("Objects still belong to the domain, " +
+		"move them to another one first")
+)
+
+// DeleteDomain: deletes a domain, unless it has subdomains or
+// objects still belong to it. The domain is deleted then checked in
+// one transaction, so that nothing can be added to it meanwhile
+func DeleteDomain(req bson.M, revisions []int64) (map[string]interface{}, string) {
+	domains, e := findDocuments(u.EntityToString(u.DOMAIN), req)
+	if e != nil {
+		return u.Message(false, "Error while deleting domain: "+e.Error()), "internal"
+	}
+	if len(domains) == 0 {
+		return u.Message(false, "Error while deleting domain: not found"), "not found"
+	}
+	domain := domains[0]
+	if !u.ETagMatches(revisions, GetRevision(domain)) {
+		return preconditionFailedMessage(), "precondition failed"
+	}
+	id := domain["_id"].(primitive.ObjectID)
+	hierarchyName := domain["hierarchyName"].(string)
+
+	deleted := false
+	deleteUnused := func(ctx context.Context) error {
+		domains := GetDB().Collection(u.EntityToString(u.DOMAIN))
+		res, e := domains.DeleteOne(ctx,
+			withRevision(bson.M{"_id": id}, []int64{GetRevision(domain)}))
+		if e != nil {
+			return e
+		}
+		if res.DeletedCount == 0 {
+			return modifiedError(u.EntityToString(u.DOMAIN))
+		}
+		deleted = true
+		c, e := domains.CountDocuments(ctx, bson.M{"hierarchyName": domainRegex(hierarchyName)})
+		if e != nil {
+			return e
+		} else if c != 0 {
+			return errDomainHasSubdomains
+		}
+		for _, entity := range domainEntities() {
+			c, e := GetDB().Collection(u.EntityToString(entity)).CountDocuments(ctx,
+				bson.M{"domain": hierarchyName})
+			if e != nil {
+				return e
+			} else if c != 0 {
+				return errDomainHasObjects
+			}
+		}
+		return nil
+	}
+	restore := func(ctx context.Context) {
+		if deleted {
+			GetDB().Collection(u.EntityToString(u.DOMAIN)).InsertOne(ctx, domain)
+		}
+	}
+
+	switch e := runTransaction(deleteUnused, restore); {
+	case e == nil:
+	case errors.Is(e, errDomainHasSubdomains), errors.Is(e, errDomainHasObjects):
+		return u.Message(false, e.Error()), "in use"
+	case isModifiedError(e) && revisions != nil:
+		return preconditionFailedMessage(), "precondition failed"
+	case isModifiedError(e):
+		return u.Message(false, "Error while deleting domain: "+e.Error()), "conflict"
+	default:
+		return u.Message(false, "Error while deleting domain: "+e.Error()), "internal"
+	}
+
+	publishEvent("deleted", u.EntityToString(u.DOMAIN), map[string]interface{}{
+		"id": id, "name": domain["name"], "hierarchyName": hierarchyName})
+	return u.Message(true, "success"), ""
+}
+
+// createMissingDomains: creates the domains (and their parents)
+// that objects or schema extensions belong to but that don't exist,
+// as they could be created before domains were checked
+func createMissingDomains(ctx context.Context) (created []string, errs []string) {
+	ensureDomainIndex()
+	names := map[string]bool{}
+	collections := []string{schemaExtensionCollection}
+	for _, entity := range domainEntities() {
+		collections = append(collections, u.EntityToString(entity))
+	}
+	for _, collection := range collections {
+		dbCtx, cancel := u.Connect()
+		values, e := GetDB().Collection(collection).Distinct(dbCtx, "domain", bson.M{})
+		cancel()
+		if e != nil {
+			errs = append(errs, collection+": "+e.Error())
+			continue
+		}
+		for _, value := range values {
+			if name, ok := value.(string); ok && name != "" {
+				names[name] = true
+			}
+		}
+	}
+
+	sorted := []string{}
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	// Parents first
+	sort.Strings(sorted)
+	created = []string{}
+	exists := map[string]bool{}
+	for _, hierarchyName := range sorted {
+		parts := strings.Split(hierarchyName, ".")
+		for i := range parts {
+			if ctx.Err() != nil {
+				return created, append(errs, ctx.Err().Error())
+			}
+			name := strings.Join(parts[:i+1], ".")
+			if exists[name] {
+				continue
+			}
+			exists[name] = true
+			if d, _ := GetEntity(bson.M{"hierarchyName": name}, u.EntityToString(u.DOMAIN),
+				u.RequestFilters{FieldsToShow: []string{"hierarchyName"}}); d != nil {
+				continue
+			}
+			domain := map[string]interface{}{
+				"name":        parts[i],
+				"category":    "domain",
+				"description": []interface{}{"Created for the objects already in it"},
+				"attributes":  map[string]interface{}{"color": "FFFFFF"},
+			}
+			if i > 0 {
+				domain["parentId"] = strings.Join(parts[:i], ".")
+			}
+			if resp, e := CreateEntity(u.DOMAIN, domain); e != "" {
+				msg, _ := resp["message"].(string)
+				errs = append(errs, name+": "+msg)
+				continue
+			}
+			created = append(created, name)
+		}
+	}
+	return created, errs
+}
+
+// CreateMissingDomains: createMissingDomains at startup, the
+// "domains" job does it again on demand
+func CreateMissingDomains() {
+	created, errs := createMissingDomains(context.Background())
+	if len(created) > 0 {
+		println("Created missing domains:", strings.Join(created, ", "))
+	}
+	for _, e := range errs {
+		println("Unable to create missing domain", e)
+	}
+}
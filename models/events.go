@@ -0,0 +1,48 @@
+package models
+
+import (
+	"sync"
+)
+
+// ObjectEvent: an object was created, updated or deleted.
+// For deletions, data only holds what identifies the object
+type ObjectEvent struct {
+	Type   string
+	Entity string
+	Data   map[string]interface{}
+}
+
+var (
+	eventsMutex sync.Mutex
+	subscribers = map[chan ObjectEvent]bool{}
+)
+
+// SubscribeEvents: events are sent on the returned channel until
+// the returned function is called. A subscriber too slow to read
+// them misses events instead of blocking writes
+func SubscribeEvents() (<-chan ObjectEvent, func()) {
+	events := make(chan ObjectEvent, 100)
+	eventsMutex.Lock()
+	subscribers[events] = true
+	eventsMutex.Unlock()
+
+	return events, func() {
+		eventsMutex.Lock()
+		if subscribers[events] {
+			delete(subscribers, events)
+			close(events)
+		}
+		eventsMutex.Unlock()
+	}
+}
+
+func publishEvent(eventType, entity string, data map[string]interface{}) {
+	eventsMutex.Lock()
+	defer eventsMutex.Unlock()
+	for events := range subscribers {
+		select {
+		case events <- ObjectEvent{eventType, entity, data}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package models
+
+import (
+	"context"
+	u "p3/utils"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// historyCollection: snapshots of the revisions of the objects,
+// to compare objects as they were at a date
+const historyCollection = "history"
+
+// hasHistory: the revisions of the objects of the entity are
+// recorded, as for objects of the hierarchy (those with a domain)
+func hasHistory(entity int) bool {
+	return hasDomain(entity)
+}
+
+// EnsureHistoryIndexes: creates the indexes used to record the
+// snapshots and to find the objects that were under a name
+func EnsureHistoryIndexes() {
+	ctx, cancel := u.Connect()
+	defer cancel()
+	_, e := GetDB().Collection(historyCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "objectId", Value: 1}, {Key: "revision", Value: 1}},
+			Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "hierarchyName", Value: 1}}},
+	})
+	if e != nil {
+		println("Unable to create history indexes:", e.Error())
+	}
+}
+
+// documentID: id of a document, as stored (_id) or as returned (id)
+func documentID(doc map[string]interface{}) interface{} {
+	if id, ok := doc["_id"]; ok {
+		return id
+	}
+	return doc["id"]
+}
+
+// lastUpdate: date since when a document is as it is
+func lastUpdate(doc map[string]interface{}) time.Time {
+	for _, field := range []string{"lastUpdated", "createdDate"} {
+		if date, ok := doc[field].(primitive.DateTime); ok {
+			return date.Time()
+		}
+	}
+	return time.Time{}
+}
+
+// snapshotWrite: stores doc as the given revision of its object,
+// a snapshot already stored for that revision is kept
+func snapshotWrite(collection string, doc map[string]interface{}, revision int64,
+	date time.Time, deleted bool) mongo.WriteModel {
+	snapshot := bson.M{
+		"collection":    collection,
+		"hierarchyName": getHierarchyName(doc),
+		"date":          primitive.NewDateTimeFromTime(date),
+		"deleted":       deleted,
+	}
+	if !deleted {
+		object := bson.M{}
+		for key, value := range doc {
+			if key != "_id" && key != "id" {
+				object[key] = value
+			}
+		}
+		snapshot["object"] = object
+	}
+	return mongo.NewUpdateOneModel().
+		SetFilter(bson.M{"objectId": documentID(doc), "revision": revision}).
+		SetUpdate(bson.M{"$setOnInsert": snapshot}).
+		SetUpsert(true)
+}
+
+// recordHistory: saves the snapshots of the objects of collection
+// written by a request. before holds the documents as they were, each
+// is saved as of its last update if its revision has no snapshot yet
+// (objects not written since history is recorded). after holds the
+// documents written, those of before missing from it were deleted
+func recordHistory(collection string, before, after []map[string]interface{}) {
+	if !hasHistory(u.EntityStrToInt(collection)) || len(before)+len(after) == 0 {
+		return
+	}
+	now := time.Now()
+	written := map[interface{}]bool{}
+	writes := []mongo.WriteModel{}
+	for _, doc := range after {
+		written[documentID(doc)] = true
+		writes = append(writes, snapshotWrite(collection, doc, GetRevision(doc), now, false))
+	}
+	for _, doc := range before {
+		writes = append(writes, snapshotWrite(collection, doc, GetRevision(doc), lastUpdate(doc), false))
+		if !written[documentID(doc)] {
+			writes = append(writes, snapshotWrite(collection, doc, GetRevision(doc)+1, now, true))
+		}
+	}
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+	_, e := GetDB().Collection(historyCollection).BulkWrite(ctx, writes,
+		options.BulkWrite().SetOrdered(false))
+	// A concurrent request may have saved the same revision
+	if e != nil && !strings.Contains(e.Error(), "E11000") {
+		println("Unable to record the history of "+collection+":", e.Error())
+	}
+}
+
+// updateObjects: updates the objects of collection matching filter
+// with the stages of update, giving them a new revision that is
+// recorded in their history
+func updateObjects(ctx context.Context, collection string, filter bson.M, update mongo.Pipeline) error {
+	if !hasHistory(u.EntityStrToInt(collection)) {
+		_, e := GetDB().Collection(collection).UpdateMany(ctx, filter, update)
+		return e
+	}
+	before, e := findDocuments(collection, filter)
+	if e != nil || len(before) == 0 {
+		return e
+	}
+	ids := bson.A{}
+	for _, doc := range before {
+		ids = append(ids, doc["_id"])
+	}
+	update = append(append(mongo.Pipeline{}, update...), bson.D{{
+		Key: "$set", Value: bson.M{
+			"revision": bson.M{"$add": bson.A{
+				bson.M{"$ifNull": bson.A{"$revision", int64(0)}}, int64(1)}}}}})
+	_, e = GetDB().Collection(collection).UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, update)
+	if e != nil {
+		return e
+	}
+	after, e := findDocuments(collection, bson.M{"_id": bson.M{"$in": ids}})
+	if e != nil {
+		return e
+	}
+	recordHistory(collection, before, after)
+	return nil
+}
+
+// historyBefore: the documents changed by $set changes as they are
+// before the changes, the other changes hold whole documents
+func historyBefore(changes []docChange) map[string][]map[string]interface{} {
+	ids := map[string]bson.A{}
+	for _, change := range changes {
+		if change.old != nil && change.new != nil && !change.replace &&
+			hasHistory(u.EntityStrToInt(change.collection)) {
+			ids[change.collection] = append(ids[change.collection], change.id)
+		}
+	}
+	before := map[string][]map[string]interface{}{}
+	for collection, collectionIds := range ids {
+		before[collection], _ = findDocuments(collection, bson.M{"_id": bson.M{"$in": collectionIds}})
+	}
+	return before
+}
+
+// recordChanges: records the history of the documents written by
+// changes, before being the result of historyBefore
+func recordChanges(changes []docChange, before map[string][]map[string]interface{}) {
+	written := map[string]bson.A{}
+	for _, change := range changes {
+		if !hasHistory(u.EntityStrToInt(change.collection)) {
+			continue
+		}
+		if change.old != nil && (change.replace || change.new == nil) {
+			before[change.collection] = append(before[change.collection], change.old)
+		}
+		if change.new != nil {
+			written[change.collection] = append(written[change.collection], change.id)
+		}
+	}
+	for collection := range before {
+		if _, ok := written[collection]; !ok {
+			written[collection] = nil
+		}
+	}
+	for collection, ids := range written {
+		after := []map[string]interface{}{}
+		if len(ids) > 0 {
+			after, _ = findDocuments(collection, bson.M{"_id": bson.M{"$in": ids}})
+		}
+		recordHistory(collection, before[collection], after)
+	}
+}
+
+// parseDiffDate: end of the day YYYY-MM-DD, or the given
+// date and time (RFC 3339), of a name@date of DiffObjects
+func parseDiffDate(value string) (time.Time, bool) {
+	if date, e := time.Parse("2006-01-02", value); e == nil {
+		return date.Add(24*time.Hour - time.Millisecond), true
+	}
+	date, e := time.Parse(time.RFC3339, value)
+	return date, e == nil
+}
+
+// getPastDiffTree: same as getDiffTree with the objects as they
+// were at date, from the last snapshot of each one before it. The
+// objects without snapshots, not written since history is recorded,
+// are taken as they are now if they were already as they are then
+func getPastDiffTree(name string, date time.Time) (map[string]diffNode, string) {
+	ctx, cancel := u.Connect()
+	defer cancel()
+	history := GetDB().Collection(historyCollection)
+	ids, e := history.Distinct(ctx, "objectId", bson.M{"hierarchyName": primitive.Regex{
+		Pattern: "^" + regexp.QuoteMeta(name) + "(\\.|$)", Options: ""}})
+	if e != nil {
+		return nil, e.Error()
+	}
+	c, e := history.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"objectId": bson.M{"$in": append(bson.A{}, ids...)},
+			"date": bson.M{"$lte": primitive.NewDateTimeFromTime(date)}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "revision", Value: -1}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$objectId", "snapshot": bson.M{"$first": "$$ROOT"}}}},
+	})
+	if e != nil {
+		return nil, e.Error()
+	}
+	snapshots := []struct {
+		ID       interface{} `bson:"_id"`
+		Snapshot struct {
+			HierarchyName string                 `bson:"hierarchyName"`
+			Deleted       bool                   `bson:"deleted"`
+			Object        map[string]interface{} `bson:"object"`
+		} `bson:"snapshot"`
+	}{}
+	if e := c.All(ctx, &snapshots); e != nil {
+		return nil, e.Error()
+	}
+
+	tree := map[string]diffNode{}
+	for _, s := range snapshots {
+		snapshotName := s.Snapshot.HierarchyName
+		if s.Snapshot.Deleted || (snapshotName != name && !strings.HasPrefix(snapshotName, name+".")) {
+			continue
+		}
+		s.Snapshot.Object["id"] = s.ID
+		addDiffNode(s.Snapshot.Object, name, tree)
+	}
+
+	known := map[interface{}]bool{}
+	for _, id := range ids {
+		known[id] = true
+	}
+	if now, _ := getDiffTree(name); now != nil {
+		for _, node := range now {
+			if !known[node.data["id"]] && !lastUpdate(node.data).After(date) {
+				addDiffNode(node.data, name, tree)
+			}
+		}
+	}
+	if _, ok := tree[""]; !ok {
+		return nil, "not found"
+	}
+	return tree, ""
+}
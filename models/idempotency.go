@@ -0,0 +1,210 @@
+package models
+
+import (
+	"os"
+	u "p3/utils"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Create responses are stored in this collection so that
+// a request retried with the same Idempotency-Key can be replayed
+const idempotencyCollection = "idempotency_key"
+
+// A reserved key is leased to the request processing it, and the
+// lease is renewed while it runs. If the API stops before storing the
+// response, a retry takes the key over once the lease has expired
+const idempotencyLeaseTTL = 30 * time.Second
+
+var idempotencyIndexOnce sync.Once
+
+// idempotencyLeases: the keys reserved by this instance,
+// with the owner of the reservation and its renewal
+var idempotencyLeases sync.Map
+
+type idempotencyLease struct {
+	owner primitive.ObjectID
+	stop  chan struct{}
+}
+
+// getIdempotencyTTL: how long a key and its response are kept,
+// configurable with idempotency_ttl (ex: 30m, 24h) in the .env file
+func getIdempotencyTTL() time.Duration {
+	if ttl, e := time.ParseDuration(os.Getenv("idempotency_ttl")); e == nil && ttl > 0 {
+		return ttl
+	}
+	return 24 * time.Hour
+}
+
+// ensureIdempotencyIndex: expired keys are removed by mongo
+func ensureIdempotencyIndex() {
+	idempotencyIndexOnce.Do(func() {
+		ctx, cancel := u.Connect()
+		defer cancel()
+		_, e := GetDB().Collection(idempotencyCollection).Indexes().CreateOne(ctx,
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+				Options: options.Index().SetExpireAfterSeconds(0),
+			})
+		if e != nil {
+			println("Unable to create idempotency TTL index:", e.Error())
+		}
+	})
+}
+
+// ReserveIdempotencyKey: registers key for a create request whose
+// body has the hash bodyHash. If the key is new, it returns "" and the
+// request can be processed. Otherwise it returns:
+//   - "replay" with the stored response and its status code
+//   - "mismatch" if the key was used with a different body
+//   - "in progress" if the first request has not finished yet
+func ReserveIdempotencyKey(key, bodyHash string) (map[string]interface{}, int, string) {
+	ensureIdempotencyIndex()
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	now := time.Now()
+	owner := primitive.NewObjectID()
+	record := bson.M{
+		"_id":         key,
+		"bodyHash":    bodyHash,
+		"status":      0,
+		"owner":       owner,
+		"lockedUntil": primitive.NewDateTimeFromTime(now.Add(idempotencyLeaseTTL)),
+		"createdAt":   primitive.NewDateTimeFromTime(now),
+		"expiresAt":   primitive.NewDateTimeFromTime(now.Add(getIdempotencyTTL())),
+	}
+	_, e := GetDB().Collection(idempotencyCollection).InsertOne(ctx, record)
+	if e == nil {
+		leaseIdempotencyKey(key, owner)
+		return nil, 0, ""
+	}
+	if !strings.Contains(e.Error(), "E11000") {
+		return u.Message(false, "Error while checking Idempotency-Key: "+e.Error()), 0, e.Error()
+	}
+
+	// Key already used
+	existing := map[string]interface{}{}
+	e = GetDB().Collection(idempotencyCollection).FindOne(ctx, bson.M{"_id": key}).Decode(&existing)
+	if e != nil {
+		return u.Message(false, "Error while checking Idempotency-Key: "+e.Error()), 0, e.Error()
+	}
+
+	// The TTL monitor of mongo only runs every minute
+	if expires, ok := existing["expiresAt"].(primitive.DateTime); ok && expires.Time().Before(now) {
+		GetDB().Collection(idempotencyCollection).DeleteOne(ctx,
+			bson.M{"_id": key, "expiresAt": expires})
+		return ReserveIdempotencyKey(key, bodyHash)
+	}
+
+	if existing["bodyHash"] != bodyHash {
+		return u.Message(false,
+			"Idempotency-Key has already been used with a different request body"), 0, "mismatch"
+	}
+
+	status := 0
+	switch s := existing["status"].(type) {
+	case int32:
+		status = int(s)
+	case int64:
+		status = int(s)
+	}
+	if status == 0 {
+		// The request that reserved the key stopped without
+		// a response, this one takes its place
+		lockedUntil, _ := existing["lockedUntil"].(primitive.DateTime)
+		if lockedUntil.Time().Before(now) {
+			res, e := GetDB().Collection(idempotencyCollection).UpdateOne(ctx,
+				bson.M{"_id": key, "status": 0, "lockedUntil": existing["lockedUntil"]},
+				bson.M{"$set": bson.M{"owner": owner,
+					"lockedUntil": primitive.NewDateTimeFromTime(now.Add(idempotencyLeaseTTL))}})
+			if e != nil {
+				return u.Message(false, "Error while checking Idempotency-Key: "+e.Error()), 0, e.Error()
+			}
+			if res.MatchedCount == 1 {
+				leaseIdempotencyKey(key, owner)
+				return nil, 0, ""
+			}
+		}
+		return u.Message(false,
+			"A request with this Idempotency-Key is still being processed"), 0, "in progress"
+	}
+
+	resp, _ := existing["response"].(map[string]interface{})
+	return resp, status, "replay"
+}
+
+// leaseIdempotencyKey: renews the lease of a key reserved
+// by owner until its response is saved or it is released
+func leaseIdempotencyKey(key string, owner primitive.ObjectID) {
+	lease := idempotencyLease{owner, make(chan struct{})}
+	idempotencyLeases.Store(key, lease)
+	go func() {
+		ticker := time.NewTicker(idempotencyLeaseTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-lease.stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := u.Connect()
+				res, e := GetDB().Collection(idempotencyCollection).UpdateOne(ctx,
+					bson.M{"_id": key, "owner": owner, "status": 0},
+					bson.M{"$set": bson.M{"lockedUntil": primitive.NewDateTimeFromTime(
+						time.Now().Add(idempotencyLeaseTTL))}})
+				cancel()
+				if e == nil && res.MatchedCount == 0 {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// endIdempotencyLease: stops the renewal of the lease of key,
+// it returns the owner of the reservation made by this instance
+func endIdempotencyLease(key string) (primitive.ObjectID, bool) {
+	value, ok := idempotencyLeases.LoadAndDelete(key)
+	if !ok {
+		return primitive.NilObjectID, false
+	}
+	lease := value.(idempotencyLease)
+	close(lease.stop)
+	return lease.owner, true
+}
+
+// SaveIdempotentResponse: stores the response of the request
+// that reserved key, to be replayed on retries
+func SaveIdempotentResponse(key string, status int, resp map[string]interface{}) {
+	owner, ok := endIdempotencyLease(key)
+	if !ok {
+		return
+	}
+	ctx, cancel := u.Connect()
+	defer cancel()
+	_, e := GetDB().Collection(idempotencyCollection).UpdateOne(ctx,
+		bson.M{"_id": key, "owner": owner},
+		bson.M{"$set": bson.M{"status": status, "response": resp},
+			"$unset": bson.M{"lockedUntil": ""}})
+	if e != nil {
+		println("Unable to save idempotent response:", e.Error())
+	}
+}
+
+// ReleaseIdempotencyKey: forgets key so that the request can be
+// retried, used when it failed for reasons unrelated to its content
+func ReleaseIdempotencyKey(key string) {
+	owner, ok := endIdempotencyLease(key)
+	if !ok {
+		return
+	}
+	ctx, cancel := u.Connect()
+	defer cancel()
+	GetDB().Collection(idempotencyCollection).DeleteOne(ctx, bson.M{"_id": key, "owner": owner})
+}
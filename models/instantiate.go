@@ -0,0 +1,341 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	u "p3/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// formatFloat: numbers of the templates as attribute strings
+func formatFloat(x float64) string {
+	return strconv.FormatFloat(x, 'f', -1, 64)
+}
+
+// templateVector: the first n numbers of an array of a template
+// (ex: sizeWDHmm), an error if it is shorter
+func templateVector(value interface{}, n int, field string) ([]float64, error) {
+	list, _ := toSlice(value)
+	if len(list) < n {
+		return nil, errors.New("invalid " + field + " in template")
+	}
+	v := make([]float64, n)
+	for i := range v {
+		var ok bool
+		if v[i], ok = toFloat(list[i]); !ok {
+			return nil, errors.New("invalid " + field + " in template")
+		}
+	}
+	return v, nil
+}
+
+// toJSONString: arrays and objects of a template copied
+// in attributes are stored as JSON strings, as size is
+func toJSONString(value interface{}) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// setDefault: sets an attribute copied from a template,
+// unless it was given with the object
+func setDefault(attrs map[string]interface{}, key string, value interface{}) {
+	if v, ok := attrs[key]; !ok || v == "" {
+		attrs[key] = value
+	}
+}
+
+// getTemplate: the version of the template given by the template
+// attribute of an object (see validateTemplateRef)
+func getTemplate(entity int, attrs map[string]interface{}) (map[string]interface{}, error) {
+	if slug, _ := attrs["template"].(string); slug == "" {
+		return nil, errors.New("the template attribute is required to instantiate a template")
+	}
+	if resp, ok := validateTemplateRef(entity, attrs); !ok {
+		return nil, errors.New(resp["message"].(string))
+	}
+	template, e := getTemplateVersion(templateCollection(entity),
+		attrs["template"].(string), attrs["templateVersion"].(string))
+	if template == nil {
+		return nil, errors.New("Unable to get the template: " + e)
+	}
+	return template, nil
+}
+
+// applyObjTemplate: copies the size, height, model, attributes
+// and colors of an obj_template to a rack or a device
+func applyObjTemplate(attrs, template map[string]interface{}) error {
+	size, e := templateVector(template["sizeWDHmm"], 3, "sizeWDHmm")
+	if e != nil {
+		return e
+	}
+	setDefault(attrs, "size", `{"x":`+formatFloat(size[0])+`,"y":`+formatFloat(size[1])+`}`)
+	setDefault(attrs, "sizeUnit", "mm")
+	setDefault(attrs, "height", formatFloat(size[2]))
+	setDefault(attrs, "heightUnit", "mm")
+	if fbxModel, _ := template["fbxModel"].(string); fbxModel != "" {
+		setDefault(attrs, "fbxModel", fbxModel)
+	}
+	if templateAttrs, ok := template["attributes"].(map[string]interface{}); ok {
+		for key, value := range templateAttrs {
+			setDefault(attrs, key, value)
+		}
+	}
+	if colors, _ := toSlice(template["colors"]); len(colors) > 0 {
+		setDefault(attrs, "colors", toJSONString(colors))
+	}
+	return nil
+}
+
+// applyRoomTemplate: copies the size, height, floor, areas,
+// separators, tiles and colors of a room_template to a room
+func applyRoomTemplate(attrs, template map[string]interface{}) error {
+	size, e := templateVector(template["sizeWDHm"], 3, "sizeWDHm")
+	if e != nil {
+		return e
+	}
+	setDefault(attrs, "size", `{"x":`+formatFloat(size[0])+`,"y":`+formatFloat(size[1])+`}`)
+	setDefault(attrs, "sizeUnit", "m")
+	setDefault(attrs, "height", formatFloat(size[2]))
+	setDefault(attrs, "heightUnit", "m")
+	for _, key := range []string{"floorUnit", "axisOrientation"} {
+		if value, _ := template[key].(string); value != "" {
+			setDefault(attrs, key, value)
+		}
+	}
+	for _, key := range []string{"reservedArea", "technicalArea"} {
+		if area, e := templateVector(template[key], 4, key); e == nil {
+			setDefault(attrs, key, toJSONString(area))
+		}
+	}
+	for _, key := range []string{"separators", "tiles", "colors"} {
+		if list, _ := toSlice(template[key]); len(list) > 0 {
+			setDefault(attrs, key, toJSONString(list))
+		}
+	}
+	return nil
+}
+
+// resolveTemplateColor: colors of components can name
+// one of the colors of the template ("@name")
+func resolveTemplateColor(color string, template map[string]interface{}) string {
+	if !strings.HasPrefix(color, "@") {
+		return color
+	}
+	colors, _ := toSlice(template["colors"])
+	for _, c := range colors {
+		if c, ok := c.(map[string]interface{}); ok && c["name"] == color[1:] {
+			value, _ := c["value"].(string)
+			return value
+		}
+	}
+	return ""
+}
+
+// templateElements: the components and slots of an obj_template as
+// child devices of the object, named after their location. They are
+// marked with the templateElement attribute ("component" or "slot")
+func templateElements(parent, template map[string]interface{}) ([]map[string]interface{}, error) {
+	children := []map[string]interface{}{}
+	names := map[string]bool{}
+	for _, kind := range []string{"component", "slot"} {
+		list, _ := toSlice(template[kind+"s"])
+		for _, element := range list {
+			element, ok := element.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			location, _ := element["location"].(string)
+			if location == "" || names[location] {
+				return nil, errors.New("the " + kind + " location '" + location +
+					"' of the template is empty or not unique")
+			}
+			names[location] = true
+			size, e := templateVector(element["elemSize"], 3, "elemSize of "+location)
+			if e != nil {
+				return nil, e
+			}
+
+			attrs := map[string]interface{}{}
+			if elementAttrs, ok := element["attributes"].(map[string]interface{}); ok {
+				for key, value := range elementAttrs {
+					attrs[key] = value
+				}
+			}
+			attrs["templateElement"] = kind
+			attrs["template"] = ""
+			attrs["type"], _ = element["type"].(string)
+			attrs["orientation"] = "front"
+			attrs["size"] = `{"x":` + formatFloat(size[0]) + `,"y":` + formatFloat(size[1]) + `}`
+			attrs["sizeUnit"] = "mm"
+			attrs["height"] = formatFloat(size[2])
+			attrs["heightUnit"] = "mm"
+			// Position in mm in the parent, as in the template
+			if pos, e := templateVector(element["elemPos"], 3, "elemPos"); e == nil {
+				attrs["posXYZ"] = `{"x":` + formatFloat(pos[0]) + `,"y":` +
+					formatFloat(pos[1]) + `,"z":` + formatFloat(pos[2]) + `}`
+			}
+			if color, _ := element["color"].(string); color != "" {
+				attrs["color"] = resolveTemplateColor(color, template)
+			}
+
+			children = append(children, map[string]interface{}{
+				"category":    "device",
+				"name":        location,
+				"description": []interface{}{},
+				"domain":      parent["domain"],
+				"parentId":    parent["_id"].(primitive.ObjectID).Hex(),
+				"attributes":  attrs,
+			})
+		}
+	}
+	return children, nil
+}
+
+// isTemplateElement: the device is a component or a slot
+// created with its parent from the template of the parent
+func isTemplateElement(device map[string]interface{}) bool {
+	attrs, _ := device["attributes"].(map[string]interface{})
+	kind, _ := attrs["templateElement"].(string)
+	return kind != ""
+}
+
+// checkElementPlacement: a component or a slot should be
+// inside its parent, its posXYZ is in mm in the parent
+func checkElementPlacement(element, parent map[string]interface{}) (map[string]interface{}, bool) {
+	attrs, _ := element["attributes"].(map[string]interface{})
+	posXYZ, _ := attrs["posXYZ"].(string)
+	if posXYZ == "" {
+		return nil, true
+	}
+	pos, e := u.ParseVector(posXYZ)
+	if e != nil {
+		return u.Message(false, "Invalid posXYZ: "+e.Error()), false
+	}
+	size, e := getDeviceSizeMM(element)
+	if e != nil {
+		return u.Message(false, "Unable to get the size of the element: "+e.Error()), false
+	}
+	parentSize, e := getDeviceSizeMM(parent)
+	if e != nil {
+		return u.Message(false, "Unable to get the size of the parent: "+e.Error()), false
+	}
+	if pos.X < -mmEpsilon || pos.Y < -mmEpsilon || pos.Z < -mmEpsilon ||
+		pos.X+size.X > parentSize.X+mmEpsilon ||
+		pos.Y+size.Y > parentSize.Y+mmEpsilon ||
+		pos.Z+size.Z > parentSize.Z+mmEpsilon {
+		return u.Message(false, "The element does not fit in "+getHierarchyName(parent)), false
+	}
+	return nil, true
+}
+
+// InstantiateEntity: creates a room, a rack or a device from its
+// template. Its size, height and colors (and areas, separators and
+// tiles for rooms) are copied from the template, the components and
+// slots of obj_templates are created as child devices. Everything is
+// validated before anything is created
+func InstantiateEntity(entity int, t map[string]interface{}) (map[string]interface{}, string) {
+	if entity != u.ROOM && entity != u.RACK && entity != u.DEVICE {
+		return u.Message(false, "Only rooms, racks and devices can be instantiated from a template"), "validate"
+	}
+	attrs, ok := t["attributes"].(map[string]interface{})
+	if !ok {
+		return u.Message(false, "Attributes should be a JSON Dictionary"), "validate"
+	}
+	template, err := getTemplate(entity, attrs)
+	if err != nil {
+		return u.Message(false, err.Error()), "validate"
+	}
+	if entity == u.ROOM {
+		err = applyRoomTemplate(attrs, template)
+	} else {
+		err = applyObjTemplate(attrs, template)
+	}
+	if err != nil {
+		return u.Message(false, err.Error()), "validate"
+	}
+
+	if entity == u.DEVICE {
+		unlock, resp, e := lockDeviceRack(t)
+		if e != "" {
+			return resp, e
+		}
+		defer unlock()
+	}
+	if hasFootprint(entity) {
+		unlock, resp, e := lockObjectRoom(entity, t)
+		if e != "" {
+			return resp, e
+		}
+		defer unlock()
+	}
+	if resp, ok := ValidateEntity(entity, t); !ok {
+		return resp, "validate"
+	}
+	if entity == u.DEVICE {
+		if resp, ok := validateDevicePlacement(t, nil); !ok {
+			return resp, "validate"
+		}
+	}
+	if resp, ok := validateRoomPlacement(entity, t, nil); !ok {
+		return resp, "validate"
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	t["_id"] = primitive.NewObjectID()
+	t["createdDate"] = now
+	t["lastUpdated"] = now
+	t["revision"] = int64(1)
+	entStr := u.EntityToString(entity)
+	changes := []docChange{{collection: entStr, id: t["_id"].(primitive.ObjectID), new: t}}
+
+	if entity != u.ROOM {
+		children, err := templateElements(t, template)
+		if err != nil {
+			return u.Message(false, err.Error()), "validate"
+		}
+		for _, child := range children {
+			// The parent is not created yet, the
+			// children are checked against t
+			resp, ok := validateEntity(u.DEVICE, child, t)
+			if ok {
+				resp, ok = checkElementPlacement(child, t)
+			}
+			if !ok {
+				resp["message"] = "Template element " + child["name"].(string) +
+					": " + resp["message"].(string)
+				return resp, "validate"
+			}
+			child["_id"] = primitive.NewObjectID()
+			child["createdDate"] = now
+			child["lastUpdated"] = now
+			child["revision"] = int64(1)
+			changes = append(changes, docChange{collection: "device",
+				id: child["_id"].(primitive.ObjectID), new: child})
+		}
+	}
+
+	if err := applyChanges(changes); err != nil {
+		if strings.Contains(err.Error(), "E11000") {
+			return u.Message(false, "Error while creating "+entStr+": Duplicates not allowed"), "duplicate"
+		}
+		return u.Message(false, "Internal error while creating "+entStr+": "+err.Error()), err.Error()
+	}
+
+	created := []interface{}{}
+	for _, change := range changes[1:] {
+		created = append(created, map[string]interface{}{
+			"id": change.id, "name": change.new["name"], "hierarchyName": change.new["hierarchyName"]})
+	}
+	for _, change := range changes {
+		publishEvent("created", change.collection, fixID(change.new))
+	}
+
+	resp := u.Message(true, "successfully created object")
+	t["children"] = created
+	resp["data"] = t
+	return resp, ""
+}
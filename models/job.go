@@ -0,0 +1,250 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	u "p3/utils"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Long operations are run as jobs by a bounded pool of workers,
+// their state is persisted in this collection
+const jobCollection = "job"
+
+const (
+	JOBPENDING   = "pending"
+	JOBRUNNING   = "running"
+	JOBDONE      = "done"
+	JOBFAILED    = "failed"
+	JOBCANCELLED = "cancelled"
+)
+
+// JobHandler: runs a job, it should regularly check ctx to stop
+// when the job is cancelled and report its progress on job
+type JobHandler func(ctx context.Context, job *Job) (interface{}, error)
+
+type Job struct {
+	ID     primitive.ObjectID
+	Type   string
+	Params map[string]interface{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	errors []string
+}
+
+var jobHandlers = map[string]JobHandler{}
+
+var (
+	jobQueue      chan *Job
+	jobRunnerOnce sync.Once
+	jobsMutex     sync.Mutex
+	activeJobs    = map[primitive.ObjectID]*Job{}
+)
+
+// RegisterJobHandler: makes jobType available to SubmitJob
+func RegisterJobHandler(jobType string, handler JobHandler) {
+	jobHandlers[jobType] = handler
+}
+
+// StartJobRunner: starts the workers, their number is given
+// by job_workers in the .env file (default 4). Jobs left pending
+// by a previous run are queued again, running ones are failed
+func StartJobRunner() {
+	jobRunnerOnce.Do(func() {
+		workers, e := strconv.Atoi(os.Getenv("job_workers"))
+		if e != nil || workers <= 0 {
+			workers = 4
+		}
+		jobQueue = make(chan *Job, 100*workers)
+		for i := 0; i < workers; i++ {
+			go jobWorker()
+		}
+		recoverJobs()
+	})
+}
+
+func recoverJobs() {
+	ctx, cancel := u.Connect()
+	defer cancel()
+	GetDB().Collection(jobCollection).UpdateMany(ctx,
+		bson.M{"status": JOBRUNNING},
+		bson.M{"$set": bson.M{"status": JOBFAILED,
+			"errors":      bson.A{"Job interrupted by an API restart"},
+			"lastUpdated": primitive.NewDateTimeFromTime(time.Now())}})
+
+	c, e := GetDB().Collection(jobCollection).Find(ctx, bson.M{"status": JOBPENDING})
+	if e != nil {
+		println("Unable to recover pending jobs:", e.Error())
+		return
+	}
+	pending, _ := ExtractCursor(c, ctx)
+	for _, data := range pending {
+		params, _ := data["params"].(map[string]interface{})
+		job := newJob(data["id"].(primitive.ObjectID), data["type"].(string), params)
+		select {
+		case jobQueue <- job:
+		default:
+			updateJob(job.ID, bson.M{"status": JOBFAILED,
+				"errors": bson.A{"Job queue is full"}})
+		}
+	}
+}
+
+func newJob(id primitive.ObjectID, jobType string, params map[string]interface{}) *Job {
+	job := &Job{ID: id, Type: jobType, Params: params, errors: []string{}}
+	job.ctx, job.cancel = context.WithCancel(context.Background())
+	jobsMutex.Lock()
+	activeJobs[id] = job
+	jobsMutex.Unlock()
+	return job
+}
+
+func jobWorker() {
+	for job := range jobQueue {
+		runJob(job)
+	}
+}
+
+func runJob(job *Job) {
+	defer func() {
+		jobsMutex.Lock()
+		delete(activeJobs, job.ID)
+		jobsMutex.Unlock()
+		job.cancel()
+	}()
+
+	// Cancelled while waiting in the queue
+	if job.ctx.Err() != nil {
+		return
+	}
+	updateJob(job.ID, bson.M{"status": JOBRUNNING,
+		"startDate": primitive.NewDateTimeFromTime(time.Now())})
+
+	result, e := runJobHandler(job)
+	update := bson.M{"result": result, "errors": job.errors,
+		"endDate": primitive.NewDateTimeFromTime(time.Now())}
+	switch {
+	case job.ctx.Err() != nil:
+		update["status"] = JOBCANCELLED
+	case e != nil:
+		update["status"] = JOBFAILED
+		update["errors"] = append(job.errors, e.Error())
+	default:
+		update["status"] = JOBDONE
+	}
+	updateJob(job.ID, update)
+}
+
+// runJobHandler: a panicking job should not kill its worker
+func runJobHandler(job *Job) (result interface{}, e error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e = errors.New("job crashed: " + fmt.Sprint(r))
+		}
+	}()
+	return jobHandlers[job.Type](job.ctx, job)
+}
+
+func updateJob(id primitive.ObjectID, set bson.M) {
+	set["lastUpdated"] = primitive.NewDateTimeFromTime(time.Now())
+	ctx, cancel := u.Connect()
+	defer cancel()
+	_, e := GetDB().Collection(jobCollection).UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	if e != nil {
+		println("Unable to update job", id.Hex(), ":", e.Error())
+	}
+}
+
+// SetProgress: reports that done out of total steps are finished
+func (job *Job) SetProgress(done, total int) {
+	updateJob(job.ID, bson.M{"progress": bson.M{"done": done, "total": total}})
+}
+
+// AddError: records a non fatal error, the job goes on
+func (job *Job) AddError(message string) {
+	job.errors = append(job.errors, message)
+}
+
+// SubmitJob: persists a new job of type jobType and queues it
+func SubmitJob(jobType string, params map[string]interface{}, user string) (map[string]interface{}, string) {
+	if _, ok := jobHandlers[jobType]; !ok {
+		return u.Message(false, "Unknown job type: "+jobType), "invalid"
+	}
+	StartJobRunner()
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	data := map[string]interface{}{
+		"_id":         primitive.NewObjectID(),
+		"type":        jobType,
+		"params":      params,
+		"status":      JOBPENDING,
+		"progress":    bson.M{"done": 0, "total": 0},
+		"errors":      bson.A{},
+		"user":        user,
+		"createdDate": now,
+		"lastUpdated": now,
+	}
+	ctx, cancel := u.Connect()
+	defer cancel()
+	if _, e := GetDB().Collection(jobCollection).InsertOne(ctx, data); e != nil {
+		return u.Message(false, "Internal error while creating job: "+e.Error()), e.Error()
+	}
+
+	job := newJob(data["_id"].(primitive.ObjectID), jobType, params)
+	select {
+	case jobQueue <- job:
+	default:
+		job.cancel()
+		jobsMutex.Lock()
+		delete(activeJobs, job.ID)
+		jobsMutex.Unlock()
+		GetDB().Collection(jobCollection).DeleteOne(ctx, bson.M{"_id": job.ID})
+		return u.Message(false, "Too many jobs waiting, please retry later"), "busy"
+	}
+
+	resp := u.Message(true, "successfully submitted job")
+	resp["data"] = fixID(data)
+	return resp, ""
+}
+
+// GetJob: a job submitted by user, admins can see
+// all the jobs. Other jobs are not found
+func GetJob(id primitive.ObjectID, user string) (map[string]interface{}, string) {
+	req := bson.M{"_id": id}
+	if !IsAdmin(user) {
+		req["user"] = user
+	}
+	return GetEntity(req, jobCollection, u.RequestFilters{})
+}
+
+// CancelJob: stops a pending or running job submitted by user
+// (any job for admins)
+func CancelJob(id primitive.ObjectID, user string) (map[string]interface{}, string) {
+	data, e := GetJob(id, user)
+	if e != "" {
+		return u.Message(false, "Error while getting job: "+e), e
+	}
+	if data["status"] != JOBPENDING && data["status"] != JOBRUNNING {
+		return u.Message(false, "Job is already "+data["status"].(string)), "finished"
+	}
+
+	jobsMutex.Lock()
+	job, ok := activeJobs[id]
+	jobsMutex.Unlock()
+	if ok {
+		job.cancel()
+	}
+	if !ok || data["status"] == JOBPENDING {
+		// No worker will update it
+		updateJob(id, bson.M{"status": JOBCANCELLED})
+	}
+	return u.Message(true, "successfully cancelled job"), ""
+}
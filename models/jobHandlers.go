@@ -0,0 +1,221 @@
+package models
+
+import (
+	"context"
+	"errors"
+	u "p3/utils"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	RegisterJobHandler("delete", deleteJob)
+	RegisterJobHandler("import", importJob)
+	RegisterJobHandler("hierarchyNames", hierarchyNamesJob)
+	RegisterJobHandler("domains", domainsJob)
+}
+
+// GetChildCollections: collections where direct children
+// of an object of the given entity may be found
+func GetChildCollections(entity int) []int {
+	switch entity {
+	case u.TENANT, u.SITE:
+		return []int{entity + 1}
+	case u.BLDG:
+		return []int{u.ROOM, u.SENSOR, u.GROUP}
+	case u.ROOM:
+		return []int{u.RACK, u.AC, u.PWRPNL, u.CABINET, u.CORRIDOR, u.SENSOR, u.GROUP}
+	case u.RACK, u.DEVICE:
+		return []int{u.DEVICE, u.SENSOR, u.GROUP}
+	case u.STRAYDEV:
+		return []int{u.STRAYDEV, u.STRAYSENSOR}
+	default:
+		return []int{}
+	}
+}
+
+// toSlice: job params may come from JSON or from mongo
+func toSlice(x interface{}) ([]interface{}, bool) {
+	switch v := x.(type) {
+	case []interface{}:
+		return v, true
+	case primitive.A:
+		return []interface{}(v), true
+	}
+	return nil, false
+}
+
+// deleteJob: deletes an object given by params "entity" and
+// "name" (hierarchyName, tenant name or template slug) as a DELETE
+// request does, collection after collection so it can be followed
+// and cancelled. Param "ifMatch" is the If-Match header of the request
+func deleteJob(ctx context.Context, job *Job) (interface{}, error) {
+	entity, _ := job.Params["entity"].(string)
+	name, _ := job.Params["name"].(string)
+	entInt := u.EntityStrToInt(entity)
+	if entInt < 0 || name == "" {
+		return nil, errors.New("params 'entity' and 'name' are required")
+	}
+
+	var req bson.M
+	if strings.Contains(entity, "template") {
+		req = bson.M{"slug": name}
+	} else if entInt == u.TENANT {
+		req = bson.M{"name": name}
+	} else {
+		req = bson.M{"hierarchyName": name}
+	}
+
+	ifMatch, _ := job.Params["ifMatch"].(string)
+	revisions := u.ParseETags(ifMatch)
+
+	// Templates still used are only deleted with force
+	if strings.Contains(entity, "template") {
+		force, _ := job.Params["force"].(bool)
+		if resp, e := DeleteTemplate(entInt, req, force, revisions); e != "" {
+			return resp["data"], errors.New(resp["message"].(string))
+		}
+		return bson.M{"deleted": map[string]int64{entity: 1}}, nil
+	}
+
+	deleted, errs, e := deleteObjectTree(ctx, entity, req, revisions, job.SetProgress)
+	for _, err := range errs {
+		job.AddError(err)
+	}
+	switch {
+	case e == "":
+		return bson.M{"deleted": deleted}, nil
+	case e == "not found":
+		return nil, errors.New("No " + entity + " found with name " + name)
+	case e == "precondition failed":
+		return nil, errors.New(preconditionFailedMessage()["message"].(string))
+	case ctx.Err() != nil:
+		return deleted, ctx.Err()
+	default:
+		return deleted, errors.New(e)
+	}
+}
+
+// importJob: creates params "objects", a list of
+// {"entity": "rack", "data": {...}}, in the given order
+// so that parents can be created before their children
+func importJob(ctx context.Context, job *Job) (interface{}, error) {
+	objects, ok := toSlice(job.Params["objects"])
+	if !ok {
+		return nil, errors.New("param 'objects' should be a list")
+	}
+
+	created := []interface{}{}
+	for i, objInf := range objects {
+		if ctx.Err() != nil {
+			return bson.M{"created": created}, ctx.Err()
+		}
+		if i%50 == 0 {
+			job.SetProgress(i, len(objects))
+		}
+
+		obj, _ := objInf.(map[string]interface{})
+		entity, _ := obj["entity"].(string)
+		data, ok := obj["data"].(map[string]interface{})
+		entInt := u.EntityStrToInt(strings.Replace(entity, "-", "_", 1))
+		if entInt < 0 || !ok {
+			job.AddError("object " + strconv.Itoa(i) + ": invalid entity or data")
+			continue
+		}
+		delete(data, "id")
+		resp, e := CreateEntity(entInt, data)
+		if e != "" {
+			msg, _ := resp["message"].(string)
+			job.AddError("object " + strconv.Itoa(i) + ": " + msg)
+			continue
+		}
+		created = append(created, resp["data"].(map[string]interface{})["id"])
+	}
+	job.SetProgress(len(objects), len(objects))
+	return bson.M{"created": created}, nil
+}
+
+// hierarchyNamesJob: recomputes the hierarchyName of every object
+// from its parentId, starting at the tenants (or at param "tenant")
+func hierarchyNamesJob(ctx context.Context, job *Job) (interface{}, error) {
+	req := bson.M{}
+	if tenant, ok := job.Params["tenant"].(string); ok && tenant != "" {
+		req["name"] = tenant
+	}
+
+	total := 0
+	for i := u.TENANT; i <= u.GROUP; i++ {
+		total += int(GetEntityCount(i))
+	}
+
+	type node struct {
+		entity        int
+		id            string
+		hierarchyName string
+	}
+	tenants, e := GetManyEntities("tenant", req, u.RequestFilters{})
+	if e != "" {
+		return nil, errors.New(e)
+	}
+	queue := []node{}
+	for _, tenant := range tenants {
+		queue = append(queue, node{u.TENANT,
+			tenant["id"].(primitive.ObjectID).Hex(), tenant["name"].(string)})
+	}
+
+	done, updated := 0, 0
+	for len(queue) > 0 {
+		if ctx.Err() != nil {
+			return bson.M{"updated": updated}, ctx.Err()
+		}
+		parent := queue[0]
+		queue = queue[1:]
+		done++
+		if done%100 == 0 {
+			job.SetProgress(done, total)
+		}
+
+		for _, childEnt := range GetChildCollections(parent.entity) {
+			childEntName := u.EntityToString(childEnt)
+			children, e := GetManyEntities(childEntName, bson.M{"parentId": parent.id},
+				u.RequestFilters{FieldsToShow: []string{"name", "hierarchyName"}})
+			if e != "" {
+				job.AddError(childEntName + ": " + e)
+				continue
+			}
+			for _, child := range children {
+				id := child["id"].(primitive.ObjectID)
+				name, _ := child["name"].(string)
+				hierarchyName := parent.hierarchyName + "." + name
+				if child["hierarchyName"] != hierarchyName {
+					dbCtx, cancel := u.Connect()
+					e := updateObjects(dbCtx, childEntName, bson.M{"_id": id},
+						mongo.Pipeline{{{Key: "$set", Value: bson.M{"hierarchyName": hierarchyName}}}})
+					cancel()
+					if e != nil {
+						job.AddError(hierarchyName + ": " + e.Error())
+					} else {
+						updated++
+					}
+				}
+				queue = append(queue, node{childEnt, id.Hex(), hierarchyName})
+			}
+		}
+	}
+	job.SetProgress(done, total)
+	return bson.M{"updated": updated}, nil
+}
+
+// domainsJob: creates the domains objects belong to but that
+// don't exist, as objects could be created before domains were checked
+func domainsJob(ctx context.Context, job *Job) (interface{}, error) {
+	created, errs := createMissingDomains(ctx)
+	for _, err := range errs {
+		job.AddError(err)
+	}
+	return bson.M{"created": created}, ctx.Err()
+}
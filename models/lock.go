@@ -0,0 +1,117 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	u "p3/utils"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Locks shared by all the instances of the API
+// using the same database, one document per lock
+const lockCollection = "lock"
+
+// A lock whose owner died is taken again after this delay,
+// the lock is renewed while it is held
+const lockTTL = 10 * time.Second
+
+// errLockTimeout: the lock is held by another request
+var errLockTimeout = errors.New("timeout while waiting for lock")
+
+// acquireLock: takes the lock key, waiting at most timeout for it.
+// It returns the function that releases the lock
+func acquireLock(key string, timeout time.Duration) (func(), error) {
+	owner := primitive.NewObjectID()
+	deadline := time.Now().Add(timeout)
+	for wait := 10 * time.Millisecond; ; wait *= 2 {
+		ctx, cancel := u.Connect()
+		now := time.Now()
+		// Only matches an expired lock, otherwise the upsert
+		// fails because the lock key already exists
+		_, e := GetDB().Collection(lockCollection).UpdateOne(ctx,
+			bson.M{"_id": key, "expiresAt": bson.M{"$lt": primitive.NewDateTimeFromTime(now)}},
+			bson.M{"$set": bson.M{"owner": owner,
+				"expiresAt": primitive.NewDateTimeFromTime(now.Add(lockTTL))}},
+			options.Update().SetUpsert(true))
+		cancel()
+		if e == nil {
+			stop := make(chan struct{})
+			go renewLock(key, owner, stop)
+			var once sync.Once
+			return func() {
+				once.Do(func() {
+					close(stop)
+					ctx, cancel := u.Connect()
+					defer cancel()
+					GetDB().Collection(lockCollection).DeleteOne(ctx, bson.M{"_id": key, "owner": owner})
+				})
+			}, nil
+		}
+		if !strings.Contains(e.Error(), "E11000") {
+			return nil, e
+		}
+		if time.Now().Add(wait).After(deadline) {
+			return nil, fmt.Errorf("%w %s", errLockTimeout, key)
+		}
+		if wait > 200*time.Millisecond {
+			wait = 200 * time.Millisecond
+		}
+		time.Sleep(wait)
+	}
+}
+
+// renewLock: pushes back the expiry of a held lock until stop is
+// closed, so that a long operation keeps the lock it took
+func renewLock(key string, owner primitive.ObjectID, stop chan struct{}) {
+	ticker := time.NewTicker(lockTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := u.Connect()
+			res, e := GetDB().Collection(lockCollection).UpdateOne(ctx,
+				bson.M{"_id": key, "owner": owner},
+				bson.M{"$set": bson.M{"expiresAt": primitive.NewDateTimeFromTime(time.Now().Add(lockTTL))}})
+			cancel()
+			if e == nil && res.MatchedCount == 0 {
+				println("Lock " + key + " lost")
+				return
+			}
+		}
+	}
+}
+
+// acquireLocks: takes several locks, always in the same order so
+// that two requests can't wait for each other. It returns the
+// function that releases all of them
+func acquireLocks(keys []string, timeout time.Duration) (func(), error) {
+	sorted := append([]string{}, keys...)
+	sort.Strings(sorted)
+	unlocks := []func(){}
+	unlockAll := func() {
+		for i := len(unlocks) - 1; i >= 0; i-- {
+			unlocks[i]()
+		}
+	}
+	for i, key := range sorted {
+		if i > 0 && key == sorted[i-1] {
+			continue
+		}
+		unlock, e := acquireLock(key, timeout)
+		if e != nil {
+			unlockAll()
+			return nil, e
+		}
+		unlocks = append(unlocks, unlock)
+	}
+	return unlockAll, nil
+}
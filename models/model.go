@@ -2,8 +2,10 @@ package models
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	u "p3/utils"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -17,13 +19,39 @@ import (
 
 func CreateEntity(entity int, t map[string]interface{}) (map[string]interface{}, string) {
 	message := ""
+	if entity == u.DEVICE {
+		unlock, resp, e := lockDeviceRack(t)
+		if e != "" {
+			return resp, e
+		}
+		defer unlock()
+	}
+	if hasFootprint(entity) {
+		unlock, resp, e := lockObjectRoom(entity, t)
+		if e != "" {
+			return resp, e
+		}
+		defer unlock()
+	}
 	if resp, ok := ValidateEntity(entity, t); !ok {
 		return resp, "validate"
 	}
+	if entity == u.DEVICE {
+		if resp, ok := validateDevicePlacement(t, nil); !ok {
+			return resp, "validate"
+		}
+	}
+	if resp, ok := validateRoomPlacement(entity, t, nil); !ok {
+		return resp, "validate"
+	}
+	if isTemplate(entity) {
+		t["version"] = int64(1)
+	}
 
-	//Set timestamp
+	//Set timestamp and first revision
 	t["createdDate"] = primitive.NewDateTimeFromTime(time.Now())
 	t["lastUpdated"] = t["createdDate"]
+	t["revision"] = int64(1)
 
 	ctx, cancel := u.Connect()
 	entStr := u.EntityToString(entity)
@@ -41,6 +69,15 @@ func CreateEntity(entity int, t map[string]interface{}) (map[string]interface{},
 	defer cancel()
 
 	t["id"] = res.InsertedID
+	recordHistory(entStr, nil, []map[string]interface{}{t})
+	if isTemplate(entity) {
+		if e := saveTemplateVersion(entStr, t); e != nil {
+			return u.Message(false,
+					"Internal error while saving the version of "+entStr+": "+e.Error()),
+				e.Error()
+		}
+	}
+	publishEvent("created", entStr, t)
 
 	switch entity {
 	case u.ROOMTMPL:
@@ -85,6 +122,38 @@ func GetObjectByName(hierarchyName string, filters u.RequestFilters) (map[string
 	}
 }
 
+// GetObjectsByPath: search in all collections of the hierarchy and stray
+// objects for hierarchyNames (or names, if not set) matching a path pattern,
+// optionally only for some categories
+func GetObjectsByPath(path string, categories []string, filters u.RequestFilters) ([]map[string]interface{}, string) {
+	regex, e := u.PathPatternToRegex(path)
+	if e != nil {
+		return nil, e.Error()
+	}
+	pattern := primitive.Regex{Pattern: regex, Options: ""}
+
+	objects := []map[string]interface{}{}
+	for entity := u.TENANT; entity <= u.STRAYSENSOR; entity++ {
+		if entity >= u.ROOMTMPL && entity <= u.BLDGTMPL {
+			continue
+		}
+		req := bson.M{"hierarchyName": pattern}
+		if entity == u.TENANT || entity == u.STRAYDEV || entity == u.STRAYSENSOR {
+			// Their hierarchyName, when set, starts with their name
+			req = bson.M{"$or": bson.A{req, bson.M{"hierarchyName": nil, "name": pattern}}}
+		}
+		if len(categories) > 0 {
+			req["category"] = bson.M{"$in": categories}
+		}
+		data, e := GetManyEntities(u.EntityToString(entity), req, filters)
+		if e != "" {
+			return nil, e
+		}
+		objects = append(objects, data...)
+	}
+	return objects, ""
+}
+
 func GetEntity(req bson.M, ent string, filters u.RequestFilters) (map[string]interface{}, string) {
 	t := map[string]interface{}{}
 	ctx, cancel := u.Connect()
@@ -146,6 +215,23 @@ func getDateFilters(req bson.M, filters u.RequestFilters) error {
 	return nil
 }
 
+// getTagFilters: adds to req the requirements of the tag selector
+// of filters (ex: env=prod,owner!=x), see u.TagSelectorToFilter
+func getTagFilters(req bson.M, filters u.RequestFilters) error {
+	if len(filters.Tags) > 0 {
+		tagReq, e := u.TagSelectorToFilter(filters.Tags)
+		if e != nil {
+			return e
+		}
+		if and, ok := req["$and"].([]interface{}); ok {
+			req["$and"] = append(and, tagReq["$and"].([]interface{})...)
+		} else {
+			req["$and"] = tagReq["$and"]
+		}
+	}
+	return nil
+}
+
 func GetManyEntities(ent string, req bson.M, filters u.RequestFilters) ([]map[string]interface{}, string) {
 	ctx, cancel := u.Connect()
 	var err error
@@ -163,6 +249,10 @@ func GetManyEntities(ent string, req bson.M, filters u.RequestFilters) ([]map[st
 	if err != nil {
 		return nil, err.Error()
 	}
+	err = getTagFilters(req, filters)
+	if err != nil {
+		return nil, err.Error()
+	}
 
 	if opts != nil {
 		c, err = GetDB().Collection(ent).Find(ctx, req, opts)
@@ -191,6 +281,41 @@ func GetManyEntities(ent string, req bson.M, filters u.RequestFilters) ([]map[st
 	return data, ""
 }
 
+// StreamEntities: like GetManyEntities, but the objects are read one
+// at a time from a cursor and given to send, so that a whole collection
+// is never held in memory. It stops when send returns false, after
+// limit objects if limit is not 0, or when ctx is done
+func StreamEntities(ctx context.Context, ent string, req bson.M, limit int64,
+	send func(map[string]interface{}) bool) string {
+	opts := options.Find()
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	c, err := GetDB().Collection(ent).Find(ctx, req, opts)
+	if err != nil {
+		return err.Error()
+	}
+	defer c.Close(context.Background())
+
+	for c.Next(ctx) {
+		x := map[string]interface{}{}
+		if err := c.Decode(x); err != nil {
+			return err.Error()
+		}
+		x = fixID(x)
+		if strings.Contains(ent, "_") {
+			FixUnderScore(x)
+		}
+		if !send(x) {
+			return ""
+		}
+	}
+	if err := c.Err(); err != nil && ctx.Err() == nil {
+		return err.Error()
+	}
+	return ""
+}
+
 // GetCompleteHierarchy: gets all objects in db using hierachyName and returns:
 //   - tree: map with parents as key and their children as an array value
 //     tree: {parent:[children]}
@@ -366,130 +491,199 @@ func GetStats() map[string]interface{} {
 // DeleteEntityByName: delete object of given hierarchyName
 // search for all its children and delete them too, return:
 // - success or fail message map
-func DeleteEntityByName(entity string, name string) map[string]interface{} {
+// - error code, "precondition failed" if revisions is not nil
+// and the revision of the object is not one of them
+func DeleteEntityByName(entity string, name string, revisions []int64) (map[string]interface{}, string) {
 	var req primitive.M
 	if entity == "tenant" {
 		req = bson.M{"name": name}
+	} else if entity == "domain" {
+		return DeleteDomain(bson.M{"hierarchyName": name}, revisions)
 	} else {
 		req = bson.M{"hierarchyName": name}
 	}
-	resp, err := DeleteEntityManual(entity, req)
-	if err != "" {
-		// Unable to delete given object
-		return resp
-	} else {
-		// Delete possible children
-		rangeEntities := getChildrenCollections(u.STRAYSENSOR, entity)
-		for _, childEnt := range rangeEntities {
-			childEntName := u.EntityToString(childEnt)
-			pattern := primitive.Regex{Pattern: name, Options: ""}
-
-			ctx, cancel := u.Connect()
-			GetDB().Collection(childEntName).DeleteMany(ctx,
-				bson.M{"hierarchyName": pattern})
-			defer cancel()
+	if _, _, e := deleteObjectTree(context.Background(), entity, req, revisions, nil); e != "" {
+		if e == "precondition failed" {
+			return preconditionFailedMessage(), e
 		}
+		return u.Message(false, "There was an error in deleting the entity"), e
 	}
-
-	return u.Message(true, "success")
+	return u.Message(true, "success"), ""
 }
 
 func DeleteEntityManual(entity string, req bson.M) (map[string]interface{}, string) {
 	//Finally delete the Entity
 	ctx, cancel := u.Connect()
-	c, _ := GetDB().Collection(entity).DeleteOne(ctx, req)
-	if c.DeletedCount == 0 {
+	obj := map[string]interface{}{}
+	e := GetDB().Collection(entity).FindOneAndDelete(ctx, req).Decode(&obj)
+	if e != nil {
 		return u.Message(false, "There was an error in deleting the entity"), "not found"
 	}
 	defer cancel()
+	recordHistory(entity, []map[string]interface{}{obj}, nil)
+	// The event names the object, so that it can be filtered
+	event := map[string]interface{}{"id": obj["_id"]}
+	for _, field := range []string{"name", "hierarchyName", "slug"} {
+		if value, ok := obj[field]; ok {
+			event[field] = value
+		}
+	}
+	publishEvent("deleted", entity, event)
 
 	return u.Message(true, "success"), ""
 }
 
-func DeleteEntity(entity string, id primitive.ObjectID) (map[string]interface{}, string) {
-	var t map[string]interface{}
-	var e string
-	eNum := u.EntityStrToInt(entity)
-	if eNum > u.DEVICE {
-		//Delete the non hierarchal objects
-		t, e = GetEntityHierarchy(id, entity, eNum, eNum+eNum, u.RequestFilters{})
-	} else {
-		t, e = GetEntityHierarchy(id, entity, eNum, u.AC, u.RequestFilters{})
+// deleteWithRevision: DeleteEntityManual, the revision of the
+// object has to be one of revisions if it is not nil
+func deleteWithRevision(entity string, req bson.M, revisions []int64) (map[string]interface{}, string) {
+	resp, e := DeleteEntityManual(entity, withRevision(req, revisions))
+	if e != "" && deleteMissCode(entity, req, revisions) == "precondition failed" {
+		return preconditionFailedMessage(), "precondition failed"
 	}
+	return resp, e
+}
 
-	if e != "" {
+// DeleteEntity: same as DeleteEntityByName, with the id of the object
+func DeleteEntity(entity string, id primitive.ObjectID, revisions []int64) (map[string]interface{}, string) {
+	if u.EntityStrToInt(entity) == u.DOMAIN {
+		return DeleteDomain(bson.M{"_id": id}, revisions)
+	}
+	if _, _, e := deleteObjectTree(context.Background(), entity, bson.M{"_id": id}, revisions, nil); e != "" {
+		if e == "precondition failed" {
+			return preconditionFailedMessage(), e
+		}
 		return u.Message(false,
 			"There was an error in deleting the entity: "+e), "not found"
 	}
-
-	return deleteHelper(t, eNum)
+	return u.Message(true, "success"), ""
 }
 
-func deleteHelper(t map[string]interface{}, ent int) (map[string]interface{}, string) {
-	if t != nil {
-
-		if v, ok := t["children"]; ok {
-			if x, ok := v.([]map[string]interface{}); ok {
-				for i := range x {
-					if ent == u.STRAYDEV {
-						deleteHelper(x[i], ent)
-					} else {
-						deleteHelper(x[i], ent+1)
-					}
-
-				}
-			}
+// deleteMissCode: error code of a delete of req, filtered on
+// revisions, that matched nothing: the object doesn't exist
+// or its revision is not one of them
+func deleteMissCode(entity string, req bson.M, revisions []int64) string {
+	if revisions != nil {
+		if obj, _ := GetEntity(req, entity, u.RequestFilters{}); obj != nil {
+			return "precondition failed"
 		}
+	}
+	return "not found"
+}
 
-		println("So we got: ", ent)
-
-		if ent == u.RACK {
-			ctx, cancel := u.Connect()
-			GetDB().Collection("sensor").DeleteMany(ctx,
-				bson.M{"parentId": t["id"].(primitive.ObjectID).Hex()})
-
-			GetDB().Collection("group").DeleteMany(ctx,
-				bson.M{"parentId": t["id"].(primitive.ObjectID).Hex()})
-			defer cancel()
-		}
+// descendantCollections: collections where the descendants
+// of an object of the given entity may be found
+func descendantCollections(entity string) []int {
+	collections := getChildrenCollections(u.STRAYSENSOR, entity)
+	switch u.EntityStrToInt(entity) {
+	case u.DEVICE:
+		collections = append(collections, u.SENSOR, u.GROUP)
+	case u.STRAYDEV:
+		collections = append(collections, u.STRAYSENSOR)
+	}
+	return collections
+}
 
-		//Delete associated non hierarchal objs
-		if ent == u.ROOM {
-			//ITER Through all nonhierarchal objs
-			ctx, cancel := u.Connect()
-			for i := u.AC; i < u.GROUP+1; i++ {
-				ent := u.EntityToString(i)
-				GetDB().Collection(ent).DeleteMany(ctx, bson.M{"parentId": t["id"].(primitive.ObjectID).Hex()})
+// deleteBatchSize: number of descendants deleted at once
+// by deleteObjectTree, each batch has its own timeout
+const deleteBatchSize = 1000
+
+// deleteObjectTree: deletes the object matching req then its
+// descendants, by batches, with their attachments and notes. The
+// object goes first so that it is never left with part of its
+// children gone. Used by DELETE requests and delete jobs: progress
+// (if not nil) is called after each batch with the number of objects
+// deleted and ctx is checked between them, no timeout applies to the
+// whole deletion. If revisions is not nil, the revision of the object
+// has to be one of them when it is deleted. Errors on the descendants
+// are returned in errs, the error code is about the object itself
+func deleteObjectTree(ctx context.Context, entity string, req bson.M, revisions []int64,
+	progress func(done, total int)) (deleted map[string]int64, errs []string, e string) {
+	deleted = map[string]int64{}
+	subtree := getObjectSubtree(entity, req)
+	collections := descendantCollections(entity)
+
+	obj := map[string]interface{}{}
+	dbCtx, cancel := u.Connect()
+	err := GetDB().Collection(entity).FindOneAndDelete(dbCtx, withRevision(req, revisions)).Decode(&obj)
+	cancel()
+	if err == mongo.ErrNoDocuments {
+		return deleted, nil, deleteMissCode(entity, req, revisions)
+	} else if err != nil {
+		return deleted, nil, err.Error()
+	}
+	obj = fixID(obj)
+	deleted[entity] = 1
+	recordHistory(entity, []map[string]interface{}{obj}, nil)
+	publishEvent("deleted", entity, map[string]interface{}{
+		"id": obj["id"], "hierarchyName": getHierarchyName(obj)})
+
+	pattern := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(getHierarchyName(obj)) + "\\.", Options: ""}
+	done, total := 1, len(subtree.ids)
+	if total < 1 {
+		total = 1
+	}
+	if progress != nil {
+		progress(done, total)
+	}
+	for _, childEnt := range collections {
+		childEntName := u.EntityToString(childEnt)
+		for {
+			if ctx.Err() != nil {
+				return deleted, errs, "cancelled"
 			}
-			defer cancel()
-		}
-
-		//Delete hierarchy under stray-device
-		if ent == u.STRAYDEV {
-			ctx, cancel := u.Connect()
-			entity := u.EntityToString(u.STRAYSENSOR)
-			GetDB().Collection(entity).DeleteMany(ctx, bson.M{"parentId": t["id"].(primitive.ObjectID).Hex()})
-			defer cancel()
-		}
-
-		if ent == u.DEVICE {
-			DeleteDeviceF(t["id"].(primitive.ObjectID))
-		} else {
-			ctx, cancel := u.Connect()
-			entity := u.EntityToString(ent)
-			println(entity)
-			c, _ := GetDB().Collection(entity).DeleteOne(ctx, bson.M{"_id": t["id"].(primitive.ObjectID)})
-			if c.DeletedCount == 0 {
-				return u.Message(false, "There was an error in deleting the entity"), "not found"
+			n, err := deleteBatch(ctx, childEntName, bson.M{"hierarchyName": pattern})
+			if err != nil {
+				errs = append(errs, childEntName+": "+err.Error())
+				break
+			}
+			if n == 0 {
+				break
+			}
+			deleted[childEntName] += n
+			done += int(n)
+			if progress != nil {
+				progress(done, total)
 			}
-			defer cancel()
-
 		}
 	}
-	return nil, ""
+	releaseObjectSubtree(subtree)
+	return deleted, errs, ""
 }
 
-func UpdateEntity(ent string, req bson.M, t *map[string]interface{}, isPatch bool) (map[string]interface{}, string) {
+// deleteBatch: deletes at most deleteBatchSize documents of
+// collection matching filter, returns how many were deleted
+func deleteBatch(ctx context.Context, collection string, filter bson.M) (int64, error) {
+	dbCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	// Whole documents are read for the history
+	c, e := GetDB().Collection(collection).Find(dbCtx, filter,
+		options.Find().SetLimit(deleteBatchSize))
+	if e != nil {
+		return 0, e
+	}
+	docs := []map[string]interface{}{}
+	if e := c.All(dbCtx, &docs); e != nil {
+		return 0, e
+	}
+	ids := bson.A{}
+	for _, doc := range docs {
+		ids = append(ids, doc["_id"])
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	res, e := GetDB().Collection(collection).DeleteMany(dbCtx, bson.M{"_id": bson.M{"$in": ids}})
+	if e != nil {
+		return 0, e
+	}
+	recordHistory(collection, docs, nil)
+	return res.DeletedCount, nil
+}
+
+// UpdateEntity: update (PATCH) or replace (PUT) the object matching req.
+// If revisions is not nil, the object is only written if its current
+// revision is one of them, otherwise "precondition failed" is returned
+func UpdateEntity(ent string, req bson.M, t *map[string]interface{}, isPatch bool, revisions []int64) (map[string]interface{}, string) {
 	var e *mongo.SingleResult
 	updatedDoc := bson.M{}
 	retDoc := options.ReturnDocument(options.After)
@@ -501,9 +695,56 @@ func UpdateEntity(ent string, req bson.M, t *map[string]interface{}, isPatch boo
 	if e1 != "" {
 		return u.Message(false, "Error: "+e1), e1
 	}
+	if !u.ETagMatches(revisions, GetRevision(oldObj)) {
+		return preconditionFailedMessage(), "precondition failed"
+	}
+	delete(*t, "revision")
 	(*t)["lastUpdated"] = primitive.NewDateTimeFromTime(time.Now())
 	(*t)["createdDate"] = oldObj["createdDate"]
 
+	// The revision we read is part of the filter so that a concurrent
+	// update between our read and our write can't be overwritten, and
+	// two writes can't give the same revision to different contents
+	filter := withRevision(req, []int64{GetRevision(oldObj)})
+
+	// Updating a template creates a new version of it
+	if isTemplate(u.EntityStrToInt(ent)) {
+		if err := nextTemplateVersion(ent, oldObj, *t, isPatch); err != nil {
+			return u.Message(false, err.Error()), "invalid"
+		}
+		filter["version"] = templateVersionFilter(oldObj)
+	}
+
+	// A device is checked and written while its new rack is locked
+	if ent == "device" {
+		target := *t
+		if isPatch {
+			target = applyFlatPatch(oldObj, *t)
+		}
+		unlock, resp, e := lockDeviceRack(target)
+		if e != "" {
+			return resp, e
+		}
+		defer unlock()
+		if _, ok := (*t)["attributes.posU"]; ok && isPatch {
+			// posU "auto" resolved
+			(*t)["attributes.posU"] = target["attributes"].(map[string]interface{})["posU"]
+		}
+	}
+	// An object with a footprint is checked and written
+	// while its new room is locked
+	if hasFootprint(u.EntityStrToInt(ent)) {
+		target := *t
+		if isPatch {
+			target = applyFlatPatch(oldObj, *t)
+		}
+		unlock, resp, e := lockObjectRoom(u.EntityStrToInt(ent), target)
+		if e != "" {
+			return resp, e
+		}
+		defer unlock()
+	}
+
 	// Ensure the update is valid and apply it
 	ctx, cancel := u.Connect()
 	if isPatch {
@@ -511,24 +752,49 @@ func UpdateEntity(ent string, req bson.M, t *map[string]interface{}, isPatch boo
 		if !ok {
 			return msg, "invalid"
 		}
+		if msg, ok := validatePatchExtensions(u.EntityStrToInt(ent), oldObj, *t); !ok {
+			return msg, "invalid"
+		}
+		if ent == "device" {
+			msg, ok := validateDevicePlacement(applyFlatPatch(oldObj, *t), oldObj["id"])
+			if !ok {
+				return msg, "invalid"
+			}
+		}
+		if msg, ok := validateRoomPlacement(u.EntityStrToInt(ent), applyFlatPatch(oldObj, *t), oldObj); !ok {
+			return msg, "invalid"
+		}
 		e = GetDB().Collection(ent).FindOneAndUpdate(ctx,
-			req, bson.M{"$set": *t},
+			filter, bson.M{"$set": *t, "$inc": bson.M{"revision": int64(1)}},
 			&options.FindOneAndUpdateOptions{ReturnDocument: &retDoc})
-		if e.Err() != nil {
-			return u.Message(false, "failure: "+e.Err().Error()), e.Err().Error()
-		}
 	} else {
 		println("NOT A PATCH")
 		msg, ok := ValidateEntity(u.EntityStrToInt(ent), *t)
 		if !ok {
 			return msg, "invalid"
 		}
+		if ent == "device" {
+			msg, ok := validateDevicePlacement(*t, oldObj["id"])
+			if !ok {
+				return msg, "invalid"
+			}
+		}
+		if msg, ok := validateRoomPlacement(u.EntityStrToInt(ent), *t, oldObj); !ok {
+			return msg, "invalid"
+		}
+		(*t)["revision"] = GetRevision(oldObj) + 1
 		e = GetDB().Collection(ent).FindOneAndReplace(ctx,
-			req, *t,
+			filter, *t,
 			&options.FindOneAndReplaceOptions{ReturnDocument: &retDoc})
-		if e.Err() != nil {
-			return u.Message(false, "failure: "+e.Err().Error()), e.Err().Error()
+	}
+	if e.Err() != nil {
+		if e.Err() == mongo.ErrNoDocuments && revisions != nil {
+			return preconditionFailedMessage(), "precondition failed"
+		} else if e.Err() == mongo.ErrNoDocuments {
+			return u.Message(false,
+				"Error: the object has been modified by another request, retry"), "conflict"
 		}
+		return u.Message(false, "failure: "+e.Err().Error()), e.Err().Error()
 	}
 
 	// Changes to hierarchyName should be propagated to its children
@@ -545,6 +811,16 @@ func UpdateEntity(ent string, req bson.M, t *map[string]interface{}, isPatch boo
 	//Fix the _id / id discrepancy
 	e.Decode(&updatedDoc)
 	updatedDoc = fixID(updatedDoc)
+	recordHistory(ent, []map[string]interface{}{oldObj}, []map[string]interface{}{updatedDoc})
+	if isTemplate(u.EntityStrToInt(ent)) {
+		if err := saveTemplateVersion(ent, updatedDoc); err != nil {
+			return u.Message(false, "Error while saving the version of "+ent+": "+err.Error()), err.Error()
+		}
+	}
+	publishEvent("updated", ent, updatedDoc)
+
+	// Assets the object doesn't reference anymore may be orphaned
+	releaseAssets(removedAssetRefs(oldObj, updatedDoc))
 
 	//Response Message
 	message := ""
@@ -565,11 +841,119 @@ func UpdateEntity(ent string, req bson.M, t *map[string]interface{}, isPatch boo
 	return resp, ""
 }
 
+// PatchEntity: applies a JSON Patch (RFC 6902) or a JSON Merge Patch
+// (RFC 7396) to the object matching req. The patched object is then
+// fully validated, as for a PUT, before replacing the stored one
+func PatchEntity(ent string, req bson.M, patch interface{}, patchType string, revisions []int64) (map[string]interface{}, string) {
+	// Without If-Match, the patch still has to be applied to the
+	// revision we read: retry if someone else wrote in between
+	for attempt := 0; ; attempt++ {
+		oldObj, e := GetEntity(req, ent, u.RequestFilters{})
+		if e != "" {
+			return u.Message(false, "Error: "+e), e
+		}
+		expected := revisions
+		if expected == nil {
+			expected = []int64{GetRevision(oldObj)}
+		} else if !u.ETagMatches(revisions, GetRevision(oldObj)) {
+			return preconditionFailedMessage(), "precondition failed"
+		}
+
+		// Only user fields can be patched
+		for _, field := range []string{"id", "hierarchyName", "createdDate", "lastUpdated", "revision"} {
+			delete(oldObj, field)
+		}
+		var doc interface{}
+		js, _ := json.Marshal(oldObj)
+		json.Unmarshal(js, &doc)
+
+		var err error
+		switch patchType {
+		case u.JSONPatchType:
+			ops, ok := patch.([]interface{})
+			if !ok {
+				return u.Message(false, "A JSON Patch must be an array of operations"), "invalid"
+			}
+			doc, err = u.ApplyJSONPatch(doc, ops)
+		case u.MergePatchType:
+			doc = u.ApplyMergePatch(doc, patch)
+		default:
+			return u.Message(false, "Unsupported patch format: "+patchType), "invalid"
+		}
+		if err != nil {
+			return u.Message(false, "Error while applying patch: "+err.Error()), "invalid"
+		}
+		t, ok := doc.(map[string]interface{})
+		if !ok {
+			return u.Message(false, "The patched object should be a JSON object"), "invalid"
+		}
+
+		resp, e := UpdateEntity(ent, req, &t, false, expected)
+		if e != "precondition failed" || revisions != nil || attempt >= 2 {
+			return resp, e
+		}
+	}
+}
+
+// GetRevision: returns the revision of a document,
+// 0 for documents created before revisions existed
+func GetRevision(obj map[string]interface{}) int64 {
+	switch rev := obj["revision"].(type) {
+	case int64:
+		return rev
+	case int32:
+		return int64(rev)
+	case float64:
+		return int64(rev)
+	}
+	return 0
+}
+
+// CheckRevision: verifies that the object matching req exists
+// and that its revision is one of the given revisions
+func CheckRevision(ent string, req bson.M, revisions []int64) (map[string]interface{}, string) {
+	obj, e := GetEntity(req, ent, u.RequestFilters{})
+	if e != "" {
+		return u.Message(false, "Error: "+e), e
+	}
+	if !u.ETagMatches(revisions, GetRevision(obj)) {
+		return preconditionFailedMessage(), "precondition failed"
+	}
+	return nil, ""
+}
+
+// withRevision: copies req adding a condition on the revision
+// field if revisions is not nil
+func withRevision(req bson.M, revisions []int64) bson.M {
+	filter := bson.M{}
+	for k, v := range req {
+		filter[k] = v
+	}
+	if revisions != nil {
+		in := bson.A{}
+		for _, rev := range revisions {
+			in = append(in, rev)
+		}
+		if u.ETagMatches(revisions, 0) {
+			// documents created before revisions existed
+			in = append(in, nil)
+		}
+		filter["revision"] = bson.M{"$in": in}
+	}
+	return filter
+}
+
+func preconditionFailedMessage() map[string]interface{} {
+	return u.Message(false,
+		"Error: the object has been modified since it was retrieved (If-Match)")
+}
+
 // propagateParentNameChange: search for given parent children and
 // update their hierarchyName with new parent name
 func propagateParentNameChange(ctx context.Context, oldParentName, newName string, entityInt int) {
 	// Find all objects containing parent name
-	req := bson.M{"hierarchyName": primitive.Regex{Pattern: oldParentName + ".", Options: ""}}
+	req := bson.M{"hierarchyName": primitive.Regex{
+		Pattern: "^" + regexp.QuoteMeta(oldParentName) + "\\.", Options: ""}}
 	// For each object found, replace old name by new
 	update := bson.D{{
 		Key: "$set", Value: bson.M{
@@ -580,21 +964,20 @@ func propagateParentNameChange(ctx context.Context, oldParentName, newName strin
 					"replacement": newName}}}}}
 
 	if entityInt == u.DEVICE {
-		_, e := GetDB().Collection(u.EntityToString(u.DEVICE)).UpdateMany(ctx,
-			req, mongo.Pipeline{update})
+		e := updateObjects(ctx, u.EntityToString(u.DEVICE), req, mongo.Pipeline{update})
 		if e != nil {
 			println(e.Error())
 		}
+	} else if entityInt == u.DOMAIN {
+		propagateDomainNameChange(ctx, oldParentName, newName)
 	} else if entityInt == u.STRAYDEV {
-		_, e := GetDB().Collection(u.EntityToString(u.STRAYDEV)).UpdateMany(ctx,
-			req, mongo.Pipeline{update})
+		e := updateObjects(ctx, u.EntityToString(u.STRAYDEV), req, mongo.Pipeline{update})
 		if e != nil {
 			println(e.Error())
 		}
 	} else if entityInt >= u.TENANT && entityInt <= u.RACK {
 		for i := entityInt + 1; i <= u.GROUP; i++ {
-			_, e := GetDB().Collection(u.EntityToString(i)).UpdateMany(ctx,
-				req, mongo.Pipeline{update})
+			e := updateObjects(ctx, u.EntityToString(i), req, mongo.Pipeline{update})
 			if e != nil {
 				println(e.Error())
 			}
@@ -688,7 +1071,7 @@ func GetEntityHierarchy(ID primitive.ObjectID, ent string, start, end int, filte
 	return nil, ""
 }
 
-func GetEntitiesUsingAncestorNames(ent string, id primitive.ObjectID, ancestry []map[string]string) ([]map[string]interface{}, string) {
+func GetEntitiesUsingAncestorNames(ent string, id primitive.ObjectID, ancestry []map[string]string, filters u.RequestFilters) ([]map[string]interface{}, string) {
 	top, e := GetEntity(bson.M{"_id": id}, ent, u.RequestFilters{})
 	if e != "" {
 		return nil, e
@@ -712,7 +1095,7 @@ func GetEntitiesUsingAncestorNames(ent string, id primitive.ObjectID, ancestry [
 				/*if k == "device" {
 					return GetDeviceFByParentID(pid) nil, ""
 				}*/
-				return GetManyEntities(k, bson.M{"parentId": pid}, u.RequestFilters{})
+				return GetManyEntities(k, bson.M{"parentId": pid}, filters)
 			}
 
 			x, e1 = GetEntity(bson.M{"parentId": pid, "name": v}, k, u.RequestFilters{})
@@ -778,8 +1161,8 @@ func GetHierarchyByName(entity, hierarchyName string, limit int, filters u.Reque
 	for _, checkEnt := range rangeEntities {
 		checkEntName := u.EntityToString(checkEnt)
 		// Obj should include parentName and not surpass limit range
-		pattern := primitive.Regex{Pattern: hierarchyName +
-			"(.[A-Za-z0-9_\" \"]+){1," + strconv.Itoa(limit) + "}$", Options: ""}
+		pattern := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(hierarchyName) +
+			"(\\.[^.]+){1," + strconv.Itoa(limit) + "}$", Options: ""}
 		children, e1 := GetManyEntities(checkEntName, bson.M{"hierarchyName": pattern}, filters)
 		if e1 != "" {
 			println("SUBENT: ", checkEntName)
@@ -844,7 +1227,7 @@ func getChildrenCollections(limit int, parentEntStr string) []int {
 	return rangeEntities
 }
 
-func GetEntitiesUsingTenantAsAncestor(ent, id string, ancestry []map[string]string) ([]map[string]interface{}, string) {
+func GetEntitiesUsingTenantAsAncestor(ent, id string, ancestry []map[string]string, filters u.RequestFilters) ([]map[string]interface{}, string) {
 	top, e := GetEntity(bson.M{"name": id}, ent, u.RequestFilters{})
 	if e != "" {
 		return nil, e
@@ -865,7 +1248,7 @@ func GetEntitiesUsingTenantAsAncestor(ent, id string, ancestry []map[string]stri
 
 			if v == "all" {
 				println("K:", k)
-				return GetManyEntities(k, bson.M{"parentId": pid}, u.RequestFilters{})
+				return GetManyEntities(k, bson.M{"parentId": pid}, filters)
 			}
 
 			x, e1 = GetEntity(bson.M{"parentId": pid, "name": v}, k, u.RequestFilters{})
@@ -908,7 +1291,10 @@ func GetEntityUsingTenantAsAncestor(ent, id string, ancestry []map[string]string
 	return x, ""
 }
 
-func GetEntitiesOfAncestor(id interface{}, ent int, entStr, wantedEnt string) ([]map[string]interface{}, string) {
+// GetEntitiesOfAncestor: objects two levels below the object id,
+// (or of the wantedEnt collection below its children), filtered by
+// filters (ex: tags)
+func GetEntitiesOfAncestor(id interface{}, ent int, entStr, wantedEnt string, filters u.RequestFilters) ([]map[string]interface{}, string) {
 	var ans []map[string]interface{}
 	var t map[string]interface{}
 	var e, e1 string
@@ -939,56 +1325,12 @@ func GetEntitiesOfAncestor(id interface{}, ent int, entStr, wantedEnt string) ([
 
 	for i := range sub {
 		x, _ := GetManyEntities(wantedEnt,
-			bson.M{"parentId": sub[i]["id"].(primitive.ObjectID).Hex()}, u.RequestFilters{})
+			bson.M{"parentId": sub[i]["id"].(primitive.ObjectID).Hex()}, filters)
 		ans = append(ans, x...)
 	}
 	return ans, ""
 }
 
-//DEV FAMILY FUNCS
-
-func DeleteDeviceF(entityID primitive.ObjectID) (map[string]interface{}, string) {
-	//var deviceType string
-
-	t, e := GetEntityHierarchy(entityID, "device", 0, 999, u.RequestFilters{})
-	if e != "" {
-		return u.Message(false,
-			"There was an error in deleting the entity"), "not found"
-	}
-
-	return deleteDeviceHelper(t)
-}
-
-func deleteDeviceHelper(t map[string]interface{}) (map[string]interface{}, string) {
-	println("entered ddH")
-	if t != nil {
-
-		if v, ok := t["children"]; ok {
-			if x, ok := v.([]map[string]interface{}); ok {
-				for i := range x {
-					deleteDeviceHelper(x[i])
-				}
-			}
-		}
-
-		ctx, cancel := u.Connect()
-		//Delete relevant non hierarchal objects
-		GetDB().Collection("sensor").DeleteMany(ctx,
-			bson.M{"parentId": t["id"].(primitive.ObjectID).Hex()})
-
-		GetDB().Collection("group").DeleteMany(ctx,
-			bson.M{"parentId": t["id"].(primitive.ObjectID).Hex()})
-
-		c, _ := GetDB().Collection("device").DeleteOne(ctx, bson.M{"_id": t["id"].(primitive.ObjectID)})
-		if c.DeletedCount == 0 {
-			return u.Message(false, "There was an error in deleting the entity"), "not found"
-		}
-		defer cancel()
-
-	}
-	return nil, ""
-}
-
 func ExtractCursor(c *mongo.Cursor, ctx context.Context) ([]map[string]interface{}, string) {
 	ans := []map[string]interface{}{}
 	for c.Next(ctx) {
@@ -0,0 +1,311 @@
+package models
+
+import (
+	u "p3/utils"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// findObjectByName: like GetObjectByName but also
+// returns the entity of the object found (-1 if none)
+func findObjectByName(hierarchyName string) (map[string]interface{}, int) {
+	candidates := []int{}
+	for _, entity := range u.HierachyNameToEntity(hierarchyName) {
+		if entity != u.STRAYDEV && entity != u.GROUP {
+			candidates = append(candidates, entity)
+		}
+	}
+	// Sensors and groups may be found at several levels
+	if strings.Contains(hierarchyName, ".") {
+		candidates = append(candidates, u.SENSOR, u.GROUP)
+	}
+
+	for _, entity := range candidates {
+		req := bson.M{"hierarchyName": hierarchyName}
+		if entity == u.TENANT {
+			req = bson.M{"name": hierarchyName}
+		}
+		data, _ := GetEntity(req, u.EntityToString(entity), u.RequestFilters{})
+		if data != nil {
+			return data, entity
+		}
+	}
+	return nil, -1
+}
+
+// GetParentCollections: entities an object of the given
+// entity can be the child of, as checked by validateParent
+func GetParentCollections(entity int) []int {
+	switch entity {
+	case u.SITE, u.BLDG, u.ROOM, u.RACK:
+		return []int{entity - 1}
+	case u.DEVICE:
+		return []int{u.RACK, u.DEVICE}
+	case u.AC, u.PWRPNL, u.CABINET, u.CORRIDOR:
+		return []int{u.ROOM}
+	case u.SENSOR, u.GROUP:
+		return []int{u.DEVICE, u.RACK, u.ROOM, u.BLDG}
+	default:
+		return []int{}
+	}
+}
+
+// getSubtreeCollections: collections where descendants
+// of an object of the given entity may be found
+func getSubtreeCollections(entity int) []int {
+	switch {
+	case entity >= u.TENANT && entity <= u.RACK:
+		collections := []int{}
+		for i := entity + 1; i <= u.GROUP; i++ {
+			collections = append(collections, i)
+		}
+		return collections
+	case entity == u.DEVICE:
+		return []int{u.DEVICE, u.SENSOR, u.GROUP}
+	default:
+		return []int{}
+	}
+}
+
+// subtreeObject: a descendant found by getSubtree
+type subtreeObject struct {
+	entity int
+	data   map[string]interface{}
+}
+
+// getSubtree: all the descendants of the object hierarchyName
+func getSubtree(hierarchyName string, entity int) ([]subtreeObject, string) {
+	pattern := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(hierarchyName) + "\\.", Options: ""}
+	subtree := []subtreeObject{}
+	for _, childEnt := range getSubtreeCollections(entity) {
+		children, e := GetManyEntities(u.EntityToString(childEnt),
+			bson.M{"hierarchyName": pattern}, u.RequestFilters{})
+		if e != "" {
+			return nil, e
+		}
+		for _, child := range children {
+			subtree = append(subtree, subtreeObject{childEnt, child})
+		}
+	}
+	return subtree, ""
+}
+
+// getContent: names listed in the content attribute
+// of a group or a corridor
+func getContent(obj map[string]interface{}) []string {
+	attrs, _ := obj["attributes"].(map[string]interface{})
+	content, _ := attrs["content"].(string)
+	names := []string{}
+	for _, name := range strings.Split(content, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// getReferencingObjects: groups and corridors with the given
+// parent that have name in their content
+func getReferencingObjects(parentId, name string) []string {
+	refs := []string{}
+	for _, ent := range []int{u.GROUP, u.CORRIDOR} {
+		objs, _ := GetManyEntities(u.EntityToString(ent),
+			bson.M{"parentId": parentId}, u.RequestFilters{})
+		for _, obj := range objs {
+			for _, item := range getContent(obj) {
+				if item == name {
+					refs = append(refs, getHierarchyName(obj))
+					break
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// checkContentUnder: every name of content should be a child of
+// parentId in the collections a group or corridor can reference
+func checkContentUnder(content []string, parentId string, parentEnt int) string {
+	collections := []int{u.DEVICE}
+	if parentEnt == u.ROOM {
+		collections = []int{u.RACK, u.CORRIDOR}
+	}
+	for _, name := range content {
+		found := false
+		for _, ent := range collections {
+			if data, _ := GetEntity(bson.M{"parentId": parentId, "name": name},
+				u.EntityToString(ent), u.RequestFilters{}); data != nil {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return name
+		}
+	}
+	return ""
+}
+
+// checkNameClash: returns the hierarchyName of an
+// existing child of the parent named like newName
+func checkNameClash(newName string, parentEnt int) string {
+	collections := GetChildCollections(parentEnt)
+	for _, ent := range collections {
+		if data, _ := GetEntity(bson.M{"hierarchyName": newName},
+			u.EntityToString(ent), u.RequestFilters{}); data != nil {
+			return newName
+		}
+	}
+	return ""
+}
+
+// getNewParent: finds parentName and checks that an object
+// of the given entity can become its child
+func getNewParent(hierarchyName string, entity int, parentName string) (map[string]interface{}, int, map[string]interface{}, string) {
+	allowed := GetParentCollections(entity)
+	if len(allowed) == 0 {
+		return nil, -1, u.Message(false, "The parent of a "+u.EntityToString(entity)+" cannot be changed"), "invalid"
+	}
+
+	parent, parentEnt := findObjectByName(parentName)
+	if parent == nil {
+		return nil, -1, u.Message(false, "Unable to find parent "+parentName), "not found"
+	}
+	compatible := false
+	for _, ent := range allowed {
+		compatible = compatible || ent == parentEnt
+	}
+	if !compatible {
+		return nil, -1, u.Message(false, "A "+u.EntityToString(entity)+
+			" cannot be placed under a "+u.EntityToString(parentEnt)), "invalid"
+	}
+	if parentName == hierarchyName || strings.HasPrefix(parentName, hierarchyName+".") {
+		return nil, -1, u.Message(false, "An object cannot be placed under itself"), "invalid"
+	}
+
+	return parent, parentEnt, nil, ""
+}
+
+// MoveObject: moves the object hierarchyName with all its
+// descendants under the object parentName. Every parentId and
+// hierarchyName is updated or none of them. Attachments and notes
+// reference their object by id, they follow it
+func MoveObject(hierarchyName, parentName string) (map[string]interface{}, string) {
+	obj, entity := findObjectByName(hierarchyName)
+	if obj == nil {
+		return u.Message(false, "Unable to find object "+hierarchyName), "not found"
+	}
+	parent, parentEnt, resp, e := getNewParent(hierarchyName, entity, parentName)
+	if e != "" {
+		return resp, e
+	}
+
+	oldParentId, _ := obj["parentId"].(string)
+	parentId := parent["id"].(primitive.ObjectID).Hex()
+	newName := getHierarchyName(parent) + "." + obj["name"].(string)
+	if parentId == oldParentId {
+		resp = u.Message(true, "object already under "+parentName)
+		resp["data"] = []interface{}{}
+		return resp, ""
+	}
+	if clash := checkNameClash(newName, parentEnt); clash != "" {
+		return u.Message(false, "An object named "+clash+" already exists"), "clash"
+	}
+	if entity == u.DEVICE {
+		unlock, resp, e := lockParentRack(parent)
+		if e != "" {
+			return resp, e
+		}
+		defer unlock()
+		if resp, ok := checkDevicePlacement(obj, parent, obj["id"]); !ok {
+			return resp, "clash"
+		}
+	}
+	if hasFootprint(entity) {
+		unlock, resp, e := lockRooms(parentId)
+		if e != "" {
+			return resp, e
+		}
+		defer unlock()
+		if resp, ok := checkRoomPlacement(obj, parent, obj["id"]); !ok {
+			return resp, "clash"
+		}
+	}
+
+	// Groups and corridors reference their siblings by name
+	switch entity {
+	case u.RACK, u.DEVICE, u.CORRIDOR:
+		if refs := getReferencingObjects(oldParentId, obj["name"].(string)); len(refs) > 0 {
+			return u.Message(false, "The object is referenced by "+
+				strings.Join(refs, ", ")+" and cannot be moved"), "invalid"
+		}
+	}
+	switch entity {
+	case u.GROUP, u.CORRIDOR:
+		if missing := checkContentUnder(getContent(obj), parentId, parentEnt); missing != "" {
+			return u.Message(false, "The content "+missing+" of the object"+
+				" does not exist under "+parentName), "invalid"
+		}
+	}
+
+	subtree, e := getSubtree(hierarchyName, entity)
+	if e != "" {
+		return u.Message(false, "Error while getting object children: "+e), e
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	changes := []docChange{{
+		collection: u.EntityToString(entity),
+		id:         obj["id"].(primitive.ObjectID),
+		old: bson.M{"parentId": oldParentId, "hierarchyName": hierarchyName,
+			"lastUpdated": obj["lastUpdated"]},
+		new:      bson.M{"parentId": parentId, "hierarchyName": newName, "lastUpdated": now},
+		checked:  true,
+		revision: GetRevision(obj),
+	}}
+	moved := []interface{}{map[string]interface{}{
+		"id": obj["id"], "category": u.EntityToString(entity),
+		"oldHierarchyName": hierarchyName, "hierarchyName": newName}}
+	for _, child := range subtree {
+		oldChildName := child.data["hierarchyName"].(string)
+		newChildName := newName + strings.TrimPrefix(oldChildName, hierarchyName)
+		changes = append(changes, docChange{
+			collection: u.EntityToString(child.entity),
+			id:         child.data["id"].(primitive.ObjectID),
+			old:        bson.M{"hierarchyName": oldChildName, "lastUpdated": child.data["lastUpdated"]},
+			new:        bson.M{"hierarchyName": newChildName, "lastUpdated": now},
+			checked:    true,
+			revision:   GetRevision(child.data),
+		})
+		moved = append(moved, map[string]interface{}{
+			"id": child.data["id"], "category": u.EntityToString(child.entity),
+			"oldHierarchyName": oldChildName, "hierarchyName": newChildName})
+	}
+
+	if err := applyChanges(changes); err != nil {
+		if strings.Contains(err.Error(), "E11000") {
+			return u.Message(false, "Error while moving object: Duplicates not allowed"), "clash"
+		}
+		if isModifiedError(err) {
+			return u.Message(false, "Error while moving object: "+err.Error()), "conflict"
+		}
+		return u.Message(false, "Error while moving object: "+err.Error()), err.Error()
+	}
+
+	obj["parentId"] = parentId
+	for _, movedObj := range append([]subtreeObject{{entity, obj}}, subtree...) {
+		movedObj.data["hierarchyName"] = newName +
+			strings.TrimPrefix(movedObj.data["hierarchyName"].(string), hierarchyName)
+		movedObj.data["lastUpdated"] = now
+		movedObj.data["revision"] = GetRevision(movedObj.data) + 1
+		publishEvent("updated", u.EntityToString(movedObj.entity), movedObj.data)
+	}
+
+	resp = u.Message(true, "successfully moved object")
+	resp["data"] = moved
+	return resp, ""
+}
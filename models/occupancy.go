@@ -0,0 +1,299 @@
+package models
+
+import (
+	"errors"
+	u "p3/utils"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// getRackHeightU: height of a rack in U
+func getRackHeightU(rack map[string]interface{}) (float64, error) {
+	attrs, _ := rack["attributes"].(map[string]interface{})
+	height, _ := attrs["height"].(string)
+	unit, _ := attrs["heightUnit"].(string)
+	return u.ToRackUnits(height, unit)
+}
+
+// getDeviceURange: rack units used by a device. Its size is sizeU,
+// or its height if not given. ok is false for devices not
+// placed by U (ex: in the slot of a chassis)
+func getDeviceURange(device map[string]interface{}) (r u.URange, ok bool, err error) {
+	attrs, _ := device["attributes"].(map[string]interface{})
+	posU, _ := attrs["posU"].(string)
+	if posU == "" {
+		return r, false, nil
+	}
+	start, e := strconv.ParseFloat(posU, 64)
+	if e != nil {
+		return r, true, errors.New("invalid posU: '" + posU + "'")
+	}
+
+	var size float64
+	if sizeU, _ := attrs["sizeU"].(string); sizeU != "" {
+		if size, e = strconv.ParseFloat(sizeU, 64); e != nil {
+			return r, true, errors.New("invalid sizeU: '" + sizeU + "'")
+		}
+	} else {
+		height, _ := attrs["height"].(string)
+		unit, _ := attrs["heightUnit"].(string)
+		if size, e = u.ToRackUnits(height, unit); e != nil {
+			return r, true, e
+		}
+	}
+	if size <= 0 {
+		return r, true, errors.New("the size of a device should be positive")
+	}
+	return u.URange{Start: start, End: start + size}, true, nil
+}
+
+// describeURange: posU and size of a range, for messages
+func describeURange(r u.URange) string {
+	return "posU " + strconv.FormatFloat(r.Start, 'f', -1, 64) +
+		", " + strconv.FormatFloat(r.End-r.Start, 'f', -1, 64) + "U"
+}
+
+// getChildDevices: devices placed directly in a rack or a device
+func getChildDevices(parent map[string]interface{}) ([]map[string]interface{}, string) {
+	pattern := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(getHierarchyName(parent)) + "\\.[^.]+$", Options: ""}
+	return GetManyEntities("device", bson.M{"hierarchyName": pattern}, u.RequestFilters{})
+}
+
+// checkRackOccupancy: a device placed by U should be inside its rack
+// and should not overlap the other devices of the rack. exclude is
+// the ID of the device itself, when it is updated or moved
+func checkRackOccupancy(device, rack map[string]interface{}, exclude interface{}) (map[string]interface{}, bool) {
+	r, ok, e := getDeviceURange(device)
+	if !ok {
+		return nil, true
+	} else if e != nil {
+		return u.Message(false, "Unable to get the U position of the device: "+e.Error()), false
+	}
+
+	height, e := getRackHeightU(rack)
+	if e != nil {
+		return u.Message(false, "Unable to get the height of rack "+
+			getHierarchyName(rack)+": "+e.Error()), false
+	}
+	if !r.Fits(height) {
+		return u.Message(false, "The device ("+describeURange(r)+") does not fit in rack "+
+			getHierarchyName(rack)+" of "+strconv.FormatFloat(height, 'f', -1, 64)+"U"), false
+	}
+
+	siblings, e2 := getChildDevices(rack)
+	if e2 != "" {
+		return u.Message(false, "Unable to get the devices of the rack: "+e2), false
+	}
+	for _, sibling := range siblings {
+		if exclude != nil && sibling["id"] == exclude {
+			continue
+		}
+		other, ok, e := getDeviceURange(sibling)
+		if !ok || e != nil {
+			continue
+		}
+		if r.Overlaps(other) {
+			return u.Message(false, "The device ("+describeURange(r)+") clashes with device "+
+				getHierarchyName(sibling)+" ("+describeURange(other)+")"), false
+		}
+	}
+	return nil, true
+}
+
+// validateDevicePlacement: checks the U range of a device in a rack,
+// or its slot in a device. exclude is the ID of the device itself
+func validateDevicePlacement(device map[string]interface{}, exclude interface{}) (map[string]interface{}, bool) {
+	parentId, _ := device["parentId"].(string)
+	if parentId == "" {
+		return nil, true
+	}
+	parent := getParentObject(u.DEVICE, parentId)
+	if parent == nil {
+		return nil, true
+	}
+	return checkDevicePlacement(device, parent, exclude)
+}
+
+// checkDevicePlacement: checks a device against its parent
+func checkDevicePlacement(device, parent map[string]interface{}, exclude interface{}) (map[string]interface{}, bool) {
+	if parent["category"] == "rack" {
+		return checkRackOccupancy(device, parent, exclude)
+	}
+	return checkSlotOccupancy(device, parent, exclude)
+}
+
+// allocateDevicePosU: sets the posU of a device given with posU
+// "auto" to the first free position of the rack where it fits
+func allocateDevicePosU(device, rack map[string]interface{}, auto u.AutoPosU) (map[string]interface{}, bool) {
+	attrs := device["attributes"].(map[string]interface{})
+	attrs["posU"] = "1" // only to get its size
+	r, _, err := getDeviceURange(device)
+	if err != nil {
+		return u.Message(false, "Unable to get the size of the device: "+err.Error()), false
+	}
+	size := r.End - r.Start
+
+	height, err := getRackHeightU(rack)
+	if err != nil {
+		return u.Message(false, "Unable to get the height of rack "+
+			getHierarchyName(rack)+": "+err.Error()), false
+	}
+	devices, e := getChildDevices(rack)
+	if e != "" {
+		return u.Message(false, "Unable to get the devices of the rack: "+e), false
+	}
+	used := []u.URange{}
+	for _, other := range devices {
+		if r, ok, err := getDeviceURange(other); ok && err == nil {
+			used = append(used, r)
+		}
+	}
+
+	free := u.FreeURanges(height, used)
+	posU, ok := 0.0, false
+	if auto.Preferred != nil {
+		posU, ok = u.FirstFit(free, size, auto.Top, auto.Preferred)
+	}
+	if !ok {
+		posU, ok = u.FirstFit(free, size, auto.Top, nil)
+	}
+	if !ok {
+		return u.Message(false, "No free range of "+strconv.FormatFloat(size, 'f', -1, 64)+
+			"U in rack "+getHierarchyName(rack)), false
+	}
+	attrs["posU"] = strconv.FormatFloat(posU, 'f', -1, 64)
+	return nil, true
+}
+
+// lockDeviceRack: devices are placed in a rack one at a time so that
+// two of them can't get the same U range. It locks the rack parent of
+// the device, if any, and resolves its posU "auto". The returned
+// function releases the lock
+func lockDeviceRack(device map[string]interface{}) (func(), map[string]interface{}, string) {
+	attrs, _ := device["attributes"].(map[string]interface{})
+	posU, _ := attrs["posU"].(string)
+	auto, isAuto, err := u.ParseAutoPosU(posU)
+	if err != nil {
+		return nil, u.Message(false, err.Error()), "validate"
+	}
+
+	parentId, _ := device["parentId"].(string)
+	var rack map[string]interface{}
+	if parentId != "" {
+		if parent := getParentObject(u.DEVICE, parentId); parent != nil && parent["category"] == "rack" {
+			rack = parent
+		}
+	}
+	if rack == nil {
+		if isAuto {
+			return nil, u.Message(false, "posU auto is only possible for a device in a rack"), "validate"
+		}
+		return func() {}, nil, ""
+	}
+
+	unlock, resp, e := lockRacks(rack["id"].(primitive.ObjectID).Hex())
+	if e != "" {
+		return nil, resp, e
+	}
+	if isAuto {
+		if resp, ok := allocateDevicePosU(device, rack, auto); !ok {
+			unlock()
+			return nil, resp, "validate"
+		}
+	}
+	return unlock, nil, ""
+}
+
+// lockRacks: takes the locks of the racks given by their
+// id (see lockDeviceRack), returns the function releasing them.
+// The error code is "locked" when a rack stays locked by another
+// request, the request can then be retried
+func lockRacks(ids ...string) (func(), map[string]interface{}, string) {
+	keys := []string{}
+	for _, id := range ids {
+		keys = append(keys, "rack:"+id)
+	}
+	unlock, err := acquireLocks(keys, 5*time.Second)
+	if errors.Is(err, errLockTimeout) {
+		return nil, u.Message(false, "Unable to place the device in the rack: "+err.Error()), "locked"
+	} else if err != nil {
+		return nil, u.Message(false, "Unable to place the device in the rack: "+err.Error()), "internal"
+	}
+	return unlock, nil, ""
+}
+
+// lockParentRack: locks the parent of an object if it is a rack
+func lockParentRack(parent map[string]interface{}) (func(), map[string]interface{}, string) {
+	if parent["category"] != "rack" {
+		return func() {}, nil, ""
+	}
+	return lockRacks(parent["id"].(primitive.ObjectID).Hex())
+}
+
+// applyFlatPatch: the parent and attributes of an object
+// once a flattened PATCH (ex: "attributes.posU") applied
+func applyFlatPatch(old, patch map[string]interface{}) map[string]interface{} {
+	attrs := map[string]interface{}{}
+	if oldAttrs, ok := old["attributes"].(map[string]interface{}); ok {
+		for k, v := range oldAttrs {
+			attrs[k] = v
+		}
+	}
+	patched := map[string]interface{}{"parentId": old["parentId"], "attributes": attrs}
+	for k, v := range patch {
+		if k == "parentId" {
+			patched[k] = v
+		} else if strings.HasPrefix(k, "attributes.") {
+			attrs[strings.TrimPrefix(k, "attributes.")] = v
+		}
+	}
+	return patched
+}
+
+// GetRackOccupancy: used and free U ranges of a rack
+func GetRackOccupancy(req bson.M) (map[string]interface{}, string) {
+	rack, e := GetEntity(req, "rack", u.RequestFilters{})
+	if rack == nil {
+		return u.Message(false, "Error while getting rack: "+e), e
+	}
+	height, err := getRackHeightU(rack)
+	if err != nil {
+		return u.Message(false, "Unable to get the height of the rack: "+err.Error()), "invalid"
+	}
+	devices, e := getChildDevices(rack)
+	if e != "" {
+		return u.Message(false, "Error while getting devices: "+e), e
+	}
+
+	usedRanges := []u.URange{}
+	used := []map[string]interface{}{}
+	for _, device := range devices {
+		r, ok, err := getDeviceURange(device)
+		if !ok || err != nil {
+			continue
+		}
+		usedRanges = append(usedRanges, r)
+		used = append(used, map[string]interface{}{
+			"id": device["id"], "name": device["name"], "hierarchyName": device["hierarchyName"],
+			"posU": r.Start, "sizeU": r.End - r.Start})
+	}
+	sort.Slice(used, func(i, j int) bool {
+		return used[i]["posU"].(float64) < used[j]["posU"].(float64)
+	})
+	free := []map[string]interface{}{}
+	for _, r := range u.FreeURanges(height, usedRanges) {
+		free = append(free, map[string]interface{}{"posU": r.Start, "sizeU": r.End - r.Start})
+	}
+
+	resp := u.Message(true, "successfully got rack occupancy")
+	resp["data"] = map[string]interface{}{
+		"id": rack["id"], "hierarchyName": rack["hierarchyName"],
+		"heightU": height, "used": used, "free": free}
+	return resp, ""
+}
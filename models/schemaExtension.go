@@ -0,0 +1,300 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	u "p3/utils"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// JSON Schema fragments registered by users, validated in addition
+// to the embedded schemas for objects of an entity (and a domain).
+// The category of an extension is the entity (ex: device, obj_template)
+// and not the category field of the objects: the one of obj_templates
+// is rack or device
+const schemaExtensionCollection = "schema_extension"
+
+// How long extensions are cached: changes made through this instance
+// apply at once, changes made through another one after this delay
+const schemaExtensionsTTL = 10 * time.Second
+
+type schemaExtension struct {
+	id       string
+	category string
+	domain   string
+	schema   *jsonschema.Schema
+}
+
+var (
+	schemaExtensionsMutex    sync.Mutex
+	schemaExtensions         []schemaExtension
+	schemaExtensionsLoadedAt time.Time
+	schemaExtensionIndexOnce sync.Once
+)
+
+// compileSchemaExtension: compiles a fragment, it can reference
+// the embedded definitions (ex: refs/types.json#/definitions/color)
+func compileSchemaExtension(id string, fragment interface{}) (*jsonschema.Schema, error) {
+	data, e := json.Marshal(fragment)
+	if e != nil {
+		return nil, e
+	}
+	compiler := jsonschema.NewCompiler()
+	entries, _ := embeddfs.ReadDir("schemas/refs")
+	for _, entry := range entries {
+		if file, e := embeddfs.Open("schemas/refs/" + entry.Name()); e == nil {
+			compiler.AddResource("refs/"+entry.Name(), file)
+		}
+	}
+	url := "extensions/" + id + ".json"
+	if e := compiler.AddResource(url, bytes.NewReader(data)); e != nil {
+		return nil, e
+	}
+	return compiler.Compile(url)
+}
+
+// InvalidateSchemaExtensions: the next validation reloads the extensions
+func InvalidateSchemaExtensions() {
+	schemaExtensionsMutex.Lock()
+	schemaExtensionsLoadedAt = time.Time{}
+	schemaExtensionsMutex.Unlock()
+}
+
+// getSchemaExtensions: compiled extensions, reloaded from the DB
+// when they changed or the cache expired
+func getSchemaExtensions() []schemaExtension {
+	schemaExtensionsMutex.Lock()
+	defer schemaExtensionsMutex.Unlock()
+	if time.Since(schemaExtensionsLoadedAt) < schemaExtensionsTTL {
+		return schemaExtensions
+	}
+
+	data, e := GetManyEntities(schemaExtensionCollection, bson.M{}, u.RequestFilters{})
+	if e != "" {
+		// Keep the previous ones rather than validating less
+		println("Unable to load schema extensions:", e)
+		return schemaExtensions
+	}
+	extensions := []schemaExtension{}
+	for _, ext := range data {
+		id := ext["id"].(primitive.ObjectID).Hex()
+		schema, err := compileSchemaExtension(id, toPlainJSON(ext["schema"]))
+		if err != nil {
+			println("Unable to compile schema extension "+id+":", err.Error())
+			continue
+		}
+		category, _ := ext["category"].(string)
+		domain, _ := ext["domain"].(string)
+		extensions = append(extensions, schemaExtension{id, category, domain, schema})
+	}
+	schemaExtensions = extensions
+	schemaExtensionsLoadedAt = time.Now()
+	return schemaExtensions
+}
+
+// toPlainJSON: mongo values to the JSON values jsonschema expects
+func toPlainJSON(value interface{}) interface{} {
+	var plain interface{}
+	data, _ := json.Marshal(value)
+	json.Unmarshal(data, &plain)
+	return plain
+}
+
+// validateSchemaExtensions: errors of t for the extensions of its
+// entity and domain. An extension without domain applies to all
+// domains, one with a domain to it and its subdomains
+func validateSchemaExtensions(entity int, t map[string]interface{}) []string {
+	category := u.EntityToString(entity)
+	domain, _ := t["domain"].(string)
+
+	errs := []string{}
+	var plain interface{}
+	for _, ext := range getSchemaExtensions() {
+		if ext.category != category || (ext.domain != "" && ext.domain != domain &&
+			!strings.HasPrefix(domain, ext.domain+".")) {
+			continue
+		}
+		if plain == nil {
+			plain = toPlainJSON(t)
+		}
+		if err := ext.schema.Validate(plain); err != nil {
+			if v, ok := err.(*jsonschema.ValidationError); ok {
+				for _, schErr := range v.BasicOutput().Errors {
+					if len(schErr.Error) > 0 && !strings.Contains(schErr.Error, "doesn't validate with") {
+						errs = append(errs, strings.TrimSpace(schErr.InstanceLocation+" "+schErr.Error))
+					}
+				}
+			} else {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	return errs
+}
+
+// patchedDocument: the object as it will be once a flat
+// patch (ex: {"attributes.assetTag": "T1"}) is applied to it
+func patchedDocument(old, patch map[string]interface{}) map[string]interface{} {
+	doc := copyDocument(old)
+	for key, value := range patch {
+		fields := strings.Split(key, ".")
+		parent := doc
+		for _, field := range fields[:len(fields)-1] {
+			child := map[string]interface{}{}
+			if m, ok := parent[field].(map[string]interface{}); ok {
+				for k, v := range m {
+					child[k] = v
+				}
+			}
+			parent[field] = child
+			parent = child
+		}
+		parent[fields[len(fields)-1]] = value
+	}
+	for _, field := range []string{"createdDate", "lastUpdated", "revision"} {
+		delete(doc, field)
+	}
+	return doc
+}
+
+// validatePatchExtensions: a flat patch is checked field by field,
+// the extensions apply to the whole object once patched
+func validatePatchExtensions(entity int, old, patch map[string]interface{}) (map[string]interface{}, bool) {
+	if errSlice := validateSchemaExtensions(entity, patchedDocument(old, patch)); len(errSlice) > 0 {
+		resp := u.Message(false, "JSON body doesn't validate with the schema extensions")
+		resp["errors"] = errSlice
+		return resp, false
+	}
+	return nil, true
+}
+
+func ensureSchemaExtensionIndex() {
+	schemaExtensionIndexOnce.Do(func() {
+		ctx, cancel := u.Connect()
+		defer cancel()
+		_, e := GetDB().Collection(schemaExtensionCollection).Indexes().CreateOne(ctx,
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "category", Value: 1}, {Key: "domain", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			})
+		if e != nil {
+			println("Unable to create schema extension index:", e.Error())
+		}
+	})
+}
+
+// checkSchemaExtension: the fields of an extension given by a user
+func checkSchemaExtension(t map[string]interface{}) (map[string]interface{}, string) {
+	for field := range t {
+		if field != "category" && field != "domain" && field != "schema" && field != "description" {
+			return u.Message(false, "Unknown field: "+field), "invalid"
+		}
+	}
+	if category, ok := t["category"].(string); !ok || category == "" {
+		return u.Message(false, "category is required"), "invalid"
+	} else if entity := u.EntityStrToInt(category); entity < 0 || u.EntityToString(entity) != category {
+		return u.Message(false, "category should be an entity (ex: device, obj_template)"), "invalid"
+	}
+	if domain, ok := t["domain"]; !ok || domain == nil {
+		t["domain"] = ""
+	} else if _, ok := domain.(string); !ok {
+		return u.Message(false, "domain should be a string"), "invalid"
+	}
+	if _, ok := t["schema"].(map[string]interface{}); !ok {
+		return u.Message(false, "schema should be a JSON Schema object"), "invalid"
+	}
+	if _, e := compileSchemaExtension("new", t["schema"]); e != nil {
+		return u.Message(false, "Invalid JSON Schema: "+e.Error()), "invalid"
+	}
+	return nil, ""
+}
+
+// CreateSchemaExtension: registers a fragment for a category, and
+// optionally a domain. There is one extension per category and domain
+func CreateSchemaExtension(t map[string]interface{}) (map[string]interface{}, string) {
+	if resp, e := checkSchemaExtension(t); e != "" {
+		return resp, e
+	}
+	ensureSchemaExtensionIndex()
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	t["_id"] = primitive.NewObjectID()
+	t["createdDate"] = now
+	t["lastUpdated"] = now
+	ctx, cancel := u.Connect()
+	defer cancel()
+	if _, e := GetDB().Collection(schemaExtensionCollection).InsertOne(ctx, t); e != nil {
+		if mongo.IsDuplicateKeyError(e) {
+			return u.Message(false, "An extension already exists for this category and domain"), "duplicate"
+		}
+		return u.Message(false, "Internal error while creating extension: "+e.Error()), e.Error()
+	}
+	InvalidateSchemaExtensions()
+
+	resp := u.Message(true, "successfully created schema extension")
+	resp["data"] = fixID(t)
+	return resp, ""
+}
+
+// GetSchemaExtensions: extensions, optionally of a category and a domain
+func GetSchemaExtensions(category, domain string) ([]map[string]interface{}, string) {
+	req := bson.M{}
+	if category != "" {
+		req["category"] = category
+	}
+	if domain != "" {
+		req["domain"] = domain
+	}
+	return GetManyEntities(schemaExtensionCollection, req, u.RequestFilters{})
+}
+
+func GetSchemaExtension(id primitive.ObjectID) (map[string]interface{}, string) {
+	return GetEntity(bson.M{"_id": id}, schemaExtensionCollection, u.RequestFilters{})
+}
+
+// UpdateSchemaExtension: replaces an extension, the fields
+// not given (ex: description) are removed
+func UpdateSchemaExtension(id primitive.ObjectID, t map[string]interface{}) (map[string]interface{}, string) {
+	if resp, e := checkSchemaExtension(t); e != "" {
+		return resp, e
+	}
+	old, e1 := GetSchemaExtension(id)
+	if old == nil {
+		return u.Message(false, "Error while updating extension: "+e1), e1
+	}
+	t["createdDate"] = old["createdDate"]
+	t["lastUpdated"] = primitive.NewDateTimeFromTime(time.Now())
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+	var updated map[string]interface{}
+	e := GetDB().Collection(schemaExtensionCollection).FindOneAndReplace(ctx,
+		bson.M{"_id": id}, t,
+		options.FindOneAndReplace().SetReturnDocument(options.After)).Decode(&updated)
+	if e != nil {
+		if mongo.IsDuplicateKeyError(e) {
+			return u.Message(false, "An extension already exists for this category and domain"), "duplicate"
+		}
+		return u.Message(false, "Error while updating extension: "+e.Error()), e.Error()
+	}
+	InvalidateSchemaExtensions()
+
+	resp := u.Message(true, "successfully updated schema extension")
+	resp["data"] = fixID(updated)
+	return resp, ""
+}
+
+func DeleteSchemaExtension(id primitive.ObjectID) (map[string]interface{}, string) {
+	resp, e := DeleteEntityManual(schemaExtensionCollection, bson.M{"_id": id})
+	InvalidateSchemaExtensions()
+	return resp, e
+}
@@ -0,0 +1,241 @@
+package models
+
+import (
+	"errors"
+	"os"
+	u "p3/utils"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Name of the text index of each collection used by search
+const searchIndexName = "search_text"
+
+var (
+	searchIndexMutex sync.RWMutex
+	// Collections with a text index, the others are searched with regexes
+	searchIndexed = map[string]bool{}
+)
+
+// getSearchAttributes: attributes searched besides names and descriptions,
+// configurable with search_attributes (comma separated) in the .env file
+func getSearchAttributes() []string {
+	attributes := []string{"template", "type", "model", "vendor", "serial"}
+	if env := os.Getenv("search_attributes"); env != "" {
+		attributes = []string{}
+		for _, attr := range strings.Split(env, ",") {
+			if attr = strings.TrimSpace(attr); attr != "" {
+				attributes = append(attributes, attr)
+			}
+		}
+	}
+	return attributes
+}
+
+// searchFields: searched fields with their weight in the ranking
+func searchFields() map[string]int {
+	fields := map[string]int{"name": 10, "slug": 10, "hierarchyName": 5, "description": 2}
+	for _, attr := range getSearchAttributes() {
+		fields["attributes."+attr] = 1
+	}
+	return fields
+}
+
+// searchEntities: every collection of objects and templates
+func searchEntities() []string {
+	entities := []string{}
+	for entity := u.TENANT; entity <= u.STRAYSENSOR; entity++ {
+		entities = append(entities, u.EntityToString(entity))
+	}
+	return entities
+}
+
+// EnsureSearchIndexes: creates the text indexes used by Search.
+// Collections where it fails (storage without text indexes, or
+// another text index exists) are searched with regexes instead
+func EnsureSearchIndexes() {
+	weights := bson.D{}
+	keys := bson.D{}
+	for field, weight := range searchFields() {
+		keys = append(keys, bson.E{Key: field, Value: "text"})
+		weights = append(weights, bson.E{Key: field, Value: weight})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+
+	for _, entity := range searchEntities() {
+		ctx, cancel := u.Connect()
+		_, e := GetDB().Collection(entity).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: keys,
+			Options: options.Index().SetName(searchIndexName).SetWeights(weights).
+				SetDefaultLanguage("none"),
+		})
+		cancel()
+		if e != nil {
+			println("Unable to create search index of "+entity+", using regexes:", e.Error())
+		}
+		searchIndexMutex.Lock()
+		searchIndexed[entity] = e == nil
+		searchIndexMutex.Unlock()
+	}
+}
+
+func isSearchIndexed(entity string) bool {
+	searchIndexMutex.RLock()
+	defer searchIndexMutex.RUnlock()
+	return searchIndexed[entity]
+}
+
+// searchResult: an object found, with its rank
+type searchResult struct {
+	score float64
+	data  map[string]interface{}
+}
+
+// searchScore: rank of an object, exact and prefix matches of the name
+// first, then the text score (or the weight of the fields matching)
+func searchScore(obj map[string]interface{}, query string, textScore float64, indexed bool) float64 {
+	q := strings.ToLower(query)
+	name, _ := obj["name"].(string)
+	if slug, ok := obj["slug"].(string); ok && name == "" {
+		name = slug
+	}
+	name = strings.ToLower(name)
+
+	score := textScore
+	switch {
+	case name == q:
+		score += 100
+	case strings.HasPrefix(name, q):
+		score += 50
+	case strings.Contains(name, q):
+		score += 20
+	}
+	if !indexed {
+		// No text score without index: weight of the fields containing query
+		fields := map[string]interface{}{}
+		flattenFields("", obj, fields)
+		for field, weight := range searchFields() {
+			if v, ok := fields[field]; ok && strings.Contains(strings.ToLower(v.(string)), q) {
+				score += float64(weight)
+			}
+		}
+	}
+	return score
+}
+
+// searchRegexFilter: objects where a searched field contains query
+func searchRegexFilter(query string) bson.M {
+	pattern := primitive.Regex{Pattern: regexp.QuoteMeta(query), Options: "i"}
+	or := bson.A{}
+	for field := range searchFields() {
+		or = append(or, bson.M{field: pattern})
+	}
+	return bson.M{"$or": or}
+}
+
+// searchCollection: total of objects of entity matching query and
+// the first max ones (all without text index) with their rank
+func searchCollection(entity, query string, max int64) (int64, []searchResult, error) {
+	ctx, cancel := u.Connect()
+	defer cancel()
+	c := GetDB().Collection(entity)
+
+	var filter bson.M
+	opts := options.Find()
+	indexed := isSearchIndexed(entity)
+	if indexed {
+		filter = bson.M{"$text": bson.M{"$search": query}}
+		opts.SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+			SetSort(bson.M{"score": bson.M{"$meta": "textScore"}}).SetLimit(max)
+	} else {
+		filter = searchRegexFilter(query)
+	}
+
+	total, e := c.CountDocuments(ctx, filter)
+	if e != nil || total == 0 {
+		return 0, nil, e
+	}
+	cursor, e := c.Find(ctx, filter, opts)
+	if e != nil {
+		return 0, nil, e
+	}
+	data, e1 := ExtractCursor(cursor, ctx)
+	if e1 != "" {
+		return 0, nil, errors.New(e1)
+	}
+
+	results := []searchResult{}
+	for _, obj := range data {
+		textScore, _ := obj["score"].(float64)
+		delete(obj, "score")
+		results = append(results, searchResult{searchScore(obj, query, textScore, indexed), obj})
+	}
+	return total, results, nil
+}
+
+// searchPath: hierarchyName of an object, or its name
+// (tenants, stray objects) or its slug (templates)
+func searchPath(obj map[string]interface{}) string {
+	for _, field := range []string{"hierarchyName", "name", "slug"} {
+		if path, ok := obj[field].(string); ok && path != "" {
+			return path
+		}
+	}
+	return ""
+}
+
+// Search: objects and templates of all collections whose name,
+// hierarchyName, slug, description or searched attributes match
+// query, ranked best first. Returns the page (starting at 1) of
+// limit results and the total number of results
+func Search(query string, page, limit int) (map[string]interface{}, string) {
+	if strings.TrimSpace(query) == "" || page < 1 || limit < 1 {
+		return u.Message(false, "Error: q, page and limit should not be empty or negative"), "invalid"
+	}
+
+	var total int64
+	results := []searchResult{}
+	for _, entity := range searchEntities() {
+		count, found, e := searchCollection(entity, query, int64(page*limit))
+		if e != nil {
+			return u.Message(false, "Error while searching "+entity+": "+e.Error()), e.Error()
+		}
+		total += count
+		for _, result := range found {
+			result.data["type"] = entity
+		}
+		results = append(results, found...)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return searchPath(results[i].data) < searchPath(results[j].data)
+	})
+
+	objects := []interface{}{}
+	for i := (page - 1) * limit; i < len(results) && i < page*limit; i++ {
+		obj := fixID(results[i].data)
+		objects = append(objects, map[string]interface{}{
+			"id":       obj["id"],
+			"type":     obj["type"],
+			"category": obj["category"],
+			"name":     obj["name"],
+			"path":     searchPath(obj),
+			"score":    results[i].score,
+		})
+	}
+
+	resp := u.Message(true, "successfully searched objects")
+	resp["data"] = map[string]interface{}{
+		"total": total, "page": page, "limit": limit, "objects": objects,
+	}
+	return resp, ""
+}
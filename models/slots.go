@@ -0,0 +1,201 @@
+package models
+
+import (
+	"errors"
+	u "p3/utils"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Tolerance when comparing sizes in mm
+const mmEpsilon = 1e-6
+
+// toFloat: numbers read from JSON or from mongo
+func toFloat(x interface{}) (float64, bool) {
+	switch v := x.(type) {
+	case float64:
+		return v, true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// getTemplateSlots: slots of the obj_template version of a device,
+// nil if it has no template or if its template is not found
+func getTemplateSlots(device map[string]interface{}) ([]map[string]interface{}, string) {
+	attrs, _ := device["attributes"].(map[string]interface{})
+	slug, _ := attrs["template"].(string)
+	if slug == "" {
+		return nil, ""
+	}
+	version, _ := attrs["templateVersion"].(string)
+	template, _ := getTemplateVersion("obj_template", slug, version)
+	if template == nil {
+		return nil, slug
+	}
+	list, _ := toSlice(template["slots"])
+	slots := []map[string]interface{}{}
+	for _, slot := range list {
+		if s, ok := slot.(map[string]interface{}); ok {
+			slots = append(slots, s)
+		}
+	}
+	return slots, slug
+}
+
+// getDeviceSizeMM: width (x), depth (y) and height of a device in mm
+func getDeviceSizeMM(device map[string]interface{}) (u.Vector, error) {
+	attrs, _ := device["attributes"].(map[string]interface{})
+	size, _ := attrs["size"].(string)
+	sizeUnit, _ := attrs["sizeUnit"].(string)
+	height, _ := attrs["height"].(string)
+	heightUnit, _ := attrs["heightUnit"].(string)
+
+	v, e := u.ParseVector(size)
+	if e != nil {
+		return v, e
+	}
+	if v.X, e = u.ToMillimeters(v.X, sizeUnit); e != nil {
+		return v, e
+	}
+	if v.Y, e = u.ToMillimeters(v.Y, sizeUnit); e != nil {
+		return v, e
+	}
+	h, e := strconv.ParseFloat(height, 64)
+	if e != nil {
+		return v, errors.New("invalid height: '" + height + "'")
+	}
+	v.Z, e = u.ToMillimeters(h, heightUnit)
+	return v, e
+}
+
+// fitsInSlot: the size of a device fits in the elemSize (mm) of a
+// slot, whatever the orientation of the device in its front plane
+func fitsInSlot(size u.Vector, slot map[string]interface{}) bool {
+	elemSize, _ := toSlice(slot["elemSize"])
+	if len(elemSize) != 3 {
+		return false
+	}
+	dims := [3]float64{}
+	for i := range dims {
+		dims[i], _ = toFloat(elemSize[i])
+	}
+	if size.Y > dims[1]+mmEpsilon {
+		return false
+	}
+	return (size.X <= dims[0]+mmEpsilon && size.Z <= dims[2]+mmEpsilon) ||
+		(size.X <= dims[2]+mmEpsilon && size.Z <= dims[0]+mmEpsilon)
+}
+
+func findSlot(slots []map[string]interface{}, location string) map[string]interface{} {
+	for _, slot := range slots {
+		if slot["location"] == location {
+			return slot
+		}
+	}
+	return nil
+}
+
+// checkSlotOccupancy: a device placed in a device whose template has
+// slots should be in one of them, free and big enough. exclude is the
+// ID of the device itself, when it is updated or moved
+func checkSlotOccupancy(device, parent map[string]interface{}, exclude interface{}) (map[string]interface{}, bool) {
+	slots, slug := getTemplateSlots(parent)
+	if len(slots) == 0 {
+		return nil, true
+	}
+	parentName := getHierarchyName(parent)
+	attrs, _ := device["attributes"].(map[string]interface{})
+	location, _ := attrs["slot"].(string)
+	if location == "" {
+		return u.Message(false, "The device "+parentName+" has slots (template "+
+			slug+"), the slot of the device is required"), false
+	}
+	slot := findSlot(slots, location)
+	if slot == nil {
+		return u.Message(false, "The slot "+location+" does not exist in the template "+
+			slug+" of device "+parentName), false
+	}
+
+	siblings, e := getChildDevices(parent)
+	if e != "" {
+		return u.Message(false, "Unable to get the devices of the parent: "+e), false
+	}
+	for _, sibling := range siblings {
+		// Components and slots created from the template are not occupants
+		if (exclude != nil && sibling["id"] == exclude) || isTemplateElement(sibling) {
+			continue
+		}
+		if siblingAttrs, _ := sibling["attributes"].(map[string]interface{}); siblingAttrs["slot"] == location {
+			return u.Message(false, "The slot "+location+" of device "+parentName+
+				" is already used by device "+getHierarchyName(sibling)), false
+		}
+	}
+
+	size, err := getDeviceSizeMM(device)
+	if err != nil {
+		return u.Message(false, "Unable to get the size of the device: "+err.Error()), false
+	}
+	if !fitsInSlot(size, slot) {
+		return u.Message(false, "The device does not fit in the slot "+location+
+			" of device "+parentName), false
+	}
+	return nil, true
+}
+
+// GetDeviceSlots: the slots of the template of a device
+// with the child device in each of them, if any
+func GetDeviceSlots(req bson.M) (map[string]interface{}, string) {
+	device, e := GetEntity(req, "device", u.RequestFilters{})
+	if device == nil {
+		return u.Message(false, "Error while getting device: "+e), e
+	}
+	slots, slug := getTemplateSlots(device)
+	children, e := getChildDevices(device)
+	if e != "" {
+		return u.Message(false, "Error while getting devices: "+e), e
+	}
+
+	childBySlot := map[string]map[string]interface{}{}
+	others := []map[string]interface{}{}
+	for _, child := range children {
+		if isTemplateElement(child) {
+			continue
+		}
+		attrs, _ := child["attributes"].(map[string]interface{})
+		location, _ := attrs["slot"].(string)
+		summary := map[string]interface{}{
+			"id": child["id"], "name": child["name"], "hierarchyName": child["hierarchyName"]}
+		if location != "" && findSlot(slots, location) != nil && childBySlot[location] == nil {
+			childBySlot[location] = summary
+		} else {
+			summary["slot"] = location
+			others = append(others, summary)
+		}
+	}
+
+	slotUsage := []map[string]interface{}{}
+	for _, slot := range slots {
+		location, _ := slot["location"].(string)
+		usage := map[string]interface{}{
+			"location": location, "type": slot["type"],
+			"elemOrient": slot["elemOrient"], "elemSize": slot["elemSize"],
+			"used": childBySlot[location] != nil}
+		if child := childBySlot[location]; child != nil {
+			usage["device"] = child
+		}
+		slotUsage = append(slotUsage, usage)
+	}
+
+	resp := u.Message(true, "successfully got device slots")
+	resp["data"] = map[string]interface{}{
+		"id": device["id"], "hierarchyName": device["hierarchyName"], "template": slug,
+		"slots": slotUsage, "others": others}
+	return resp, ""
+}
@@ -0,0 +1,270 @@
+package models
+
+import (
+	"encoding/json"
+	u "p3/utils"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// bundleTemplates: the template collections of a bundle
+var bundleTemplates = []int{u.OBJTMPL, u.ROOMTMPL, u.BLDGTMPL}
+
+var trailingDigits = regexp.MustCompile(`[0-9]+$`)
+
+// bundleEntity: the template collection of a file of a bundle. It is
+// given by its folder or its name (ex: obj_template/a.json,
+// room-templates/b.json, bldg_template1.json), or else by its category.
+// It is -1 for files that are not templates
+func bundleEntity(name string, template map[string]interface{}) int {
+	for _, part := range []string{path.Base(path.Dir(name)),
+		trailingDigits.ReplaceAllString(strings.TrimSuffix(path.Base(name), ".json"), "")} {
+		part = strings.TrimSuffix(strings.Replace(part, "-", "_", 1), "s")
+		if entity := u.EntityStrToInt(part); entity >= 0 {
+			if isTemplate(entity) {
+				return entity
+			}
+			return -1
+		}
+	}
+	if _, ok := template["slug"]; !ok {
+		return -1
+	}
+	switch template["category"] {
+	case "room":
+		return u.ROOMTMPL
+	case "building":
+		return u.BLDGTMPL
+	}
+	return u.OBJTMPL
+}
+
+// comparableTemplate: a template without the fields set by the API
+func comparableTemplate(template map[string]interface{}) string {
+	doc := map[string]interface{}{}
+	for key, value := range template {
+		switch key {
+		case "id", "_id", "createdDate", "lastUpdated", "revision", "version":
+		default:
+			doc[key] = value
+		}
+	}
+	// Numbers from mongo and from JSON compare the same once in JSON
+	var normalized interface{}
+	data, _ := json.Marshal(doc)
+	json.Unmarshal(data, &normalized)
+	data, _ = json.Marshal(normalized)
+	return string(data)
+}
+
+// ImportTemplateBundle: creates the templates of a bundle, or updates
+// those whose slug exists (creating a new version). All the files are
+// validated first, nothing is imported if one of them is not valid.
+// They are then written all at once: nothing is imported on failure.
+// With dryRun, the result of each file is given but nothing is imported
+func ImportTemplateBundle(files []u.ArchiveFile, dryRun bool) (map[string]interface{}, string) {
+	type bundleItem struct {
+		entity   int
+		template map[string]interface{}
+		existing map[string]interface{}
+		result   map[string]interface{}
+	}
+	items := []*bundleItem{}
+	results := []map[string]interface{}{}
+	slugs := map[string]string{}
+	valid := true
+
+	for _, file := range files {
+		result := map[string]interface{}{"file": file.Name}
+		results = append(results, result)
+		if !strings.HasSuffix(file.Name, ".json") {
+			result["status"] = "ignored"
+			continue
+		}
+		template := map[string]interface{}{}
+		if e := json.Unmarshal(file.Data, &template); e != nil {
+			result["status"] = "invalid"
+			result["message"] = "Invalid JSON: " + e.Error()
+			valid = false
+			continue
+		}
+		entity := bundleEntity(file.Name, template)
+		if entity < 0 {
+			result["status"] = "ignored"
+			result["message"] = "Not a template"
+			continue
+		}
+		entStr := u.EntityToString(entity)
+		slug, _ := template["slug"].(string)
+		result["template"] = entStr
+		result["slug"] = slug
+		for _, key := range []string{"id", "createdDate", "lastUpdated", "revision", "version"} {
+			delete(template, key)
+		}
+
+		if resp, ok := ValidateEntity(entity, template); !ok {
+			result["status"] = "invalid"
+			result["message"] = resp["message"]
+			result["errors"] = resp["errors"]
+			valid = false
+			continue
+		}
+		if other, ok := slugs[entStr+"/"+slug]; ok {
+			result["status"] = "invalid"
+			result["message"] = "The " + entStr + " " + slug + " is also in " + other
+			valid = false
+			continue
+		}
+		slugs[entStr+"/"+slug] = file.Name
+
+		item := &bundleItem{entity: entity, template: template, result: result}
+		existing, e := GetEntity(bson.M{"slug": slug}, entStr, u.RequestFilters{})
+		switch {
+		case existing == nil && e != "mongo: no documents in result":
+			return u.Message(false, "Error while getting "+entStr+" "+slug+": "+e), e
+		case existing == nil:
+			result["status"] = "created"
+		case comparableTemplate(existing) == comparableTemplate(template):
+			result["status"] = "unchanged"
+			continue
+		default:
+			item.existing = existing
+			result["status"] = "updated"
+		}
+		items = append(items, item)
+	}
+
+	if !valid {
+		resp := u.Message(false, "The bundle is not valid, nothing was imported")
+		resp["data"] = map[string]interface{}{"dryRun": dryRun, "files": results}
+		return resp, "invalid"
+	}
+
+	if !dryRun && len(items) > 0 {
+		// All the templates and their new versions are written at once
+		changes := []docChange{}
+		released := []string{}
+		now := primitive.NewDateTimeFromTime(time.Now())
+		for _, item := range items {
+			entStr := u.EntityToString(item.entity)
+			doc := item.template
+			doc["createdDate"] = now
+			doc["lastUpdated"] = now
+			doc["revision"] = int64(1)
+			doc["version"] = int64(1)
+			if item.existing != nil {
+				// The version replaced is kept
+				if e := saveTemplateVersion(entStr, item.existing); e != nil {
+					return u.Message(false, "Error while saving the version of "+entStr+
+						" "+doc["slug"].(string)+": "+e.Error()), e.Error()
+				}
+				old := copyDocument(item.existing)
+				old["_id"] = item.existing["id"]
+				for _, key := range []string{"createdDate", "lastUpdated", "revision", "version"} {
+					if value, ok := item.existing[key]; ok {
+						old[key] = value
+					}
+				}
+				doc["_id"] = old["_id"]
+				if created, ok := item.existing["createdDate"]; ok {
+					doc["createdDate"] = created
+				}
+				doc["revision"] = GetRevision(item.existing) + 1
+				doc["version"] = getTemplateVersionNumber(item.existing) + 1
+				changes = append(changes, docChange{collection: entStr,
+					id: doc["_id"].(primitive.ObjectID), old: old, new: doc, replace: true})
+				released = append(released, removedAssetRefs(item.existing, doc)...)
+			} else {
+				doc["_id"] = primitive.NewObjectID()
+				changes = append(changes, docChange{collection: entStr,
+					id: doc["_id"].(primitive.ObjectID), new: doc})
+			}
+
+			version := bson.M{"_id": primitive.NewObjectID(), "collection": entStr,
+				"slug": doc["slug"], "version": doc["version"], "createdDate": now}
+			saved := map[string]interface{}{}
+			for key, value := range doc {
+				switch key {
+				case "_id", "createdDate", "lastUpdated", "revision":
+				default:
+					saved[key] = value
+				}
+			}
+			version["template"] = saved
+			changes = append(changes, docChange{collection: templateVersionCollection,
+				id: version["_id"].(primitive.ObjectID), new: version})
+		}
+
+		ensureTemplateVersionIndex()
+		if err := applyChanges(changes); err != nil {
+			resp := u.Message(false, "Error while importing the bundle, nothing was imported: "+err.Error())
+			resp["data"] = map[string]interface{}{"dryRun": dryRun, "files": results}
+			if strings.Contains(err.Error(), "E11000") || strings.Contains(err.Error(), "modified by another request") {
+				return resp, "conflict"
+			}
+			return resp, err.Error()
+		}
+
+		for _, change := range changes {
+			if change.collection == templateVersionCollection {
+				continue
+			}
+			if change.replace {
+				publishEvent("updated", change.collection, fixID(change.new))
+			} else {
+				publishEvent("created", change.collection, fixID(change.new))
+			}
+		}
+		releaseAssets(released)
+	}
+
+	resp := u.Message(true, "successfully imported the bundle")
+	if dryRun {
+		resp = u.Message(true, "successfully checked the bundle, nothing was imported")
+	}
+	resp["data"] = map[string]interface{}{"dryRun": dryRun, "files": results}
+	return resp, ""
+}
+
+// ExportTemplateBundle: the templates as bundle files (one folder
+// per template collection). Only the given template collections are
+// exported if any, and only the templates with the given slugs or
+// with one of the given tags if any
+func ExportTemplateBundle(entities []int, slugs, tags []string) ([]u.ArchiveFile, string) {
+	if len(entities) == 0 {
+		entities = bundleTemplates
+	}
+	req := bson.M{}
+	if len(slugs) > 0 {
+		req["slug"] = bson.M{"$in": slugs}
+	}
+	if len(tags) > 0 {
+		req["tags"] = bson.M{"$in": tags}
+	}
+
+	files := []u.ArchiveFile{}
+	for _, entity := range entities {
+		entStr := u.EntityToString(entity)
+		templates, e := GetManyEntities(entStr, req, u.RequestFilters{})
+		if e != "" {
+			return nil, e
+		}
+		sort.Slice(templates, func(i, j int) bool {
+			return templates[i]["slug"].(string) < templates[j]["slug"].(string)
+		})
+		for _, template := range templates {
+			doc := map[string]interface{}{}
+			json.Unmarshal([]byte(comparableTemplate(template)), &doc)
+			data, _ := json.MarshalIndent(doc, "", "    ")
+			files = append(files, u.ArchiveFile{
+				Name: entStr + "/" + template["slug"].(string) + ".json", Data: data})
+		}
+	}
+	return files, ""
+}
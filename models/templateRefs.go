@@ -0,0 +1,211 @@
+package models
+
+import (
+	u "p3/utils"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// templateCollection: collection of the templates that the
+// objects of an entity reference with their template attribute
+func templateCollection(entity int) string {
+	switch entity {
+	case u.BLDG:
+		return "bldg_template"
+	case u.ROOM:
+		return "room_template"
+	case u.RACK, u.DEVICE, u.STRAYDEV:
+		return "obj_template"
+	}
+	return ""
+}
+
+// templateUsers: entities whose objects can reference a template
+func templateUsers(entity int) []int {
+	switch entity {
+	case u.BLDGTMPL:
+		return []int{u.BLDG}
+	case u.ROOMTMPL:
+		return []int{u.ROOM}
+	case u.OBJTMPL:
+		return []int{u.RACK, u.DEVICE, u.STRAYDEV}
+	}
+	return nil
+}
+
+func isTemplate(entity int) bool {
+	return entity == u.BLDGTMPL || entity == u.ROOMTMPL || entity == u.OBJTMPL
+}
+
+// validateTemplateRef: the template referenced by the attributes of
+// an object, if any (an empty template is no template), should exist.
+// It can be given as "slug@version", the version of the template
+// the object is built from is kept in its templateVersion attribute,
+// it is the current version of the template if not given
+func validateTemplateRef(entity int, attrs map[string]interface{}) (map[string]interface{}, bool) {
+	collection := templateCollection(entity)
+	ref, _ := attrs["template"].(string)
+	if collection == "" || ref == "" {
+		return nil, true
+	}
+	slug, version := splitTemplateRef(ref)
+	if version == "" {
+		version, _ = attrs["templateVersion"].(string)
+	}
+	template, e := getTemplateVersion(collection, slug, version)
+	if template == nil {
+		if e != "mongo: no documents in result" && e != "invalid version" {
+			return u.Message(false, "Unable to check the template: "+e), false
+		}
+		return u.Message(false, "The "+collection+" "+ref+" does not exist"), false
+	}
+	attrs["template"] = slug
+	attrs["templateVersion"] = strconv.FormatInt(getTemplateVersionNumber(template), 10)
+	return nil, true
+}
+
+// getTemplateUsages: objects referencing the template slug
+func getTemplateUsages(entity int, slug string) ([]map[string]interface{}, string) {
+	usages := []map[string]interface{}{}
+	for _, user := range templateUsers(entity) {
+		objs, e := GetManyEntities(u.EntityToString(user),
+			bson.M{"attributes.template": slug}, u.RequestFilters{})
+		if e != "" {
+			return nil, e
+		}
+		for _, obj := range objs {
+			usages = append(usages, map[string]interface{}{
+				"id": obj["id"], "category": u.EntityToString(user),
+				"hierarchyName": getHierarchyName(obj)})
+		}
+	}
+	return usages, ""
+}
+
+// GetTemplateUsages: objects referencing a template
+func GetTemplateUsages(entity int, slug string) (map[string]interface{}, string) {
+	entStr := u.EntityToString(entity)
+	template, e := GetEntity(bson.M{"slug": slug}, entStr, u.RequestFilters{})
+	if template == nil {
+		return u.Message(false, "Error while getting "+entStr+": "+e), e
+	}
+	usages, e := getTemplateUsages(entity, slug)
+	if e != "" {
+		return u.Message(false, "Error while getting the usages of "+entStr+": "+e), e
+	}
+	resp := u.Message(true, "successfully got "+entStr+" usages")
+	resp["data"] = map[string]interface{}{"slug": slug, "count": len(usages), "usages": usages}
+	return resp, ""
+}
+
+// detachChanges: the changes detaching the objects referencing
+// the template slug (their template attribute is emptied), each
+// of them only if it is not modified in the meantime
+func detachChanges(entity int, slug string) ([]docChange, error) {
+	now := primitive.NewDateTimeFromTime(time.Now())
+	changes := []docChange{}
+	for _, user := range templateUsers(entity) {
+		collection := u.EntityToString(user)
+		docs, e := findDocuments(collection, bson.M{"attributes.template": slug})
+		if e != nil {
+			return nil, e
+		}
+		for _, doc := range docs {
+			detached := copyDocument(doc)
+			attrs := detached["attributes"].(map[string]interface{})
+			attrs["template"] = ""
+			delete(attrs, "templateVersion")
+			detached["createdDate"] = doc["createdDate"]
+			detached["lastUpdated"] = now
+			detached["revision"] = GetRevision(doc) + 1
+			changes = append(changes, docChange{collection: collection,
+				id: doc["_id"].(primitive.ObjectID), old: doc, new: detached, replace: true})
+		}
+	}
+	return changes, nil
+}
+
+// DeleteTemplate: deletes a template that no object references. With
+// force, the objects referencing it are detached from it (their
+// template attribute is emptied) in the same transaction. An object
+// created with the template while it is deleted is detached too with
+// force, otherwise the template is restored
+func DeleteTemplate(entity int, req bson.M, force bool, revisions []int64) (map[string]interface{}, string) {
+	entStr := u.EntityToString(entity)
+	docs, err := findDocuments(entStr, req)
+	if err != nil {
+		return u.Message(false, "Error while getting "+entStr+": "+err.Error()), err.Error()
+	} else if len(docs) == 0 {
+		return u.Message(false, "Error while getting "+entStr+": not found"), "not found"
+	}
+	template := docs[0]
+	if !u.ETagMatches(revisions, GetRevision(template)) {
+		return preconditionFailedMessage(), "precondition failed"
+	}
+	slug, _ := template["slug"].(string)
+	usages, e := getTemplateUsages(entity, slug)
+	if e != "" {
+		return u.Message(false, "Error while getting the usages of "+entStr+": "+e), e
+	}
+
+	if len(usages) > 0 && !force {
+		resp := u.Message(false, "The "+entStr+" "+slug+" is used by "+
+			strconv.Itoa(len(usages))+" object(s), delete them or use force to detach them")
+		resp["data"] = usages
+		return resp, "in use"
+	}
+
+	changes := []docChange{}
+	if force {
+		if changes, err = detachChanges(entity, slug); err != nil {
+			return u.Message(false, "Error while detaching the objects of "+
+				entStr+" "+slug+": "+err.Error()), err.Error()
+		}
+	}
+	// The template is only deleted with the revision that was checked
+	changes = append(changes, docChange{collection: entStr,
+		id: template["_id"].(primitive.ObjectID), old: template, replace: true})
+	if err := applyChanges(changes); err != nil {
+		if isModifiedError(err) {
+			return u.Message(false, "Error while deleting "+entStr+" "+slug+": "+
+				err.Error()+", nothing was changed"), "conflict"
+		}
+		return u.Message(false, "Error while deleting "+entStr+" "+slug+": "+err.Error()), err.Error()
+	}
+
+	// Objects created with the template between the check and the delete
+	if late, e := getTemplateUsages(entity, slug); e == "" && len(late) > 0 {
+		if !force {
+			applyChanges([]docChange{{collection: entStr,
+				id: template["_id"].(primitive.ObjectID), new: template}})
+			resp := u.Message(false, "The "+entStr+" "+slug+" is used by "+
+				strconv.Itoa(len(late))+" object(s), delete them or use force to detach them")
+			resp["data"] = late
+			return resp, "in use"
+		}
+		lateChanges, err := detachChanges(entity, slug)
+		if err == nil {
+			err = applyChanges(lateChanges)
+		}
+		if err != nil {
+			return u.Message(false, "The "+entStr+" "+slug+" is deleted but objects created "+
+				"with it in the meantime could not be detached: "+err.Error()), err.Error()
+		}
+		changes = append(changes, lateChanges...)
+	}
+
+	for _, change := range changes {
+		if change.new == nil {
+			publishEvent("deleted", entStr, map[string]interface{}{"id": change.id, "slug": slug})
+		} else {
+			publishEvent("updated", change.collection, map[string]interface{}{
+				"id": change.id, "hierarchyName": change.new["hierarchyName"]})
+		}
+	}
+	refs := deleteTemplateVersions(entStr, slug)
+	releaseAssets(append(refs, docAssetRefs(template)...))
+	return u.Message(true, "success"), ""
+}
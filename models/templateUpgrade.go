@@ -0,0 +1,402 @@
+package models
+
+import (
+	"fmt"
+	u "p3/utils"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// generatedAttributes: attributes that a version of a template
+// gives to the objects built from it (see applyObjTemplate)
+func generatedAttributes(entity int, template map[string]interface{}) map[string]interface{} {
+	attrs := map[string]interface{}{}
+	if template == nil {
+		return attrs
+	}
+	switch entity {
+	case u.OBJTMPL:
+		applyObjTemplate(attrs, template)
+	case u.ROOMTMPL:
+		applyRoomTemplate(attrs, template)
+	}
+	return attrs
+}
+
+// instanceUpgrade: the changes made to one object
+// by an upgrade and the conflicts preventing it
+type instanceUpgrade struct {
+	report  map[string]interface{}
+	changes []docChange
+}
+
+func (up *instanceUpgrade) change(msg string) {
+	up.report["changes"] = append(up.report["changes"].([]string), msg)
+}
+
+func (up *instanceUpgrade) conflict(msg string) {
+	up.report["conflicts"] = append(up.report["conflicts"].([]string), msg)
+}
+
+// upgradeAttributes: attributes of the object coming from the template
+// are updated, unless they were changed since the object was built
+func upgradeAttributes(up *instanceUpgrade, obj map[string]interface{}, oldGen, newGen map[string]interface{}) (set, old bson.M) {
+	attrs, _ := obj["attributes"].(map[string]interface{})
+	set, old = bson.M{}, bson.M{}
+	keys := []string{}
+	for key := range newGen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		current, exists := attrs[key]
+		if exists && current == newGen[key] {
+			continue
+		}
+		if exists && current != "" && current != oldGen[key] {
+			up.change("attribute " + key + " kept, it differs from the template")
+			continue
+		}
+		set["attributes."+key] = newGen[key]
+		old["attributes."+key] = current
+		up.change(fmt.Sprintf("attribute %s: %v -> %v", key, current, newGen[key]))
+	}
+	return set, old
+}
+
+// checkUpgradedPlacement: the devices of an upgraded rack should still
+// fit in it and the rack in its room, an upgraded device should still
+// fit in its rack or slot, and the devices in the slots of an upgraded
+// device should still have a slot big enough
+func checkUpgradedPlacement(up *instanceUpgrade, entity int, obj, upgraded map[string]interface{}, target map[string]interface{}, resized bool) {
+	if entity == u.STRAYDEV {
+		return
+	}
+	if resized && obj["category"] == "rack" {
+		height, err := getRackHeightU(upgraded)
+		if err != nil {
+			up.conflict("Unable to get the new height of the rack: " + err.Error())
+		} else if devices, e := getChildDevices(obj); e == "" {
+			for _, device := range devices {
+				if r, ok, err := getDeviceURange(device); ok && err == nil && !r.Fits(height) {
+					up.conflict("The device " + getHierarchyName(device) + " (" + describeURange(r) +
+						") would not fit in the rack of " + strconv.FormatFloat(height, 'f', -1, 64) + "U")
+				}
+			}
+		}
+	}
+	if resized && hasFootprint(entity) {
+		if parentId, _ := obj["parentId"].(string); parentId != "" {
+			if room := getParentObject(entity, parentId); room != nil {
+				if resp, ok := checkRoomPlacement(upgraded, room, obj["id"]); !ok {
+					up.conflict(resp["message"].(string))
+				}
+			}
+		}
+	}
+	if resized && obj["category"] == "device" {
+		if parentId, _ := obj["parentId"].(string); parentId != "" {
+			if parent := getParentObject(u.DEVICE, parentId); parent != nil {
+				if resp, ok := checkDevicePlacement(upgraded, parent, obj["id"]); !ok {
+					up.conflict(resp["message"].(string))
+				}
+			}
+		}
+	}
+	if obj["category"] != "device" {
+		return
+	}
+
+	children, e := getChildDevices(obj)
+	if e != "" {
+		up.conflict("Unable to get the devices of the object: " + e)
+		return
+	}
+	list, _ := toSlice(target["slots"])
+	slots := []map[string]interface{}{}
+	for _, slot := range list {
+		if s, ok := slot.(map[string]interface{}); ok {
+			slots = append(slots, s)
+		}
+	}
+	for _, child := range children {
+		attrs, _ := child["attributes"].(map[string]interface{})
+		location, _ := attrs["slot"].(string)
+		if location == "" || isTemplateElement(child) {
+			continue
+		}
+		slot := findSlot(slots, location)
+		if slot == nil {
+			up.conflict("The slot " + location + " is used by device " +
+				getHierarchyName(child) + " and would disappear")
+		} else if size, err := getDeviceSizeMM(child); err == nil && !fitsInSlot(size, slot) {
+			up.conflict("The device " + getHierarchyName(child) +
+				" would not fit in the slot " + location + " anymore")
+		}
+	}
+}
+
+// upgradeElements: the components and slots created from the template
+// with the object (see InstantiateEntity) are added, updated or removed
+// to match the new version. Objects that were not instantiated are left
+func upgradeElements(up *instanceUpgrade, obj, target map[string]interface{}, now primitive.DateTime) {
+	children, e := getChildDevices(obj)
+	if e != "" {
+		up.conflict("Unable to get the devices of the object: " + e)
+		return
+	}
+	byName := map[string]map[string]interface{}{}
+	instantiated := false
+	for _, child := range children {
+		byName[child["name"].(string)] = child
+		instantiated = instantiated || isTemplateElement(child)
+	}
+	if !instantiated {
+		return
+	}
+
+	elements, err := templateElements(map[string]interface{}{
+		"_id": obj["id"], "domain": obj["domain"]}, target)
+	if err != nil {
+		up.conflict(err.Error())
+		return
+	}
+	names := map[string]bool{}
+	for _, element := range elements {
+		name := element["name"].(string)
+		names[name] = true
+		existing := byName[name]
+		if existing == nil {
+			if resp, ok := validateJsonSchema(u.DEVICE, element); !ok {
+				up.conflict("The element " + name + " is not valid: " + resp["message"].(string))
+				continue
+			}
+			element["_id"] = primitive.NewObjectID()
+			element["hierarchyName"] = getHierarchyName(obj) + "." + name
+			element["createdDate"] = now
+			element["lastUpdated"] = now
+			element["revision"] = int64(1)
+			up.changes = append(up.changes, docChange{collection: "device",
+				id: element["_id"].(primitive.ObjectID), new: element})
+			up.change("element " + name + " added")
+			continue
+		}
+		if !isTemplateElement(existing) {
+			up.conflict("The element " + name + " of the template clashes with device " +
+				getHierarchyName(existing))
+			continue
+		}
+		attrs, _ := existing["attributes"].(map[string]interface{})
+		set, old := bson.M{}, bson.M{}
+		for key, value := range element["attributes"].(map[string]interface{}) {
+			if attrs[key] != value {
+				set["attributes."+key] = value
+				old["attributes."+key] = attrs[key]
+			}
+		}
+		if len(set) > 0 {
+			set["lastUpdated"] = now
+			old["lastUpdated"] = existing["lastUpdated"]
+			up.changes = append(up.changes, docChange{collection: "device",
+				id: existing["id"].(primitive.ObjectID), old: old, new: set})
+			up.change("element " + name + " updated")
+		}
+	}
+
+	for name, child := range byName {
+		if names[name] || !isTemplateElement(child) {
+			continue
+		}
+		if grandChildren, _ := getChildDevices(child); len(grandChildren) > 0 {
+			up.conflict("The element " + name + " would be removed but it has devices")
+			continue
+		}
+		doc := map[string]interface{}{}
+		for key, value := range child {
+			doc[key] = value
+		}
+		doc["_id"] = doc["id"]
+		delete(doc, "id")
+		up.changes = append(up.changes, docChange{collection: "device",
+			id: child["id"].(primitive.ObjectID), old: doc})
+		up.change("element " + name + " removed")
+	}
+}
+
+// instanceRacks: ids of the racks among objects built
+// from an obj_template and of the racks of the devices
+func instanceRacks(instances map[int][]map[string]interface{}) []string {
+	ids := []string{}
+	for _, rack := range instances[u.RACK] {
+		ids = append(ids, rack["id"].(primitive.ObjectID).Hex())
+	}
+	parents := map[string]bool{}
+	for _, device := range instances[u.DEVICE] {
+		if parentId, _ := device["parentId"].(string); parentId != "" && !parents[parentId] {
+			parents[parentId] = true
+			if parent := getParentObject(u.DEVICE, parentId); parent != nil && parent["category"] == "rack" {
+				ids = append(ids, parentId)
+			}
+		}
+	}
+	return ids
+}
+
+// instanceRooms: ids of the rooms of the racks among instances
+func instanceRooms(instances map[int][]map[string]interface{}) []string {
+	ids := []string{}
+	rooms := map[string]bool{}
+	for _, rack := range instances[u.RACK] {
+		if parentId, _ := rack["parentId"].(string); parentId != "" && !rooms[parentId] {
+			rooms[parentId] = true
+			ids = append(ids, parentId)
+		}
+	}
+	return ids
+}
+
+// UpgradeTemplateInstances: upgrades the objects built from a template
+// to its version to (0 for its current version). Only the objects built
+// from the version from are upgraded if from is not 0, and only those
+// under root if root is not empty. With dryRun, nothing is written: the
+// changes and conflicts are only reported. Objects with conflicts are
+// not upgraded, the others are all upgraded or none of them
+func UpgradeTemplateInstances(entity int, slug string, from, to int64, root string, dryRun bool) (map[string]interface{}, string) {
+	entStr := u.EntityToString(entity)
+	version := ""
+	if to > 0 {
+		version = strconv.FormatInt(to, 10)
+	}
+	target, e := getTemplateVersion(entStr, slug, version)
+	if target == nil {
+		return u.Message(false, "Error while getting "+entStr+" "+slug+"@"+version+": "+e), e
+	}
+	to = getTemplateVersionNumber(target)
+	toStr := strconv.FormatInt(to, 10)
+	newGen := generatedAttributes(entity, target)
+
+	req := bson.M{"attributes.template": slug}
+	if root != "" {
+		req["hierarchyName"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(root) + `(\.|$)`}
+	}
+	versions := map[string]map[string]interface{}{toStr: target}
+	now := primitive.NewDateTimeFromTime(time.Now())
+	reports := []map[string]interface{}{}
+	changes := []docChange{}
+	upgraded, conflicts := 0, 0
+
+	instances := map[int][]map[string]interface{}{}
+	for _, user := range templateUsers(entity) {
+		objs, e := GetManyEntities(u.EntityToString(user), req, u.RequestFilters{})
+		if e != "" {
+			return u.Message(false, "Error while getting the objects of "+entStr+": "+e), e
+		}
+		instances[user] = objs
+	}
+
+	// Devices are placed in a rack one at a time: the racks upgraded
+	// and those of the devices upgraded are locked
+	if entity == u.OBJTMPL && !dryRun {
+		unlock, resp, e := lockRacks(instanceRacks(instances)...)
+		if e != "" {
+			return resp, e
+		}
+		defer unlock()
+		// and the rooms of the racks upgraded
+		unlockRooms, resp, e := lockRooms(instanceRooms(instances)...)
+		if e != "" {
+			return resp, e
+		}
+		defer unlockRooms()
+	}
+
+	for _, user := range templateUsers(entity) {
+		for _, obj := range instances[user] {
+			attrs, _ := obj["attributes"].(map[string]interface{})
+			current, _ := attrs["templateVersion"].(string)
+			if current == "" {
+				current = "1"
+			}
+			if current == toStr || (from > 0 && current != strconv.FormatInt(from, 10)) {
+				continue
+			}
+
+			up := &instanceUpgrade{report: map[string]interface{}{
+				"id": obj["id"], "category": u.EntityToString(user),
+				"hierarchyName": getHierarchyName(obj), "from": current, "to": toStr,
+				"changes": []string{}, "conflicts": []string{}}}
+			if _, ok := versions[current]; !ok {
+				versions[current], _ = getTemplateVersion(entStr, slug, current)
+			}
+			if versions[current] == nil {
+				up.change("version " + current + " of the template not found, " +
+					"all the attributes from the template are updated")
+			}
+
+			set, old := upgradeAttributes(up, obj, generatedAttributes(entity, versions[current]), newGen)
+			set["attributes.templateVersion"] = toStr
+			old["attributes.templateVersion"] = attrs["templateVersion"]
+			set["lastUpdated"] = now
+			old["lastUpdated"] = obj["lastUpdated"]
+			up.changes = append(up.changes, docChange{collection: u.EntityToString(user),
+				id: obj["id"].(primitive.ObjectID), old: old, new: set})
+
+			if entity == u.OBJTMPL {
+				upgraded := applyFlatPatch(obj, set)
+				_, resized := set["attributes.size"]
+				for _, key := range []string{"sizeUnit", "height", "heightUnit"} {
+					_, changed := set["attributes."+key]
+					resized = resized || changed
+				}
+				checkUpgradedPlacement(up, user, obj, upgraded, target, resized)
+				if user != u.STRAYDEV {
+					upgradeElements(up, obj, target, now)
+				}
+			}
+
+			if len(up.report["conflicts"].([]string)) > 0 {
+				conflicts++
+				up.report["upgraded"] = false
+			} else {
+				up.report["upgraded"] = !dryRun
+				changes = append(changes, up.changes...)
+				if !dryRun {
+					upgraded++
+				}
+			}
+			reports = append(reports, up.report)
+		}
+	}
+
+	if !dryRun && len(changes) > 0 {
+		if err := applyChanges(changes); err != nil {
+			return u.Message(false, "Error while upgrading the objects of "+entStr+": "+err.Error()), err.Error()
+		}
+		for _, change := range changes {
+			switch {
+			case change.old == nil:
+				publishEvent("created", change.collection, fixID(change.new))
+			case change.new == nil:
+				publishEvent("deleted", change.collection, map[string]interface{}{
+					"id": change.id, "hierarchyName": getHierarchyName(change.old)})
+			default:
+				publishEvent("updated", change.collection, map[string]interface{}{"id": change.id})
+			}
+		}
+	}
+
+	message := "successfully upgraded the objects of " + entStr
+	if dryRun {
+		message = "successfully previewed the upgrade of the objects of " + entStr
+	}
+	resp := u.Message(true, message)
+	resp["data"] = map[string]interface{}{
+		"slug": slug, "to": toStr, "dryRun": dryRun, "objects": reports,
+		"upgraded": upgraded, "conflicts": conflicts}
+	return resp, ""
+}
@@ -0,0 +1,209 @@
+package models
+
+import (
+	"errors"
+	u "p3/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Versions of the templates, one document per template version
+// holding the template as it was: they are never modified
+const templateVersionCollection = "template_version"
+
+var templateVersionIndexOnce sync.Once
+
+func ensureTemplateVersionIndex() {
+	templateVersionIndexOnce.Do(func() {
+		ctx, cancel := u.Connect()
+		defer cancel()
+		_, e := GetDB().Collection(templateVersionCollection).Indexes().CreateOne(ctx,
+			mongo.IndexModel{
+				Keys: bson.D{{Key: "collection", Value: 1},
+					{Key: "slug", Value: 1}, {Key: "version", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			})
+		if e != nil {
+			println("Unable to create template version index:", e.Error())
+		}
+	})
+}
+
+// getTemplateVersionNumber: version of a template, 1 for
+// the templates created before versions existed
+func getTemplateVersionNumber(template map[string]interface{}) int64 {
+	switch v := template["version"].(type) {
+	case int64:
+		return v
+	case int32:
+		return int64(v)
+	case float64:
+		return int64(v)
+	}
+	return 1
+}
+
+// splitTemplateRef: slug and version of a template
+// reference "slug@version", the version is optional
+func splitTemplateRef(ref string) (string, string) {
+	if i := strings.LastIndex(ref, "@"); i >= 0 {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, ""
+}
+
+// saveTemplateVersion: stores a template as its current version.
+// An existing version is kept as it is
+func saveTemplateVersion(collection string, template map[string]interface{}) error {
+	ensureTemplateVersionIndex()
+	doc := map[string]interface{}{}
+	for key, value := range template {
+		switch key {
+		case "_id", "id", "createdDate", "lastUpdated", "revision":
+		default:
+			doc[key] = value
+		}
+	}
+	if _, ok := doc["version"]; !ok {
+		doc["version"] = int64(1)
+	}
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+	_, e := GetDB().Collection(templateVersionCollection).UpdateOne(ctx,
+		bson.M{"collection": collection, "slug": doc["slug"], "version": getTemplateVersionNumber(doc)},
+		bson.M{"$setOnInsert": bson.M{"template": doc,
+			"createdDate": primitive.NewDateTimeFromTime(time.Now())}},
+		options.Update().SetUpsert(true))
+	if e != nil && strings.Contains(e.Error(), "E11000") {
+		// Saved at the same time by another request
+		return nil
+	}
+	return e
+}
+
+// getTemplateVersion: a version of a template, its
+// current version if version is empty
+func getTemplateVersion(collection, slug, version string) (map[string]interface{}, string) {
+	current, e := GetEntity(bson.M{"slug": slug}, collection, u.RequestFilters{})
+	if current == nil {
+		return nil, e
+	}
+	if version == "" {
+		return current, ""
+	}
+	v, err := strconv.ParseInt(version, 10, 64)
+	if err != nil || v < 1 {
+		return nil, "invalid version"
+	}
+	if v == getTemplateVersionNumber(current) {
+		return current, ""
+	}
+	saved, e := GetEntity(bson.M{"collection": collection, "slug": slug, "version": v},
+		templateVersionCollection, u.RequestFilters{})
+	if saved == nil {
+		return nil, e
+	}
+	template, _ := saved["template"].(map[string]interface{})
+	if template == nil {
+		return nil, "invalid version"
+	}
+	return template, ""
+}
+
+// GetTemplateVersion: a template given by its "slug@version"
+func GetTemplateVersion(ent, ref string) (map[string]interface{}, string) {
+	slug, version := splitTemplateRef(ref)
+	return getTemplateVersion(ent, slug, version)
+}
+
+// GetTemplateVersions: the versions of a template, oldest first
+func GetTemplateVersions(ent, slug string) (map[string]interface{}, string) {
+	current, e := GetEntity(bson.M{"slug": slug}, ent, u.RequestFilters{})
+	if current == nil {
+		return u.Message(false, "Error while getting "+ent+": "+e), e
+	}
+	saved, e := GetManyEntities(templateVersionCollection,
+		bson.M{"collection": ent, "slug": slug}, u.RequestFilters{})
+	if e != "" {
+		return u.Message(false, "Error while getting the versions of "+ent+": "+e), e
+	}
+
+	versions := []map[string]interface{}{}
+	found := false
+	for _, v := range saved {
+		template, _ := v["template"].(map[string]interface{})
+		number := getTemplateVersionNumber(template)
+		found = found || number == getTemplateVersionNumber(current)
+		versions = append(versions, map[string]interface{}{
+			"version": number, "ref": slug + "@" + strconv.FormatInt(number, 10),
+			"createdDate": v["createdDate"]})
+	}
+	if !found {
+		// Template created before versions existed
+		number := getTemplateVersionNumber(current)
+		versions = append(versions, map[string]interface{}{
+			"version": number, "ref": slug + "@" + strconv.FormatInt(number, 10),
+			"createdDate": current["lastUpdated"]})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i]["version"].(int64) < versions[j]["version"].(int64)
+	})
+
+	resp := u.Message(true, "successfully got "+ent+" versions")
+	resp["data"] = map[string]interface{}{"slug": slug,
+		"version": getTemplateVersionNumber(current), "versions": versions}
+	return resp, ""
+}
+
+// nextTemplateVersion: an update of a template creates a new version,
+// the previous one is saved first if it was not. The slug of a
+// template can't be changed, versions and objects refer to it
+func nextTemplateVersion(ent string, old, t map[string]interface{}, isPatch bool) error {
+	if slug, ok := t["slug"]; (ok || !isPatch) && slug != old["slug"] {
+		return errors.New("The slug of a template can't be changed")
+	}
+	if e := saveTemplateVersion(ent, old); e != nil {
+		return e
+	}
+	t["version"] = getTemplateVersionNumber(old) + 1
+	return nil
+}
+
+// templateVersionFilter: condition on the version of a template
+// as it was read, so that only one update can create the next version
+func templateVersionFilter(template map[string]interface{}) bson.M {
+	version := getTemplateVersionNumber(template)
+	in := bson.A{version}
+	if version == 1 {
+		// templates created before versions existed
+		in = append(in, nil)
+	}
+	return bson.M{"$in": in}
+}
+
+// deleteTemplateVersions: removes all the versions of a
+// template, returns the assets they referenced
+func deleteTemplateVersions(collection, slug string) []string {
+	req := bson.M{"collection": collection, "slug": slug}
+	refs := []string{}
+	versions, _ := GetManyEntities(templateVersionCollection, req, u.RequestFilters{})
+	for _, version := range versions {
+		if template, ok := version["template"].(map[string]interface{}); ok {
+			refs = append(refs, docAssetRefs(template)...)
+		}
+	}
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+	GetDB().Collection(templateVersionCollection).DeleteMany(ctx, req)
+	return refs
+}
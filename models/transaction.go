@@ -0,0 +1,179 @@
+package models
+
+import (
+	"context"
+	"errors"
+	u "p3/utils"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// docChange: a write on one document. If old is nil, new
+// is a whole document to insert. If new is nil, old is a whole
+// document to delete. With replace, new is a whole document
+// replacing old (or nil to delete it), which should not have been
+// changed since it was read. Otherwise new holds the values to $set
+// and old their previous values (to roll back). With checked, the
+// document should still hold old and be at revision to be updated
+type docChange struct {
+	collection string
+	id         primitive.ObjectID
+	old        bson.M
+	new        bson.M
+	replace    bool
+	checked    bool
+	revision   int64
+}
+
+// applyChanges: applies all the changes or none of them.
+// A transaction is used when mongo supports it (replica set),
+// otherwise the changes already applied are undone on failure.
+// The objects written are recorded in their history
+func applyChanges(changes []docChange) error {
+	before := historyBefore(changes)
+	if e := writeChanges(changes); e != nil {
+		return e
+	}
+	recordChanges(changes, before)
+	return nil
+}
+
+func writeChanges(changes []docChange) error {
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	session, e := GetDB().Client().StartSession()
+	if e == nil {
+		defer session.EndSession(ctx)
+		_, e = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+			for _, change := range changes {
+				if e := applyChange(sc, change, false); e != nil {
+					return nil, e
+				}
+			}
+			return nil, nil
+		})
+		if e == nil || !transactionsUnsupported(e) {
+			return e
+		}
+	}
+
+	// Standalone server
+	for i, change := range changes {
+		if e := applyChange(ctx, change, false); e != nil {
+			for j := i - 1; j >= 0; j-- {
+				applyChange(ctx, changes[j], true)
+			}
+			return e
+		}
+	}
+	return nil
+}
+
+// runTransaction: runs fn in a transaction when mongo supports
+// it, otherwise runs it as is and calls undo when it fails
+func runTransaction(fn func(ctx context.Context) error, undo func(ctx context.Context)) error {
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	session, e := GetDB().Client().StartSession()
+	if e == nil {
+		defer session.EndSession(ctx)
+		_, e = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+			return nil, fn(sc)
+		})
+		if e == nil || !transactionsUnsupported(e) {
+			return e
+		}
+	}
+
+	// Standalone server
+	if e := fn(ctx); e != nil {
+		undo(ctx)
+		return e
+	}
+	return nil
+}
+
+func applyChange(ctx context.Context, change docChange, rollback bool) error {
+	coll := GetDB().Collection(change.collection)
+	var e error
+	switch {
+	case change.old == nil && !rollback:
+		_, e = coll.InsertOne(ctx, change.new)
+	case change.old == nil:
+		_, e = coll.DeleteOne(ctx, bson.M{"_id": change.id})
+	case change.new == nil && change.replace && !rollback:
+		var res *mongo.DeleteResult
+		res, e = coll.DeleteOne(ctx,
+			withRevision(bson.M{"_id": change.id}, []int64{GetRevision(change.old)}))
+		if e == nil && res.DeletedCount == 0 {
+			e = modifiedError(change.collection)
+		}
+	case change.new == nil && !rollback:
+		_, e = coll.DeleteOne(ctx, bson.M{"_id": change.id})
+	case change.new == nil:
+		_, e = coll.InsertOne(ctx, change.old)
+	case change.replace && !rollback:
+		var res *mongo.UpdateResult
+		res, e = coll.ReplaceOne(ctx,
+			withRevision(bson.M{"_id": change.id}, []int64{GetRevision(change.old)}), change.new)
+		if e == nil && res.MatchedCount == 0 {
+			e = modifiedError(change.collection)
+		}
+	case change.replace:
+		_, e = coll.ReplaceOne(ctx, bson.M{"_id": change.id}, change.old)
+	case change.checked && !rollback:
+		filter := bson.M{"_id": change.id}
+		for k, v := range change.old {
+			filter[k] = v
+		}
+		var res *mongo.UpdateResult
+		res, e = coll.UpdateOne(ctx, withRevision(filter, []int64{change.revision}),
+			bson.M{"$set": change.new, "$inc": bson.M{"revision": int64(1)}})
+		if e == nil && res.MatchedCount == 0 {
+			e = modifiedError(change.collection)
+		}
+	case !rollback:
+		_, e = coll.UpdateOne(ctx, bson.M{"_id": change.id},
+			bson.M{"$set": change.new, "$inc": bson.M{"revision": int64(1)}})
+	default:
+		_, e = coll.UpdateOne(ctx, bson.M{"_id": change.id},
+			bson.M{"$set": change.old, "$inc": bson.M{"revision": int64(1)}})
+	}
+	return e
+}
+
+// modifiedError: a replace or a delete found a newer revision
+func modifiedError(collection string) error {
+	return errors.New("the " + collection + " has been modified by another request")
+}
+
+// isModifiedError: the changes failed because of a concurrent request
+func isModifiedError(e error) bool {
+	return strings.Contains(e.Error(), "has been modified by another request")
+}
+
+// findDocuments: documents of collection matching req as they are
+// stored (with their _id), to build the changes of applyChanges
+func findDocuments(collection string, req bson.M) ([]map[string]interface{}, error) {
+	ctx, cancel := u.Connect()
+	defer cancel()
+	c, e := GetDB().Collection(collection).Find(ctx, req)
+	if e != nil {
+		return nil, e
+	}
+	docs := []map[string]interface{}{}
+	if e := c.All(ctx, &docs); e != nil {
+		return nil, e
+	}
+	return docs, nil
+}
+
+func transactionsUnsupported(e error) bool {
+	return strings.Contains(e.Error(), "Transaction numbers are only allowed") ||
+		strings.Contains(e.Error(), "IllegalOperation")
+}
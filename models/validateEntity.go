@@ -202,6 +202,11 @@ func validateJsonSchema(entity int, t map[string]interface{}) (map[string]interf
 			return resp, false
 		}
 		return u.Message(false, err.Error()), false
+	} else if errSlice := validateSchemaExtensions(entity, t); len(errSlice) > 0 {
+		// Extensions registered for its entity and domain
+		resp := u.Message(false, "JSON body doesn't validate with the schema extensions")
+		resp["errors"] = errSlice
+		return resp, false
 	} else {
 		println("JSON Schema: all good, validated!")
 		return nil, true
@@ -209,6 +214,9 @@ func validateJsonSchema(entity int, t map[string]interface{}) (map[string]interf
 }
 
 func ValidatePatch(ent int, t map[string]interface{}) (map[string]interface{}, bool) {
+	if resp, ok := validateAssetRefs(t); !ok {
+		return resp, false
+	}
 	for k := range t {
 		switch k {
 		case "name", "category", "domain":
@@ -220,6 +228,11 @@ func ValidatePatch(ent int, t map[string]interface{}) (map[string]interface{}, b
 						"Field: "+k+" cannot be nullified!"), false
 				}
 			}
+			if k == "domain" && hasDomain(ent) {
+				if resp, ok := validateDomain(t[k]); !ok {
+					return resp, false
+				}
+			}
 
 		case "parentId":
 			if ent < u.ROOMTMPL && ent > u.TENANT {
@@ -282,6 +295,11 @@ func ValidatePatch(ent int, t map[string]interface{}) (map[string]interface{}, b
 						"Field: "+k+" cannot nullified!"), false
 				}
 			}
+			if attrs, ok := t[k].(map[string]interface{}); ok {
+				if resp, ok := validateTemplateRef(ent, attrs); !ok {
+					return resp, false
+				}
+			}
 
 		case "attributes.size", "attributes.sizeUnit",
 			"attributes.height", "attributes.heightUnit":
@@ -293,6 +311,16 @@ func ValidatePatch(ent int, t map[string]interface{}) (map[string]interface{}, b
 				}
 			}
 
+		case "attributes.template": //u.BLDG, u.ROOM, u.RACK, u.DEVICE
+			attrs := map[string]interface{}{"template": t[k]}
+			if resp, ok := validateTemplateRef(ent, attrs); !ok {
+				return resp, false
+			}
+			if _, ok := attrs["templateVersion"]; ok {
+				t[k] = attrs["template"]
+				t["attributes.templateVersion"] = attrs["templateVersion"]
+			}
+
 		case "attributes.floorUnit": //u.ROOM
 			if ent == u.ROOM {
 				if v, _ := t[k]; v == nil {
@@ -348,29 +376,67 @@ func ValidatePatch(ent int, t map[string]interface{}) (map[string]interface{}, b
 
 }
 
+// ValidateEntity: checks an object on its own and against its parent.
+// The position of a device in its parent rack or chassis is checked
+// by validateDevicePlacement, that needs to know the device updated
 func ValidateEntity(entity int, t map[string]interface{}) (map[string]interface{}, bool) {
+	return validateEntity(entity, t, nil)
+}
 
-	//parentObj := nil
-	/*
-		TODO:
-		Need to capture device if it is a parent
-		and check that the device parent has a slot
-		attribute
-	*/
+// validateEntity: checks an object against parent when it is
+// given, for a parent not created yet. Otherwise the parent
+// is read from its parentId
+func validateEntity(entity int, t, parent map[string]interface{}) (map[string]interface{}, bool) {
+	// Children default to the domain of their parent
+	if parent == nil {
+		inheritDomain(entity, t)
+	} else if domain, _ := t["domain"].(string); domain == "" && parent["domain"] != nil {
+		t["domain"] = parent["domain"]
+	}
 
 	// Validate JSON Schema
 	if resp, err := validateJsonSchema(entity, t); !err {
 		return resp, false
 	}
 
+	// Objects should belong to an existing domain
+	if hasDomain(entity) {
+		if resp, ok := validateDomain(t["domain"]); !ok {
+			return resp, false
+		}
+	}
+
+	// The template of an object should exist
+	if attrs, ok := t["attributes"].(map[string]interface{}); ok {
+		if resp, ok := validateTemplateRef(entity, attrs); !ok {
+			return resp, false
+		}
+	}
+
+	// The assets referenced by an object or a template should exist
+	if resp, ok := validateAssetRefs(t); !ok {
+		return resp, false
+	}
+
 	// Extra checks
 	switch entity {
+	case u.DOMAIN:
+		if resp, ok := validateParentDomain(t); !ok {
+			return resp, false
+		}
+
 	case u.SITE, u.BLDG, u.ROOM, u.RACK, u.DEVICE, u.AC,
 		u.PWRPNL, u.CABINET, u.CORRIDOR, u.SENSOR, u.GROUP:
 		//Check if Parent ID is valid
 		//returns a map[string]interface{} to hold parent entity
 		//if parent found
-		r, ok := validateParent(u.EntityToString(entity), entity, t)
+		r, ok := map[string]interface{}{}, true
+		if parent == nil {
+			r, ok = validateParent(u.EntityToString(entity), entity, t)
+		} else {
+			r["parent"] = parent["category"]
+			r["hierarchyName"] = getHierarchyName(parent)
+		}
 		if !ok {
 			return r, ok
 		} else if r["hierarchyName"] != nil {
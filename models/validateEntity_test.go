@@ -12,7 +12,7 @@ import (
 
 func TestValidateJsonSchemaExamples(t *testing.T) {
 	// Test schemas examples
-	testingEntities := []int{u.SITE, u.BLDG, u.ROOM, u.RACK, u.DEVICE, u.GROUP, u.BLDGTMPL, u.OBJTMPL, u.ROOMTMPL}
+	testingEntities := []int{u.SITE, u.BLDG, u.ROOM, u.RACK, u.DEVICE, u.GROUP, u.BLDGTMPL, u.OBJTMPL, u.ROOMTMPL, u.DOMAIN}
 	for _, entInt := range testingEntities {
 		entStr := u.EntityToString(entInt)
 		println("*** Testing " + entStr)
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+// Maximum size of a file read from an archive
+const MaxArchiveFileSize = 8 << 20
+
+// Maximum number of entries and total size of the files of an archive
+const (
+	MaxArchiveEntries = 4096
+	MaxArchiveSize    = 128 << 20
+)
+
+var errTooManyEntries = errors.New("the archive has too many entries")
+
+// ArchiveFile: a regular file of a tar or zip archive
+type ArchiveFile struct {
+	Name string
+	Data []byte
+}
+
+// ReadArchive: regular files of a zip, tar or gzipped tar archive,
+// the format is found from the content. Directories and hidden files
+// (ex: __MACOSX, .DS_Store) are skipped. The number of entries and
+// the size of the files once decompressed are limited
+func ReadArchive(data []byte) ([]ArchiveFile, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")), bytes.HasPrefix(data, []byte("PK\x05\x06")):
+		return readZip(data)
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		gz, e := gzip.NewReader(bytes.NewReader(data))
+		if e != nil {
+			return nil, e
+		}
+		defer gz.Close()
+		return readTar(gz)
+	}
+	return readTar(bytes.NewReader(data))
+}
+
+func isHiddenFile(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+// readAll: content of a file of an archive, total is the size
+// of the files already read
+func readAll(r io.Reader, name string, total *int64) ([]byte, error) {
+	data, e := io.ReadAll(io.LimitReader(r, MaxArchiveFileSize+1))
+	if e != nil {
+		return nil, e
+	}
+	if len(data) > MaxArchiveFileSize {
+		return nil, errors.New("the file " + name + " is too big")
+	}
+	*total += int64(len(data))
+	if *total > MaxArchiveSize {
+		return nil, errors.New("the archive is too big once decompressed")
+	}
+	return data, nil
+}
+
+func readZip(data []byte) ([]ArchiveFile, error) {
+	r, e := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if e != nil {
+		return nil, e
+	}
+	if len(r.File) > MaxArchiveEntries {
+		return nil, errTooManyEntries
+	}
+	files := []ArchiveFile{}
+	var total int64
+	for _, f := range r.File {
+		name := path.Clean(strings.TrimPrefix(f.Name, "./"))
+		if f.FileInfo().IsDir() || isHiddenFile(name) {
+			continue
+		}
+		rc, e := f.Open()
+		if e != nil {
+			return nil, e
+		}
+		content, e := readAll(rc, name, &total)
+		rc.Close()
+		if e != nil {
+			return nil, e
+		}
+		files = append(files, ArchiveFile{name, content})
+	}
+	return files, nil
+}
+
+func readTar(r io.Reader) ([]ArchiveFile, error) {
+	tr := tar.NewReader(r)
+	files := []ArchiveFile{}
+	var total int64
+	for entries := 1; ; entries++ {
+		header, e := tr.Next()
+		if e == io.EOF {
+			return files, nil
+		} else if e != nil {
+			return nil, errors.New("invalid archive, tar or zip expected: " + e.Error())
+		}
+		if entries > MaxArchiveEntries {
+			return nil, errTooManyEntries
+		}
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if header.Typeflag != tar.TypeReg || isHiddenFile(name) {
+			continue
+		}
+		content, e := readAll(tr, name, &total)
+		if e != nil {
+			return nil, e
+		}
+		files = append(files, ArchiveFile{name, content})
+	}
+}
+
+// WriteTar: writes files as a tar archive
+func WriteTar(w io.Writer, files []ArchiveFile) error {
+	tw := tar.NewWriter(w)
+	for _, f := range files {
+		header := &tar.Header{Name: f.Name, Mode: 0644, Size: int64(len(f.Data)), Typeflag: tar.TypeReg}
+		if e := tw.WriteHeader(header); e != nil {
+			return e
+		}
+		if _, e := tw.Write(f.Data); e != nil {
+			return e
+		}
+	}
+	return tw.Close()
+}
+
+// WriteZip: writes files as a zip archive
+func WriteZip(w io.Writer, files []ArchiveFile) error {
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		fw, e := zw.Create(f.Name)
+		if e != nil {
+			return e
+		}
+		if _, e := fw.Write(f.Data); e != nil {
+			return e
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strconv"
+	"testing"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	files := []ArchiveFile{
+		{"obj_template/a.json", []byte(`{"slug": "a"}`)},
+		{"room_template/b.json", []byte(`{"slug": "b"}`)},
+	}
+	tarData, zipData, gzData := bytes.Buffer{}, bytes.Buffer{}, bytes.Buffer{}
+	if e := WriteTar(&tarData, files); e != nil {
+		t.Fatalf("WriteTar: %s", e.Error())
+	}
+	if e := WriteZip(&zipData, files); e != nil {
+		t.Fatalf("WriteZip: %s", e.Error())
+	}
+	gz := gzip.NewWriter(&gzData)
+	gz.Write(tarData.Bytes())
+	gz.Close()
+
+	for format, data := range map[string][]byte{
+		"tar": tarData.Bytes(), "zip": zipData.Bytes(), "tar.gz": gzData.Bytes()} {
+		read, e := ReadArchive(data)
+		if e != nil {
+			t.Errorf("%s: unexpected error: %s", format, e.Error())
+			continue
+		}
+		if len(read) != len(files) {
+			t.Errorf("%s: got %d files, expected %d", format, len(read), len(files))
+			continue
+		}
+		for i := range files {
+			if read[i].Name != files[i].Name || !bytes.Equal(read[i].Data, files[i].Data) {
+				t.Errorf("%s: got %s, expected %s", format, read[i].Name, files[i].Name)
+			}
+		}
+	}
+}
+
+func TestReadArchiveSkipsHiddenFiles(t *testing.T) {
+	data := bytes.Buffer{}
+	WriteZip(&data, []ArchiveFile{
+		{"templates/a.json", []byte(`{}`)},
+		{"__MACOSX/templates/._a.json", []byte(`x`)},
+		{"templates/.DS_Store", []byte(`x`)},
+	})
+	read, e := ReadArchive(data.Bytes())
+	if e != nil || len(read) != 1 || read[0].Name != "templates/a.json" {
+		t.Errorf("got %v %v, expected only templates/a.json", read, e)
+	}
+}
+
+func TestReadArchiveInvalid(t *testing.T) {
+	if _, e := ReadArchive([]byte("not an archive, only some text that is long enough")); e == nil {
+		t.Errorf("expected an error for a text file")
+	}
+}
+
+func TestReadArchiveLimits(t *testing.T) {
+	many := []ArchiveFile{}
+	for i := 0; i <= MaxArchiveEntries; i++ {
+		many = append(many, ArchiveFile{"templates/" + strconv.Itoa(i) + ".json", []byte(`{}`)})
+	}
+	tarData, zipData := bytes.Buffer{}, bytes.Buffer{}
+	WriteTar(&tarData, many)
+	WriteZip(&zipData, many)
+	for format, data := range map[string][]byte{"tar": tarData.Bytes(), "zip": zipData.Bytes()} {
+		if _, e := ReadArchive(data); e == nil {
+			t.Errorf("%s: expected an error for too many entries", format)
+		}
+	}
+
+	// Files under the size limit, but too big all together once decompressed
+	big := []ArchiveFile{}
+	for i := 0; i*MaxArchiveFileSize <= MaxArchiveSize; i++ {
+		big = append(big, ArchiveFile{"templates/" + strconv.Itoa(i) + ".json",
+			bytes.Repeat([]byte(" "), MaxArchiveFileSize)})
+	}
+	zipData = bytes.Buffer{}
+	WriteZip(&zipData, big)
+	if _, e := ReadArchive(zipData.Bytes()); e == nil {
+		t.Errorf("expected an error for a too big archive")
+	}
+}
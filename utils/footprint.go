@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"errors"
+	"math"
+)
+
+// Size of a floor tile in mm, for positions given in tiles (posXYUnit t)
+const TileSizeMM = 600
+
+// Tolerance when comparing footprints in mm, objects can touch
+const mmEpsilon = 1e-3
+
+// PositionToMillimeters: converts a position given
+// in one of the posXYUnit of the schemas to mm
+func PositionToMillimeters(value float64, unit string) (float64, error) {
+	if unit == "t" {
+		return value * TileSizeMM, nil
+	}
+	mm, e := ToMillimeters(value, unit)
+	if e != nil {
+		return 0, errors.New("unknown position unit: '" + unit + "'")
+	}
+	return mm, nil
+}
+
+// Rect: the footprint of an object on the floor of
+// a room, in mm from the origin of the room
+type Rect struct {
+	MinX, MinY float64
+	MaxX, MaxY float64
+}
+
+// NewRect: the rectangle from (x, y) with sides w and d,
+// that can be negative (ex: a room of size {"x":-13, "y":-3})
+func NewRect(x, y, w, d float64) Rect {
+	return Rect{math.Min(x, x+w), math.Min(y, y+d), math.Max(x, x+w), math.Max(y, y+d)}
+}
+
+// Overlaps: the rectangles share more than a side
+func (r Rect) Overlaps(o Rect) bool {
+	return r.MinX < o.MaxX-mmEpsilon && o.MinX < r.MaxX-mmEpsilon &&
+		r.MinY < o.MaxY-mmEpsilon && o.MinY < r.MaxY-mmEpsilon
+}
+
+// Contains: o is inside r, sides included
+func (r Rect) Contains(o Rect) bool {
+	return o.MinX >= r.MinX-mmEpsilon && o.MaxX <= r.MaxX+mmEpsilon &&
+		o.MinY >= r.MinY-mmEpsilon && o.MaxY <= r.MaxY+mmEpsilon
+}
+
+// AxisDirections: the directions of the x and y axes of a room from
+// its axisOrientation (ex: "+x-y" gives 1, -1), "" is "+x+y"
+func AxisDirections(axisOrientation string) (float64, float64, error) {
+	switch axisOrientation {
+	case "", "+x+y":
+		return 1, 1, nil
+	case "+x-y":
+		return 1, -1, nil
+	case "-x+y":
+		return -1, 1, nil
+	case "-x-y":
+		return -1, -1, nil
+	}
+	return 0, 0, errors.New("unknown axis orientation: '" + axisOrientation + "'")
+}
+
+// Footprint: the rectangle covered by an object placed at pos (its
+// corner, in posUnit) of the given size (width x, depth y, in
+// sizeUnit). The width and depth are swapped for objects turned
+// to the left or to the right. The object extends along the axes
+// of its room, given by axisOrientation
+func Footprint(pos Vector, posUnit string, size Vector, sizeUnit, orientation, axisOrientation string) (Rect, error) {
+	x, e := PositionToMillimeters(pos.X, posUnit)
+	if e != nil {
+		return Rect{}, e
+	}
+	y, _ := PositionToMillimeters(pos.Y, posUnit)
+	w, e := ToMillimeters(size.X, sizeUnit)
+	if e != nil {
+		return Rect{}, e
+	}
+	d, _ := ToMillimeters(size.Y, sizeUnit)
+
+	switch orientation {
+	case "", "front", "rear":
+	case "left", "right":
+		w, d = d, w
+	default:
+		return Rect{}, errors.New("unknown orientation: '" + orientation + "'")
+	}
+	dx, dy, e := AxisDirections(axisOrientation)
+	if e != nil {
+		return Rect{}, e
+	}
+	return NewRect(x, y, dx*w, dy*d), nil
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestFootprint(t *testing.T) {
+	tests := []struct {
+		pos         Vector
+		posUnit     string
+		size        Vector
+		sizeUnit    string
+		orientation string
+		axes        string
+		expected    Rect
+	}{
+		{Vector{1, 2, 0}, "m", Vector{60, 120, 0}, "cm", "front", "+x+y", Rect{1000, 2000, 1600, 3200}},
+		{Vector{1, 2, 0}, "m", Vector{60, 120, 0}, "cm", "left", "+x+y", Rect{1000, 2000, 2200, 2600}},
+		{Vector{2, 3, 0}, "t", Vector{600, 1200, 0}, "mm", "rear", "", Rect{1200, 1800, 1800, 3000}},
+		{Vector{1, 0, 0}, "f", Vector{1, 1, 0}, "f", "", "+x+y", Rect{304.8, 0, 609.6, 304.8}},
+		{Vector{0, 0, 0}, "m", Vector{-13, -3, 0}, "m", "", "+x+y", Rect{-13000, -3000, 0, 0}},
+		{Vector{-1, 2, 0}, "m", Vector{60, 120, 0}, "cm", "front", "-x+y", Rect{-1600, 2000, -1000, 3200}},
+		{Vector{-1, -2, 0}, "m", Vector{60, 120, 0}, "cm", "right", "-x-y", Rect{-2200, -2600, -1000, -2000}},
+		{Vector{0, 0, 0}, "m", Vector{13, 3, 0}, "m", "", "+x-y", Rect{0, -3000, 13000, 0}},
+	}
+	for _, test := range tests {
+		r, e := Footprint(test.pos, test.posUnit, test.size, test.sizeUnit, test.orientation, test.axes)
+		if e != nil {
+			t.Errorf("%v: unexpected error: %s", test, e.Error())
+		} else if r != test.expected {
+			t.Errorf("%v: got %v, expected %v", test, r, test.expected)
+		}
+	}
+	if _, e := Footprint(Vector{}, "in", Vector{}, "m", "", ""); e == nil {
+		t.Errorf("in should be an unknown position unit")
+	}
+	if _, e := Footprint(Vector{}, "m", Vector{}, "m", "up", ""); e == nil {
+		t.Errorf("up should be an unknown orientation")
+	}
+	if _, e := Footprint(Vector{}, "m", Vector{}, "m", "", "+y+x"); e == nil {
+		t.Errorf("+y+x should be an unknown axis orientation")
+	}
+}
+
+func TestRect(t *testing.T) {
+	a := Rect{0, 0, 600, 1200}
+	if !a.Overlaps(Rect{500, 1000, 1100, 2200}) || a.Overlaps(Rect{600, 0, 1200, 1200}) {
+		t.Errorf("wrong overlap of adjacent rectangles")
+	}
+	room := NewRect(0, 0, 10000, 5000)
+	if !room.Contains(a) || !room.Contains(Rect{9400, 3800, 10000, 5000}) ||
+		room.Contains(Rect{9500, 0, 10100, 1200}) {
+		t.Errorf("wrong containment in the room")
+	}
+}
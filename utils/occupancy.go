@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Height of a rack unit in mm
+const RackUnitMM = 44.45
+
+// Tolerance when comparing U positions, they are floats
+const uEpsilon = 1e-6
+
+// URange: rack units from Start to End (excluded),
+// the lowest U of a rack is 1. A 2U device at posU 10
+// occupies the range 10-12, that is U10 and U11
+type URange struct {
+	Start float64
+	End   float64
+}
+
+func (r URange) Overlaps(o URange) bool {
+	return r.Start < o.End-uEpsilon && o.Start < r.End-uEpsilon
+}
+
+// Fits: the range is inside a rack of the given height in U
+func (r URange) Fits(rackHeight float64) bool {
+	return r.Start >= 1-uEpsilon && r.End <= rackHeight+1+uEpsilon
+}
+
+// ToRackUnits: converts a height given in one
+// of the heightUnit of the schemas to U
+func ToRackUnits(value string, unit string) (float64, error) {
+	height, e := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if e != nil {
+		return 0, errors.New("invalid height: '" + value + "'")
+	}
+	if unit == "U" {
+		return height, nil
+	}
+	mm, e := ToMillimeters(height, unit)
+	if e != nil {
+		return 0, errors.New("unknown height unit: '" + unit + "'")
+	}
+	return mm / RackUnitMM, nil
+}
+
+// FreeURanges: ranges of a rack of the given height in U
+// that are not used, from the bottom to the top
+func FreeURanges(rackHeight float64, used []URange) []URange {
+	sorted := append([]URange{}, used...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	free := []URange{}
+	start := 1.0
+	for _, r := range sorted {
+		if r.Start > start+uEpsilon {
+			free = append(free, URange{start, r.Start})
+		}
+		if r.End > start {
+			start = r.End
+		}
+	}
+	if rackHeight+1 > start+uEpsilon {
+		free = append(free, URange{start, rackHeight + 1})
+	}
+	return free
+}
+
+// AutoPosU: how to place a device given with posU "auto". The
+// first fit is searched from the bottom of the rack, or from its top,
+// and first in the preferred range if any
+type AutoPosU struct {
+	Top       bool
+	Preferred *URange
+}
+
+// ParseAutoPosU: parses the posU values "auto", "auto:bottom",
+// "auto:top" and "auto:<from>-<to>" (preferred range, U from and to
+// included). ok is false for other values
+func ParseAutoPosU(posU string) (auto AutoPosU, ok bool, err error) {
+	if posU != "auto" && !strings.HasPrefix(posU, "auto:") {
+		return auto, false, nil
+	}
+	switch option := strings.TrimPrefix(posU, "auto"); option {
+	case "", ":bottom":
+	case ":top":
+		auto.Top = true
+	default:
+		bounds := strings.Split(strings.TrimPrefix(option, ":"), "-")
+		if len(bounds) == 2 {
+			from, e1 := strconv.ParseFloat(bounds[0], 64)
+			to, e2 := strconv.ParseFloat(bounds[1], 64)
+			if e1 == nil && e2 == nil && from >= 1 && from <= to {
+				auto.Preferred = &URange{from, to + 1}
+				return auto, true, nil
+			}
+		}
+		return auto, true, errors.New("invalid posU: '" + posU +
+			"', expected auto, auto:bottom, auto:top or auto:<from>-<to>")
+	}
+	return auto, true, nil
+}
+
+// FirstFit: lowest (or highest if top) whole U position where a
+// device of size U fits in one of the free ranges, and inside within
+// if not nil. ok is false if there is no such position
+func FirstFit(free []URange, size float64, top bool, within *URange) (posU float64, ok bool) {
+	for _, r := range free {
+		if within != nil {
+			r = URange{math.Max(r.Start, within.Start), math.Min(r.End, within.End)}
+		}
+		first := math.Ceil(r.Start - uEpsilon)
+		last := math.Floor(r.End - size + uEpsilon)
+		if first > last {
+			continue
+		}
+		if !top {
+			return first, true
+		}
+		posU, ok = last, true
+	}
+	return posU, ok
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestToRackUnits(t *testing.T) {
+	tests := []struct {
+		value, unit string
+		expected    float64
+	}{
+		{"42", "U", 42},
+		{"88.9", "mm", 2},
+		{"4.445", "cm", 1},
+		{"0.889", "m", 20},
+		{"1", "OU", 48 / RackUnitMM},
+		{"1", "f", 304.8 / RackUnitMM},
+	}
+	for _, test := range tests {
+		u, e := ToRackUnits(test.value, test.unit)
+		if e != nil {
+			t.Errorf("%s%s: unexpected error: %s", test.value, test.unit, e.Error())
+		} else if math.Abs(u-test.expected) > 1e-9 {
+			t.Errorf("%s%s: got %f U, expected %f", test.value, test.unit, u, test.expected)
+		}
+	}
+	for _, invalid := range [][2]string{{"x", "U"}, {"1", "in"}} {
+		if _, e := ToRackUnits(invalid[0], invalid[1]); e == nil {
+			t.Errorf("%s%s should be invalid", invalid[0], invalid[1])
+		}
+	}
+}
+
+func TestURange(t *testing.T) {
+	if !(URange{10, 12}).Overlaps(URange{11, 12}) || (URange{10, 12}).Overlaps(URange{12, 14}) {
+		t.Errorf("wrong overlap of adjacent ranges")
+	}
+	if !(URange{41, 43}).Fits(42) || (URange{42, 44}).Fits(42) || (URange{0, 1}).Fits(42) {
+		t.Errorf("wrong fit in a 42U rack")
+	}
+}
+
+func TestFreeURanges(t *testing.T) {
+	free := FreeURanges(42, []URange{{20, 22}, {1, 3}, {21, 25}})
+	expected := []URange{{3, 20}, {25, 43}}
+	if !reflect.DeepEqual(free, expected) {
+		t.Errorf("got %v, expected %v", free, expected)
+	}
+	if free := FreeURanges(2, []URange{{1, 3}}); len(free) != 0 {
+		t.Errorf("full rack should have no free range, got %v", free)
+	}
+}
+
+func TestParseAutoPosU(t *testing.T) {
+	tests := []struct {
+		posU     string
+		ok       bool
+		expected AutoPosU
+	}{
+		{"12", false, AutoPosU{}},
+		{"auto", true, AutoPosU{}},
+		{"auto:bottom", true, AutoPosU{}},
+		{"auto:top", true, AutoPosU{Top: true}},
+		{"auto:10-20", true, AutoPosU{Preferred: &URange{10, 21}}},
+	}
+	for _, test := range tests {
+		auto, ok, e := ParseAutoPosU(test.posU)
+		if e != nil {
+			t.Errorf("%s: unexpected error: %s", test.posU, e.Error())
+		} else if ok != test.ok || !reflect.DeepEqual(auto, test.expected) {
+			t.Errorf("%s: got %v %v, expected %v %v", test.posU, auto, ok, test.expected, test.ok)
+		}
+	}
+	for _, invalid := range []string{"auto:", "auto:middle", "auto:20-10", "auto:0-3", "auto:1-2-3"} {
+		if _, _, e := ParseAutoPosU(invalid); e == nil {
+			t.Errorf("%s should be invalid", invalid)
+		}
+	}
+}
+
+func TestFirstFit(t *testing.T) {
+	free := FreeURanges(42, []URange{{1, 3}, {4, 10.5}, {20, 22}})
+	tests := []struct {
+		size     float64
+		top      bool
+		within   *URange
+		expected float64
+		ok       bool
+	}{
+		{1, false, nil, 3, true},
+		{2, false, nil, 11, true},
+		{2, true, nil, 41, true},
+		{9, false, nil, 11, true},
+		{10, false, nil, 22, true},
+		{2, false, &URange{15, 25}, 15, true},
+		{2, true, &URange{15, 25}, 23, true},
+		{3, false, &URange{19, 24}, 0, false},
+		{50, false, nil, 0, false},
+	}
+	for _, test := range tests {
+		posU, ok := FirstFit(free, test.size, test.top, test.within)
+		if ok != test.ok || (ok && posU != test.expected) {
+			t.Errorf("%vU top=%v within=%v: got %v %v, expected %v %v",
+				test.size, test.top, test.within, posU, ok, test.expected, test.ok)
+		}
+	}
+}
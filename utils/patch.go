@@ -0,0 +1,294 @@
+package utils
+
+//Standard PATCH formats applied to generic
+//JSON documents (map[string]interface{})
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	JSONPatchType  = "application/json-patch+json"
+	MergePatchType = "application/merge-patch+json"
+)
+
+// ApplyJSONPatch: applies a JSON Patch (RFC 6902) to doc.
+// doc should only contain values produced by encoding/json,
+// it may be modified and the patched document is returned
+func ApplyJSONPatch(doc interface{}, ops []interface{}) (interface{}, error) {
+	var err error
+	for i, opInf := range ops {
+		op, ok := opInf.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("operation " + strconv.Itoa(i) + " is not a JSON object")
+		}
+		doc, err = applyJSONPatchOperation(doc, op)
+		if err != nil {
+			return nil, errors.New("operation " + strconv.Itoa(i) + ": " + err.Error())
+		}
+	}
+	return doc, nil
+}
+
+func applyJSONPatchOperation(doc interface{}, op map[string]interface{}) (interface{}, error) {
+	opName, _ := op["op"].(string)
+	path, ok := op["path"].(string)
+	if !ok {
+		return nil, errors.New("missing path")
+	}
+	tokens, err := parseJSONPointer(path)
+	if err != nil {
+		return nil, err
+	}
+	value, hasValue := op["value"]
+
+	switch opName {
+	case "add", "replace", "test":
+		if !hasValue {
+			return nil, errors.New("missing value for " + opName)
+		}
+	case "move", "copy":
+		from, ok := op["from"].(string)
+		if !ok {
+			return nil, errors.New("missing from for " + opName)
+		}
+		fromTokens, err := parseJSONPointer(from)
+		if err != nil {
+			return nil, err
+		}
+		if value, err = getJSONPointerValue(doc, fromTokens); err != nil {
+			return nil, err
+		}
+		if opName == "move" {
+			if strings.HasPrefix(path+"/", from+"/") && path != from {
+				return nil, errors.New("cannot move " + from + " into one of its children")
+			}
+			if doc, err = patchAt(doc, fromTokens, removeOperation); err != nil {
+				return nil, err
+			}
+		} else {
+			value = deepCopy(value)
+		}
+		opName = "add"
+	case "remove":
+	default:
+		return nil, errors.New("unknown op '" + opName + "'")
+	}
+
+	switch opName {
+	case "add":
+		return patchAt(doc, tokens, addOperation(deepCopy(value)))
+	case "replace":
+		return patchAt(doc, tokens, replaceOperation(deepCopy(value)))
+	case "remove":
+		return patchAt(doc, tokens, removeOperation)
+	default: // test
+		current, err := getJSONPointerValue(doc, tokens)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, errors.New("test failed for " + path)
+		}
+		return doc, nil
+	}
+}
+
+// ApplyMergePatch: applies a JSON Merge Patch (RFC 7396) to target
+func ApplyMergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return deepCopy(patch)
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for k, v := range patchObj {
+		if v == nil {
+			delete(targetObj, k)
+		} else {
+			targetObj[k] = ApplyMergePatch(targetObj[k], v)
+		}
+	}
+	return targetObj
+}
+
+// parseJSONPointer: splits a JSON Pointer (RFC 6901) into
+// its unescaped reference tokens
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, errors.New("invalid JSON pointer '" + pointer + "'")
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(tokens[i], "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func getJSONPointerValue(doc interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			child, ok := node[token]
+			if !ok {
+				return nil, errors.New("path not found: " + token)
+			}
+			doc = child
+		case []interface{}:
+			idx, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[idx]
+		default:
+			return nil, errors.New("path not found: " + token)
+		}
+	}
+	return doc, nil
+}
+
+// patchOperation: an operation on the container of the last token
+// of a path, or on the whole document (root) for the path ""
+type patchOperation func(container interface{}, key string, root bool) (interface{}, error)
+
+// patchAt: walks doc following tokens and calls operation on
+// the container of the last token, returns the modified doc
+func patchAt(doc interface{}, tokens []string, operation patchOperation) (interface{}, error) {
+	if len(tokens) == 0 {
+		return operation(doc, "", true)
+	}
+	if len(tokens) == 1 {
+		return operation(doc, tokens[0], false)
+	}
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, ok := node[tokens[0]]
+		if !ok {
+			return nil, errors.New("path not found: " + tokens[0])
+		}
+		newChild, err := patchAt(child, tokens[1:], operation)
+		if err != nil {
+			return nil, err
+		}
+		node[tokens[0]] = newChild
+		return node, nil
+	case []interface{}:
+		idx, err := arrayIndex(tokens[0], len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		newChild, err := patchAt(node[idx], tokens[1:], operation)
+		if err != nil {
+			return nil, err
+		}
+		node[idx] = newChild
+		return node, nil
+	}
+	return nil, errors.New("path not found: " + tokens[0])
+}
+
+func addOperation(value interface{}) patchOperation {
+	return func(container interface{}, key string, root bool) (interface{}, error) {
+		if root {
+			return value, nil
+		}
+		switch node := container.(type) {
+		case map[string]interface{}:
+			node[key] = value
+			return node, nil
+		case []interface{}:
+			idx := len(node)
+			if key != "-" {
+				var err error
+				if idx, err = arrayIndex(key, len(node)); err != nil {
+					return nil, err
+				}
+			}
+			node = append(node, nil)
+			copy(node[idx+1:], node[idx:])
+			node[idx] = value
+			return node, nil
+		}
+		return nil, errors.New("cannot add " + key + " to a value")
+	}
+}
+
+func replaceOperation(value interface{}) patchOperation {
+	return func(container interface{}, key string, root bool) (interface{}, error) {
+		if root {
+			return value, nil
+		}
+		switch node := container.(type) {
+		case map[string]interface{}:
+			if _, ok := node[key]; !ok {
+				return nil, errors.New("path not found: " + key)
+			}
+			node[key] = value
+			return node, nil
+		case []interface{}:
+			idx, err := arrayIndex(key, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			node[idx] = value
+			return node, nil
+		}
+		return nil, errors.New("path not found: " + key)
+	}
+}
+
+func removeOperation(container interface{}, key string, root bool) (interface{}, error) {
+	if root {
+		return nil, errors.New("cannot remove the whole document")
+	}
+	switch node := container.(type) {
+	case map[string]interface{}:
+		if _, ok := node[key]; !ok {
+			return nil, errors.New("path not found: " + key)
+		}
+		delete(node, key)
+		return node, nil
+	case []interface{}:
+		idx, err := arrayIndex(key, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		return append(node[:idx], node[idx+1:]...), nil
+	}
+	return nil, errors.New("path not found: " + key)
+}
+
+// arrayIndex: converts a reference token to an array index in [0, max]
+func arrayIndex(token string, max int) (int, error) {
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 || idx > max || (len(token) > 1 && token[0] == '0') {
+		return 0, errors.New("invalid array index: " + token)
+	}
+	return idx, nil
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		cp := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			cp[k] = deepCopy(child)
+		}
+		return cp
+	case []interface{}:
+		cp := make([]interface{}, len(v))
+		for i, child := range v {
+			cp[i] = deepCopy(child)
+		}
+		return cp
+	}
+	return value
+}
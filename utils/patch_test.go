@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func jsonValue(s string) interface{} {
+	var v interface{}
+	json.Unmarshal([]byte(s), &v)
+	return v
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	doc := `{"name":"R1","description":["a"],"attributes":{"height":"47","color":"FF"}}`
+	tests := []struct {
+		patch    string
+		expected string
+	}{
+		{`[{"op":"add","path":"/description/-","value":"b"}]`,
+			`{"name":"R1","description":["a","b"],"attributes":{"height":"47","color":"FF"}}`},
+		{`[{"op":"add","path":"/description/0","value":"z"}]`,
+			`{"name":"R1","description":["z","a"],"attributes":{"height":"47","color":"FF"}}`},
+		{`[{"op":"remove","path":"/attributes/color"}]`,
+			`{"name":"R1","description":["a"],"attributes":{"height":"47"}}`},
+		{`[{"op":"replace","path":"/attributes/height","value":"42"}]`,
+			`{"name":"R1","description":["a"],"attributes":{"height":"42","color":"FF"}}`},
+		{`[{"op":"move","from":"/attributes/color","path":"/attributes/colour"}]`,
+			`{"name":"R1","description":["a"],"attributes":{"height":"47","colour":"FF"}}`},
+		{`[{"op":"copy","from":"/name","path":"/description/1"}]`,
+			`{"name":"R1","description":["a","R1"],"attributes":{"height":"47","color":"FF"}}`},
+		{`[{"op":"test","path":"/name","value":"R1"},{"op":"replace","path":"/name","value":"R2"}]`,
+			`{"name":"R2","description":["a"],"attributes":{"height":"47","color":"FF"}}`},
+		{`[{"op":"add","path":"/a~1b","value":1}]`,
+			`{"name":"R1","a/b":1,"description":["a"],"attributes":{"height":"47","color":"FF"}}`},
+	}
+	for i, test := range tests {
+		ans, err := ApplyJSONPatch(jsonValue(doc), jsonValue(test.patch).([]interface{}))
+		if err != nil {
+			t.Errorf("Test Case %d failed: %s", i+1, err.Error())
+		} else if !reflect.DeepEqual(ans, jsonValue(test.expected)) {
+			t.Errorf("Test Case %d failed: got %v", i+1, ans)
+		}
+	}
+}
+
+func TestApplyJSONPatchErrors(t *testing.T) {
+	doc := `{"name":"R1","description":["a"],"attributes":{"height":"47","template":null}}`
+	patches := []string{
+		`[{"op":"test","path":"/name","value":"R2"}]`,
+		`[{"op":"remove","path":"/missing"}]`,
+		`[{"op":"replace","path":"/description/3","value":"x"}]`,
+		`[{"op":"add","path":"/missing/child","value":"x"}]`,
+		`[{"op":"move","from":"/attributes","path":"/attributes/child"}]`,
+		`[{"op":"add","path":"/name"}]`,
+		`[{"op":"banana","path":"/name"}]`,
+		`[{"op":"remove","path":"name"}]`,
+		`[{"op":"add","path":"/attributes/template/slug","value":"x"}]`,
+		`[{"op":"replace","path":"/attributes/template/slug","value":"x"}]`,
+		`[{"op":"remove","path":"/attributes/template/slug"}]`,
+	}
+	for i, patch := range patches {
+		if _, err := ApplyJSONPatch(jsonValue(doc), jsonValue(patch).([]interface{})); err == nil {
+			t.Errorf("Test Case %d failed: error expected", i+1)
+		}
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	doc := `{"name":"R1","description":["a"],"attributes":{"height":"47","color":"FF"}}`
+	patch := `{"description":["b"],"attributes":{"color":null,"posU":"2"}}`
+	expected := `{"name":"R1","description":["b"],"attributes":{"height":"47","posU":"2"}}`
+	ans := ApplyMergePatch(jsonValue(doc), jsonValue(patch))
+	if !reflect.DeepEqual(ans, jsonValue(expected)) {
+		t.Errorf("Test Case 1 failed: got %v", ans)
+	}
+}
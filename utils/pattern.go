@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Limits of path patterns. Their regexes are run by mongo and
+// each wildcard multiplies the ways a name can be matched
+const (
+	MaxPathPatternLength    = 256
+	MaxPathPatternWildcards = 10
+)
+
+// PathPatternToRegex: anchored regex matching the hierarchyNames
+// described by a path pattern. Everything is literal except:
+//   - * any characters of a name (ex: DEMO.*.R1 or rack*)
+//   - ** any number of names (ex: **.rack*)
+//   - ? one character of a name
+//   - {A,B} one of the patterns A or B (ex: DEMO.{BASIC,ALPHA}.*)
+func PathPatternToRegex(pattern string) (string, error) {
+	if pattern == "" {
+		return "", errors.New("empty path pattern")
+	}
+	if len(pattern) > MaxPathPatternLength {
+		return "", errors.New("path pattern longer than " +
+			strconv.Itoa(MaxPathPatternLength) + " characters")
+	}
+	wildcards := 0
+	regex, rest, e := compilePathPattern(pattern, false, &wildcards)
+	if e != nil {
+		return "", e
+	}
+	if rest != "" {
+		return "", errors.New("unexpected '" + rest[:1] + "' in path pattern")
+	}
+	if wildcards > MaxPathPatternWildcards {
+		return "", errors.New("more than " + strconv.Itoa(MaxPathPatternWildcards) +
+			" wildcards in path pattern")
+	}
+	return "^" + regex + "$", nil
+}
+
+// compilePathPattern: regex of pattern until its end or, inside
+// braces, until the end of the current alternative. The rest of
+// the pattern is returned starting with the character stopping it.
+// wildcards counts the groups of wildcards
+func compilePathPattern(pattern string, inBraces bool, wildcards *int) (string, string, error) {
+	var regex strings.Builder
+	for pattern != "" {
+		switch {
+		case pattern[0] == '*':
+			var wildcard string
+			wildcard, pattern = compileWildcards(pattern)
+			regex.WriteString(wildcard)
+			*wildcards++
+		case pattern[0] == '?':
+			regex.WriteString(`[^.]`)
+			pattern = pattern[1:]
+		case pattern[0] == '{':
+			alternatives := []string{}
+			rest := pattern[1:]
+			for {
+				alternative, next, e := compilePathPattern(rest, true, wildcards)
+				if e != nil {
+					return "", "", e
+				}
+				if next == "" {
+					return "", "", errors.New("missing '}' in path pattern")
+				}
+				alternatives = append(alternatives, alternative)
+				rest = next[1:]
+				if next[0] == '}' {
+					break
+				}
+			}
+			regex.WriteString("(?:" + strings.Join(alternatives, "|") + ")")
+			pattern = rest
+		case inBraces && (pattern[0] == ',' || pattern[0] == '}'):
+			return regex.String(), pattern, nil
+		case pattern[0] == '}':
+			return "", "", errors.New("unexpected '}' in path pattern")
+		default:
+			i := strings.IndexAny(pattern, "*?{},")
+			if i < 0 {
+				i = len(pattern)
+			} else if i == 0 {
+				// ',' outside of braces is literal
+				i = 1
+			}
+			regex.WriteString(regexp.QuoteMeta(pattern[:i]))
+			pattern = pattern[i:]
+		}
+	}
+	return regex.String(), "", nil
+}
+
+// compileWildcards: one regex group for the consecutive wildcards
+// starting pattern, so that there is only one way to split a match
+// between them, and the rest of the pattern
+func compileWildcards(pattern string) (string, string) {
+	names, star, any := false, false, false
+	for strings.HasPrefix(pattern, "*") {
+		switch {
+		case strings.HasPrefix(pattern, "**."):
+			// Any number of names, none included
+			names = true
+			pattern = pattern[3:]
+		case strings.HasPrefix(pattern, "**"):
+			any = true
+			pattern = pattern[2:]
+		default:
+			// Only at the end, a following * would make a **
+			star = true
+			pattern = pattern[1:]
+		}
+	}
+	if any {
+		return `.*`, pattern
+	}
+	regex := ""
+	if names {
+		regex += `(?:[^.]+\.)*`
+	}
+	if star {
+		regex += `[^.]*`
+	}
+	return regex, pattern
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestPathPatternToRegex(t *testing.T) {
+	tests := []struct {
+		pattern string
+		match   []string
+		noMatch []string
+	}{
+		{"DEMO.ALPHA", []string{"DEMO.ALPHA"}, []string{"DEMOXALPHA", "DEMO.ALPHA.B1", "X.DEMO.ALPHA"}},
+		{"DEMO.*.R1.*", []string{"DEMO.BASIC.R1.A01", "DEMO..R1.A01"},
+			[]string{"DEMO.BASIC.R1", "DEMO.BASIC.B1.R1.A01", "DEMO.BASIC.R1.A01.D1"}},
+		{"**.rack*", []string{"DEMO.B.rack1", "rack", "DEMO.rackA"}, []string{"DEMO.B.myrack", "DEMO.rack1.D1"}},
+		{"DEMO.**", []string{"DEMO.A", "DEMO.A.B.C"}, []string{"DEMO", "DEMOA"}},
+		{"DEMO.{A,B*}.R?", []string{"DEMO.A.R1", "DEMO.B.R2", "DEMO.Bx.R3"},
+			[]string{"DEMO.C.R1", "DEMO.A.R10", "DEMO.AB.R1"}},
+		{"{DEMO,X.{Y,Z}}", []string{"DEMO", "X.Y", "X.Z"}, []string{"X", "X.YZ"}},
+		{"a+b(c)", []string{"a+b(c)"}, []string{"aab(c)", "a+bc"}},
+		{"**.**.**.*a", []string{"a", "DEMO.B.xa"}, []string{"DEMO.a.B", "ab"}},
+		{"DEMO.***", []string{"DEMO.A", "DEMO.A.B"}, []string{"DEMO"}},
+	}
+	for _, test := range tests {
+		regex, e := PathPatternToRegex(test.pattern)
+		if e != nil {
+			t.Errorf("%s: unexpected error: %s", test.pattern, e.Error())
+			continue
+		}
+		re := regexp.MustCompile(regex)
+		for _, name := range test.match {
+			if !re.MatchString(name) {
+				t.Errorf("%s (%s) should match %s", test.pattern, regex, name)
+			}
+		}
+		for _, name := range test.noMatch {
+			if re.MatchString(name) {
+				t.Errorf("%s (%s) should not match %s", test.pattern, regex, name)
+			}
+		}
+	}
+}
+
+func TestPathPatternToRegexWildcards(t *testing.T) {
+	regex, _ := PathPatternToRegex("**.**.**.*a")
+	if regex != `^(?:[^.]+\.)*[^.]*a$` {
+		t.Errorf("consecutive wildcards should be one group, got %s", regex)
+	}
+	regex, _ = PathPatternToRegex("**.***")
+	if regex != `^.*$` {
+		t.Errorf("consecutive wildcards should be one group, got %s", regex)
+	}
+}
+
+func TestPathPatternToRegexInvalid(t *testing.T) {
+	for _, pattern := range []string{"", "DEMO.{A,B", "DEMO.A}", "{A,{B}",
+		strings.Repeat("A", MaxPathPatternLength+1),
+		strings.Repeat("*.", MaxPathPatternWildcards+1)} {
+		if _, e := PathPatternToRegex(pattern); e == nil {
+			t.Errorf("%s should be invalid", pattern)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Keys of tags, as allowed by the JSON schemas
+var tagKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_:/-]*$`)
+
+// TagSelectorToFilter: mongo filter of a comma separated list of
+// requirements on tags, all of them must be met:
+//   - key=value (or key==value): the tag key has this value
+//   - key!=value: the tag key doesn't have this value (or isn't set)
+//   - key: the tag key is set, whatever its value
+//   - !key: the tag key isn't set
+func TagSelectorToFilter(selector string) (map[string]interface{}, error) {
+	requirements := []interface{}{}
+	for _, requirement := range strings.Split(selector, ",") {
+		requirement = strings.TrimSpace(requirement)
+		if requirement == "" {
+			continue
+		}
+
+		var key, op, value string
+		if i := strings.Index(requirement, "!="); i >= 0 {
+			key, op, value = requirement[:i], "!=", requirement[i+2:]
+		} else if i := strings.Index(requirement, "="); i >= 0 {
+			key, op, value = requirement[:i], "=", strings.TrimPrefix(requirement[i+1:], "=")
+		} else if strings.HasPrefix(requirement, "!") {
+			key, op = requirement[1:], "!"
+		} else {
+			key, op = requirement, ""
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !tagKeyRegex.MatchString(key) {
+			return nil, errors.New("invalid tag key in selector: '" + key + "'")
+		}
+
+		field := "tags." + key
+		switch op {
+		case "=":
+			requirements = append(requirements, map[string]interface{}{field: value})
+		case "!=":
+			requirements = append(requirements,
+				map[string]interface{}{field: map[string]interface{}{"$ne": value}})
+		case "!":
+			requirements = append(requirements,
+				map[string]interface{}{field: map[string]interface{}{"$exists": false}})
+		default:
+			requirements = append(requirements,
+				map[string]interface{}{field: map[string]interface{}{"$exists": true}})
+		}
+	}
+	if len(requirements) == 0 {
+		return nil, errors.New("empty tag selector")
+	}
+	return map[string]interface{}{"$and": requirements}, nil
+}
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTagSelectorToFilter(t *testing.T) {
+	filter, e := TagSelectorToFilter("env=prod, owner!=x,decommission,!legacy,team==db")
+	if e != nil {
+		t.Fatalf("unexpected error: %s", e.Error())
+	}
+	expected := map[string]interface{}{"$and": []interface{}{
+		map[string]interface{}{"tags.env": "prod"},
+		map[string]interface{}{"tags.owner": map[string]interface{}{"$ne": "x"}},
+		map[string]interface{}{"tags.decommission": map[string]interface{}{"$exists": true}},
+		map[string]interface{}{"tags.legacy": map[string]interface{}{"$exists": false}},
+		map[string]interface{}{"tags.team": "db"},
+	}}
+	if !reflect.DeepEqual(filter, expected) {
+		t.Errorf("got %v, expected %v", filter, expected)
+	}
+}
+
+func TestTagSelectorToFilterInvalid(t *testing.T) {
+	for _, selector := range []string{"", ",", "a.b=c", "$where=1", "=x", "!"} {
+		if _, e := TagSelectorToFilter(selector); e == nil {
+			t.Errorf("%s should be invalid", selector)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ToMillimeters: converts a length given in one of
+// the units of the schemas (sizeUnit, heightUnit) to mm
+func ToMillimeters(value float64, unit string) (float64, error) {
+	switch unit {
+	case "mm":
+		return value, nil
+	case "cm":
+		return value * 10, nil
+	case "m":
+		return value * 1000, nil
+	case "f":
+		return value * 304.8, nil
+	case "U":
+		return value * RackUnitMM, nil
+	case "OU":
+		// Open Compute rack unit
+		return value * 48, nil
+	default:
+		return 0, errors.New("unknown unit: '" + unit + "'")
+	}
+}
+
+// Vector: a vector2 or vector3 of the schemas,
+// stored as a JSON string (ex: {"x":80, "y":100})
+type Vector struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+func ParseVector(value string) (Vector, error) {
+	var v Vector
+	if e := json.Unmarshal([]byte(value), &v); e != nil {
+		return v, errors.New("invalid vector: '" + value + "'")
+	}
+	return v, nil
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestToMillimeters(t *testing.T) {
+	tests := []struct {
+		value    float64
+		unit     string
+		expected float64
+	}{
+		{12, "mm", 12},
+		{1.5, "cm", 15},
+		{2, "m", 2000},
+		{1, "f", 304.8},
+		{2, "U", 88.9},
+		{1, "OU", 48},
+	}
+	for _, test := range tests {
+		mm, e := ToMillimeters(test.value, test.unit)
+		if e != nil {
+			t.Errorf("%v%s: unexpected error: %s", test.value, test.unit, e.Error())
+		} else if math.Abs(mm-test.expected) > 1e-9 {
+			t.Errorf("%v%s: got %vmm, expected %v", test.value, test.unit, mm, test.expected)
+		}
+	}
+	if _, e := ToMillimeters(1, "in"); e == nil {
+		t.Errorf("in should be an unknown unit")
+	}
+}
+
+func TestParseVector(t *testing.T) {
+	v, e := ParseVector(`{"x":4.5 ,"y": -2 ,"z":0}`)
+	if e != nil || v != (Vector{4.5, -2, 0}) {
+		t.Errorf("got %v %v, expected {4.5 -2 0}", v, e)
+	}
+	if v, e := ParseVector(`{"x":80, "y":100.5}`); e != nil || v != (Vector{80, 100.5, 0}) {
+		t.Errorf("got %v %v, expected {80 100.5 0}", v, e)
+	}
+	if _, e := ParseVector("80,100"); e == nil {
+		t.Errorf("80,100 should be invalid")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -37,6 +38,7 @@ const (
 	BLDGTMPL
 	STRAYDEV
 	STRAYSENSOR
+	DOMAIN
 )
 
 type RequestFilters struct {
@@ -44,6 +46,7 @@ type RequestFilters struct {
 	StartDate    string   `schema:"startDate"`
 	EndDate      string   `schema:"endDate"`
 	Limit        string   `schema:"limit"`
+	Tags         string   `schema:"tags"`
 }
 
 func GetBuildDate() string {
@@ -97,7 +100,7 @@ func ParamsParse(link *url.URL, objType int) map[string]interface{} {
 	//Building Attribute query varies based on
 	//object type
 	for key, _ := range q {
-		if key != "fieldOnly" && key != "startDate" && key != "endDate" {
+		if key != "fieldOnly" && key != "startDate" && key != "endDate" && key != "tags" {
 			if objType != ROOMTMPL && objType != OBJTMPL &&
 				objType != BLDGTMPL { //Non template objects
 				switch key {
@@ -145,6 +148,8 @@ func EntityToString(entity int) string {
 		return "stray_device"
 	case STRAYSENSOR:
 		return "stray_sensor"
+	case DOMAIN:
+		return "domain"
 	case ROOMTMPL:
 		return "room_template"
 	case OBJTMPL:
@@ -186,6 +191,8 @@ func EntityStrToInt(entity string) int {
 		return STRAYDEV
 	case "stray_sensor":
 		return STRAYSENSOR
+	case "domain":
+		return DOMAIN
 	case "room_template":
 		return ROOMTMPL
 	case "obj_template":
@@ -250,3 +257,42 @@ func StrSliceContains(slice []string, elem string) bool {
 	}
 	return false
 }
+
+// FormatETag: returns the strong entity tag of a document revision
+func FormatETag(revision int64) string {
+	return "\"" + strconv.FormatInt(revision, 10) + "\""
+}
+
+// ParseETags: extracts the revisions listed in an If-Match or
+// If-None-Match header. A nil slice is returned if the header is
+// empty or "*" (any revision). Tags that are not revisions are
+// skipped, so the returned slice can be empty but non nil
+func ParseETags(header string) []int64 {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil
+	}
+	revisions := []int64{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		tag = strings.Trim(tag, "\"")
+		if rev, e := strconv.ParseInt(tag, 10, 64); e == nil {
+			revisions = append(revisions, rev)
+		}
+	}
+	return revisions
+}
+
+// ETagMatches: checks if revision is one of the revisions
+// given by a conditional header (see ParseETags)
+func ETagMatches(revisions []int64, revision int64) bool {
+	if revisions == nil {
+		return true
+	}
+	for _, rev := range revisions {
+		if rev == revision {
+			return true
+		}
+	}
+	return false
+}
@@ -132,3 +132,44 @@ func TestEntityToStringToReturnTrue(t *testing.T) {
 		t.Error("Test Case 6 failed")
 	}
 }
+
+func TestParseETags(t *testing.T) {
+	//Test Case 1
+	if ParseETags("") != nil || ParseETags("*") != nil {
+		t.Error("Test Case 1 failed")
+	}
+
+	//Test Case 2
+	revs := ParseETags(`"3", W/"5" ,"abc"`)
+	if len(revs) != 2 || revs[0] != 3 || revs[1] != 5 {
+		t.Error("Test Case 2 failed")
+	}
+
+	//Test Case 3
+	revs = ParseETags(`"abc"`)
+	if revs == nil || len(revs) != 0 {
+		t.Error("Test Case 3 failed")
+	}
+
+	//Test Case 4
+	if ParseETags(FormatETag(42))[0] != 42 {
+		t.Error("Test Case 4 failed")
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	//Test Case 1
+	if !ETagMatches(nil, 7) {
+		t.Error("Test Case 1 failed")
+	}
+
+	//Test Case 2
+	if !ETagMatches([]int64{2, 7}, 7) || ETagMatches([]int64{2}, 7) {
+		t.Error("Test Case 2 failed")
+	}
+
+	//Test Case 3
+	if ETagMatches([]int64{}, 7) {
+		t.Error("Test Case 3 failed")
+	}
+}